@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"main/eswagger"
+	"main/eswagger/diff"
+)
+
+// runChangelog writes Markdown release notes for the changes between two
+// spec files
+func runChangelog(args []string) error {
+	flags := flag.NewFlagSet("changelog", flag.ExitOnError)
+	out := flags.String("out", "-", `output file, "-" for stdout`)
+	next := flags.Bool("next", false, "only print the suggested next version")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: eswagger changelog [flags] old.yaml new.yaml")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return exitStatus(2)
+	}
+
+	old, err := eswagger.LoadSpec(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := eswagger.LoadSpec(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	changelog := diff.NewChangelog(old, new)
+	if *next {
+		if changelog.Next == "" {
+			return errors.New("the old spec has no semantic version to bump")
+		}
+		fmt.Println(changelog.Next)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := changelog.WriteMarkdown(&buf); err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing changelog: %v", err)
+	}
+	return nil
+}
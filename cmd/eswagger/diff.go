@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"main/eswagger"
+	"main/eswagger/diff"
+)
+
+// runDiff compares two spec files and fails when the new one breaks clients
+// of the old one
+func runDiff(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "text or json")
+	breakingOnly := flags.Bool("breaking", false, "only list the breaking changes")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: eswagger diff [flags] old.yaml new.yaml")
+		fmt.Fprintln(flags.Output(), "\nExits with status 1 when a change breaks existing clients.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return exitStatus(2)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("invalid format %q, expected text or json", *format)
+	}
+
+	old, err := eswagger.LoadSpec(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := eswagger.LoadSpec(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report := diff.Compare(old, new)
+	if *breakingOnly {
+		report.Changes = report.Of(diff.Breaking)
+	}
+
+	if *format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.HasBreaking() {
+		return exitStatus(1)
+	}
+	return nil
+}
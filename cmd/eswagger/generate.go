@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+
+	"main/eswagger"
+	"main/eswagger/harness"
+)
+
+var harnessTemplate = template.Must(template.New("harness").Parse(`// Code generated by eswagger generate. DO NOT EDIT.
+
+package main
+
+import (
+	harness "{{.Harness}}"
+	target "{{.Package}}"
+)
+
+func main() {
+	harness.Main(target.{{.Func}}, {{if .Metadata}}target.{{.Metadata}}{{else}}nil{{end}})
+}
+`))
+
+// runGenerate builds a small program importing the router constructor of the
+// target package next to it, inside its module, and runs it to write the spec
+func runGenerate(args []string) error {
+	flags := flag.NewFlagSet("generate", flag.ExitOnError)
+	pkg := flags.String("pkg", ".", "package holding the router constructor, as an import path or a directory")
+	fn := flags.String("func", "NewRouter", "router constructor, returning a *mux.Router, an *eswagger.ServeMux or an eswagger.RouteSource")
+	metadata := flags.String("metadata", "", "optional function of the package returning eswagger.RouteMetadata")
+	configPath := flags.String("config", "", "YAML or JSON config file")
+	title := flags.String("title", "", "API title")
+	description := flags.String("description", "", "API description")
+	version := flags.String("version", "", "API version")
+	host := flags.String("host", "", "API host")
+	basePath := flags.String("base-path", "", "API base path, stripped from the route paths")
+	environment := flags.String("env", "", "environment selecting the server of the config")
+	format := flags.String("format", "", "json or yaml, defaults to the extension of -out")
+	out := flags.String("out", "swagger.json", `output file, "-" for stdout`)
+	strict := flags.Bool("strict", false, "fail on documentation defects")
+	sourceDocs := flags.Bool("source-docs", false, "use the doc comments of the source for summaries and descriptions")
+	annotations := flags.Bool("annotations", false, "read swaggo style annotations from the handler comments")
+	base := flags.String("base", "", "hand-written Swagger 2.0 spec the generated operations and definitions are merged into, OpenAPI 3 isn't supported")
+	prose := flags.String("prose", "base", "side kept for summaries, descriptions and examples set in both: base or generated")
+	schemas := flags.String("schemas", "generated", "side kept for schemas set in both: base or generated")
+	var overlays []string
+	flags.Func("overlay", "OpenAPI Overlay file applied to the spec (repeatable, applied in order)", func(path string) error {
+		abs, err := filepath.Abs(path)
+		overlays = append(overlays, abs)
+		return err
+	})
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: eswagger generate [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	target, err := goList(*pkg, "{{.ImportPath}} {{.Name}}")
+	if err != nil {
+		return err
+	}
+	importPath, name, _ := strings.Cut(target, " ")
+	if name == "main" {
+		return fmt.Errorf("%s is package main, which can't be imported: move the router constructor to another package", importPath)
+	}
+
+	gomod, err := goCommand("env", "GOMOD")
+	if err != nil {
+		return err
+	}
+	if gomod == "" || gomod == os.DevNull {
+		return errors.New("eswagger generate must run inside a Go module")
+	}
+	root := filepath.Dir(gomod)
+
+	opts := harness.Options{
+		Overrides: eswagger.Config{
+			Title:       *title,
+			Description: *description,
+			Version:     *version,
+			Host:        *host,
+			BasePath:    *basePath,
+			Environment: *environment,
+			SourceDocs:  *sourceDocs,
+			Annotations: *annotations,
+			Overlays:    overlays,
+		},
+		Format: *format,
+		Out:    *out,
+		Strict: *strict,
+		BaseOptions: eswagger.BaseOptions{
+			Prose:   eswagger.Precedence(*prose),
+			Schemas: eswagger.Precedence(*schemas),
+		},
+	}
+	for _, precedence := range []eswagger.Precedence{opts.BaseOptions.Prose, opts.BaseOptions.Schemas} {
+		if precedence != eswagger.PreferBase && precedence != eswagger.PreferGenerated {
+			return fmt.Errorf("invalid precedence %q, expected base or generated", precedence)
+		}
+	}
+	if opts.Format == "" {
+		opts.Format = "json"
+		if ext := filepath.Ext(*out); ext == ".yaml" || ext == ".yml" {
+			opts.Format = "yaml"
+		}
+	}
+	// The harness runs from the module root
+	if opts.Out != "-" {
+		if opts.Out, err = filepath.Abs(opts.Out); err != nil {
+			return err
+		}
+	}
+	if *configPath != "" {
+		if opts.Config, err = filepath.Abs(*configPath); err != nil {
+			return err
+		}
+	}
+	if *base != "" {
+		if opts.Base, err = filepath.Abs(*base); err != nil {
+			return err
+		}
+	}
+
+	dir, err := os.MkdirTemp(root, ".eswagger-")
+	if err != nil {
+		return fmt.Errorf("error creating harness: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var source bytes.Buffer
+	if err := harnessTemplate.Execute(&source, map[string]string{
+		"Harness":  reflect.TypeOf(harness.Options{}).PkgPath(),
+		"Package":  importPath,
+		"Func":     *fn,
+		"Metadata": *metadata,
+	}); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), source.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error creating harness: %v", err)
+	}
+
+	encoded, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "run", "./"+filepath.Base(dir), string(encoded))
+	cmd.Dir = root
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("generation failed: %v", err)
+	}
+	return nil
+}
+
+// goList runs go list on a package and returns the formatted output
+func goList(pkg, format string) (string, error) {
+	return goCommand("list", "-f", format, pkg)
+}
+
+func goCommand(args ...string) (string, error) {
+	cmd := exec.Command("go", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return strings.TrimSpace(string(output)), nil
+}
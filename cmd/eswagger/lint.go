@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"main/eswagger"
+	"main/eswagger/lint"
+)
+
+// runLint checks a spec file with the lint rules and fails when a finding
+// is at least as severe as -fail-on
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := flags.String("config", "", "YAML or JSON file setting the severity of the rules")
+	format := flags.String("format", "text", "text or sarif")
+	out := flags.String("out", "-", `output file, "-" for stdout`)
+	failOn := flags.String("fail-on", "error", "lowest severity failing the command: error, warning, hint or off")
+	listRules := flags.Bool("rules", false, "list the rules and their default severity")
+	overrides := make(map[string]lint.Severity)
+	flags.Func("rule", "set the severity of a rule, e.g. -rule snake-case-properties=off (repeatable)", func(value string) error {
+		id, severity, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected rule=severity, got %q", value)
+		}
+		overrides[id] = lint.Severity(severity)
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: eswagger lint [flags] swagger.yaml")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if *listRules {
+		for _, rule := range lint.Rules() {
+			fmt.Printf("%-24s %-8s %s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		return nil
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitStatus(2)
+	}
+	if *format != "text" && *format != "sarif" {
+		return fmt.Errorf("invalid format %q, expected text or sarif", *format)
+	}
+	failing, ok := failingSeverities[lint.Severity(*failOn)]
+	if !ok {
+		return fmt.Errorf("invalid -fail-on %q, expected error, warning, hint or off", *failOn)
+	}
+
+	var config lint.Config
+	if *configPath != "" {
+		var err error
+		if config, err = lint.LoadConfig(*configPath); err != nil {
+			return err
+		}
+	}
+	if len(overrides) > 0 && config.Rules == nil {
+		config.Rules = make(map[string]lint.Severity)
+	}
+	for id, severity := range overrides {
+		config.Rules[id] = severity
+	}
+
+	path := flags.Arg(0)
+	swagger, err := eswagger.LoadSpec(path)
+	if err != nil {
+		return err
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	result, err := lint.Run(swagger, config)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	doc := lint.Document{URI: path, Source: source}
+	if *format == "sarif" {
+		err = result.WriteSARIF(&buf, doc)
+	} else {
+		err = result.WriteText(&buf, doc)
+	}
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = os.Stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(*out, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(result.Of(failing...)) > 0 {
+		return exitStatus(1)
+	}
+	return nil
+}
+
+// failingSeverities lists the severities failing the command for each
+// value of -fail-on
+var failingSeverities = map[lint.Severity][]lint.Severity{
+	lint.Error:   {lint.Error},
+	lint.Warning: {lint.Error, lint.Warning},
+	lint.Hint:    {lint.Error, lint.Warning, lint.Hint},
+	lint.Off:     nil,
+}
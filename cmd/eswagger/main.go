@@ -0,0 +1,78 @@
+// Command eswagger generates and checks Swagger specs outside of the server.
+//
+//	eswagger generate -pkg ./pkg/router -func New -out doc/swagger.yaml
+//	eswagger diff old.yaml doc/swagger.yaml
+//	eswagger changelog old.yaml doc/swagger.yaml > CHANGELOG.md
+//	eswagger lint -format sarif -out lint.sarif doc/swagger.yaml
+//	eswagger validate doc/swagger.yaml
+//	eswagger overlay -out public.yaml doc/swagger.yaml public.overlay.yaml
+//	eswagger merge -out gateway.yaml users=users/swagger.yaml orders=orders/swagger.yaml
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+const usage = `usage: eswagger <command> [flags]
+
+commands:
+  generate   write the spec of a router constructor without starting the server
+  diff       compare two specs and report the changes breaking clients
+  changelog  write Markdown release notes and suggest the next version
+  lint       check a spec against API design rules
+  validate   check a Swagger 2.0 or OpenAPI 3.x spec against its meta-schema
+  overlay    apply OpenAPI Overlay files to a spec
+  merge      combine the specs of several services into one
+
+Run "eswagger <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch command, args := os.Args[1], os.Args[2:]; command {
+	case "generate":
+		err = runGenerate(args)
+	case "diff":
+		err = runDiff(args)
+	case "changelog":
+		err = runChangelog(args)
+	case "lint":
+		err = runLint(args)
+	case "validate":
+		err = runValidate(args)
+	case "overlay":
+		err = runOverlay(args)
+	case "merge":
+		err = runMerge(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "eswagger: unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+
+	var status exitStatus
+	if errors.As(err, &status) {
+		os.Exit(int(status))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eswagger:", err)
+		os.Exit(1)
+	}
+}
+
+// exitStatus makes eswagger exit with the status without printing an error,
+// the command already reported why
+type exitStatus int
+
+func (s exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(s))
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"main/eswagger"
+	"main/eswagger/merge"
+)
+
+// runMerge combines the spec files of several services into one
+func runMerge(args []string) error {
+	flags := flag.NewFlagSet("merge", flag.ExitOnError)
+	title := flags.String("title", "", "title of the merged spec, the service titles joined by default")
+	description := flags.String("description", "", "description of the merged spec")
+	version := flags.String("version", "", "version of the merged spec, the one shared by the services by default")
+	host := flags.String("host", "", "host of the merged spec")
+	basePath := flags.String("base-path", "", "base path of the merged spec")
+	format := flags.String("format", "", "json or yaml, defaults to the extension of -out")
+	out := flags.String("out", "-", `output file, "-" for stdout`)
+	conflictsFormat := flags.String("conflicts", "text", "format of the conflicts written to stderr: text or json")
+	strict := flags.Bool("strict", false, "fail on any conflict, not only on paths claimed twice")
+	prefixes := make(map[string]string)
+	flags.Func("prefix", `path prefix of a service, e.g. -prefix users=/accounts (repeatable, "/<name>" by default)`, func(value string) error {
+		name, prefix, ok := strings.Cut(value, "=")
+		if !ok {
+			return fmt.Errorf("expected name=prefix, got %q", value)
+		}
+		prefixes[name] = prefix
+		return nil
+	})
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: eswagger merge [flags] [name=]users.yaml [name=]orders.yaml...")
+		fmt.Fprintln(flags.Output(), "\nThe service name defaults to the file name without extension. Exits with")
+		fmt.Fprintln(flags.Output(), "status 1 when two services declare the same operation.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return exitStatus(2)
+	}
+	if *conflictsFormat != "text" && *conflictsFormat != "json" {
+		return fmt.Errorf("invalid conflicts format %q, expected text or json", *conflictsFormat)
+	}
+
+	var services []merge.Service
+	for _, arg := range flags.Args() {
+		name, path, ok := strings.Cut(arg, "=")
+		if !ok {
+			path = arg
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		prefix, ok := prefixes[name]
+		if !ok {
+			prefix = "/" + name
+		}
+		delete(prefixes, name)
+
+		service, err := merge.FromFile(name, prefix, path)
+		if err != nil {
+			return err
+		}
+		services = append(services, service)
+	}
+	for name := range prefixes {
+		return fmt.Errorf("-prefix given for unknown service %s", name)
+	}
+
+	result, err := merge.Merge(services, merge.Options{
+		Title:       *title,
+		Description: *description,
+		Version:     *version,
+		Host:        *host,
+		BasePath:    *basePath,
+	})
+	if err != nil {
+		return err
+	}
+
+	if *conflictsFormat == "json" {
+		encoder := json.NewEncoder(os.Stderr)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result.Conflicts); err != nil {
+			return err
+		}
+	} else {
+		for _, conflict := range result.Conflicts {
+			fmt.Fprintln(os.Stderr, "eswagger: conflict:", conflict)
+		}
+	}
+
+	if *format == "" {
+		*format = "json"
+		if isYAML(*out) || *out == "-" && isYAML(flags.Arg(0)) {
+			*format = "yaml"
+		}
+	}
+	data, err := eswagger.MarshalSpec(result.Swagger, *format)
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+	} else if err = os.WriteFile(*out, data, 0644); err != nil {
+		err = fmt.Errorf("error writing swagger file: %v", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The spec is written anyway so the conflicts can be looked at
+	if len(result.Of(merge.ConflictPath)) > 0 || *strict && len(result.Conflicts) > 0 {
+		return exitStatus(1)
+	}
+	return nil
+}
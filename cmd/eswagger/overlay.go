@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"main/eswagger"
+	"main/eswagger/overlay"
+)
+
+// runOverlay applies overlay files in order to a spec file, e.g. to derive
+// the spec of each audience from the generated one
+func runOverlay(args []string) error {
+	flags := flag.NewFlagSet("overlay", flag.ExitOnError)
+	format := flags.String("format", "", "json or yaml, defaults to the extension of -out")
+	out := flags.String("out", "-", `output file, "-" for stdout`)
+	strict := flags.Bool("strict", false, "fail when an action selects nothing")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: eswagger overlay [flags] swagger.yaml overlay.yaml...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return exitStatus(2)
+	}
+
+	swagger, err := eswagger.LoadSpec(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	var overlays []*overlay.Overlay
+	for _, path := range flags.Args()[1:] {
+		o, err := overlay.Load(path)
+		if err != nil {
+			return err
+		}
+		overlays = append(overlays, o)
+	}
+
+	swagger, diagnostics, err := eswagger.ApplyOverlays(swagger, overlays...)
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, "eswagger:", diagnostic)
+	}
+	if err != nil {
+		return err
+	}
+	if *strict && len(diagnostics) > 0 {
+		return exitStatus(1)
+	}
+
+	if *format == "" {
+		*format = "json"
+		if isYAML(*out) || *out == "-" && isYAML(flags.Arg(0)) {
+			*format = "yaml"
+		}
+	}
+	data, err := eswagger.MarshalSpec(swagger, *format)
+	if err != nil {
+		return err
+	}
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return fmt.Errorf("error writing swagger file: %v", err)
+	}
+	return nil
+}
+
+func isYAML(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"main/eswagger"
+)
+
+// runValidate checks a Swagger 2.0 or OpenAPI 3.x spec file against its
+// meta-schema, its references and, for Swagger 2.0, its examples
+func runValidate(args []string) error {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	format := flags.String("format", "text", "text or json")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: eswagger validate [flags] swagger.yaml")
+		fmt.Fprintln(flags.Output(), "\nExits with status 1 when the spec is invalid.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return exitStatus(2)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("invalid format %q, expected text or json", *format)
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error reading spec file: %v", err)
+	}
+
+	var violations eswagger.SpecViolations
+	if err := eswagger.ValidateSpecDocument(data); err != nil && !errors.As(err, &violations) {
+		return err
+	}
+
+	if *format == "json" {
+		if violations == nil {
+			violations = eswagger.SpecViolations{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(violations); err != nil {
+			return err
+		}
+	} else if len(violations) == 0 {
+		fmt.Printf("%s is a valid spec\n", flags.Arg(0))
+	} else {
+		for _, violation := range violations {
+			fmt.Println(violation)
+		}
+		fmt.Printf("\n%d violations\n", len(violations))
+	}
+
+	if len(violations) > 0 {
+		return exitStatus(1)
+	}
+	return nil
+}
@@ -0,0 +1,183 @@
+package eswagger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// ServiceRoute binds a route pattern such as "PUT /users/{id}" to a method of
+// the service interface
+type ServiceRoute struct {
+	Pattern string
+	Method  string
+}
+
+// ServiceAdapter turns the methods of a service interface implementation
+// into http handlers. Supported method shapes are
+//
+//	Method([ctx context.Context,] [input]) ([output,] [error])
+//
+// The input is decoded like the request of Handle, a scalar input is taken
+// from the single path variable of the route.
+type ServiceAdapter struct {
+	iface reflect.Type
+	impl  reflect.Value
+
+	// ErrorStatus maps an error returned by the service to a status code,
+	// *HTTPError values are honoured before it is called. Clients get the
+	// status text, or the message of a ClientError below 500.
+	ErrorStatus func(error) int
+}
+
+// ClientError is implemented by service errors whose message is meant for
+// the client, e.g. "username already taken". The other errors may hold
+// internal details and are replaced by the status text.
+type ClientError interface {
+	error
+	ClientMessage() string
+}
+
+// NewServiceAdapter creates an adapter for impl, iface must be a pointer to
+// the interface, e.g. (*model.UserInterface)(nil)
+func NewServiceAdapter(iface interface{}, impl interface{}) (*ServiceAdapter, error) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return nil, fmt.Errorf("input is not an interface or pointer to interface")
+	}
+	t = t.Elem()
+
+	if impl == nil {
+		return nil, fmt.Errorf("implementation of %s is nil", t)
+	}
+	if !reflect.TypeOf(impl).Implements(t) {
+		return nil, fmt.Errorf("%T does not implement %s", impl, t)
+	}
+
+	return &ServiceAdapter{
+		iface: t,
+		impl:  reflect.ValueOf(impl),
+	}, nil
+}
+
+// Mount registers a handler on the router for every entry of the route table
+func (a *ServiceAdapter) Mount(router *mux.Router, routes []ServiceRoute) error {
+	for _, route := range routes {
+		handler, err := a.Handler(route.Method)
+		if err != nil {
+			return fmt.Errorf("route %q: %v", route.Pattern, err)
+		}
+
+		method, path := splitPattern(route.Pattern)
+		r := router.Handle(path, handler)
+		if method != "" {
+			r.Methods(method)
+		}
+	}
+	return nil
+}
+
+// Handler returns the http handler calling the named interface method
+func (a *ServiceAdapter) Handler(name string) (http.Handler, error) {
+	if _, ok := a.iface.MethodByName(name); !ok {
+		return nil, fmt.Errorf("method %s not found on %s", name, a.iface)
+	}
+	method := a.impl.MethodByName(name)
+	methodType := method.Type()
+
+	var hasContext bool
+	var inputType reflect.Type
+	for i := 0; i < methodType.NumIn(); i++ {
+		in := methodType.In(i)
+		switch {
+		case i == 0 && in == contextType:
+			hasContext = true
+		case inputType == nil:
+			inputType = in
+		default:
+			return nil, fmt.Errorf("method %s takes more than one input", name)
+		}
+	}
+
+	var outputType reflect.Type
+	errorIndex := -1
+	for i := 0; i < methodType.NumOut(); i++ {
+		out := methodType.Out(i)
+		switch {
+		case out == errorType && i == methodType.NumOut()-1:
+			errorIndex = i
+		case outputType == nil:
+			outputType = out
+		default:
+			return nil, fmt.Errorf("method %s returns more than one output", name)
+		}
+	}
+
+	handler := &typedHandler{
+		endpoint: endpointBinding{
+			Operation:    name,
+			RequestType:  inputType,
+			ResponseType: outputType,
+		},
+	}
+	handler.serve = func(w http.ResponseWriter, r *http.Request) {
+		var args []reflect.Value
+		if hasContext {
+			args = append(args, reflect.ValueOf(r.Context()))
+		}
+		if inputType != nil {
+			input := reflect.New(inputType).Elem()
+			if err := bindRequest(r, input); err != nil {
+				handler.fail(w, r, err)
+				return
+			}
+			args = append(args, input)
+		}
+
+		results := method.Call(args)
+
+		if errorIndex >= 0 {
+			if err, _ := results[errorIndex].Interface().(error); err != nil {
+				handler.fail(w, r, a.mapError(err))
+				return
+			}
+		}
+
+		if outputType == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeResponse(w, r.Method, results[0].Interface())
+	}
+
+	return handler, nil
+}
+
+func (a *ServiceAdapter) mapError(err error) error {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) || a.ErrorStatus == nil {
+		return err
+	}
+
+	status := a.ErrorStatus(err)
+	if status >= http.StatusInternalServerError {
+		// Logged with its details by the handler, never sent
+		return &HTTPError{Status: status, Message: http.StatusText(status), Err: err}
+	}
+
+	message := http.StatusText(status)
+	var clientErr ClientError
+	if errors.As(err, &clientErr) {
+		message = clientErr.ClientMessage()
+	}
+	return &HTTPError{Status: status, Message: message}
+}
@@ -0,0 +1,142 @@
+package eswagger
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type accountService interface {
+	Create(ctx context.Context, account handleUser) (handleUser, error)
+	Delete(id int) error
+	Ping()
+	Rename(ctx context.Context, account handleUser) error
+}
+
+var (
+	errTaken    = takenError{"ada"}
+	errNotFound = errors.New("account 3 not found in shard 7")
+)
+
+type takenError struct{ name string }
+
+func (e takenError) Error() string {
+	return "duplicate key value violates unique constraint users_name_key"
+}
+
+func (e takenError) ClientMessage() string {
+	return "username " + e.name + " is already taken"
+}
+
+type accounts struct{}
+
+func (accounts) Create(ctx context.Context, account handleUser) (handleUser, error) {
+	if account.Name == "ada" {
+		return handleUser{}, errTaken
+	}
+	account.ID = 1
+	return account, nil
+}
+
+func (accounts) Delete(id int) error {
+	if id == 3 {
+		return errNotFound
+	}
+	return nil
+}
+
+func (accounts) Ping() {}
+
+func (accounts) Rename(ctx context.Context, account handleUser) error {
+	return errors.New("connection reset by peer")
+}
+
+func TestServiceAdapter(t *testing.T) {
+	adapter, err := NewServiceAdapter((*accountService)(nil), accounts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	adapter.ErrorStatus = func(err error) int {
+		switch {
+		case errors.Is(err, errNotFound):
+			return http.StatusNotFound
+		case errors.As(err, new(takenError)):
+			return http.StatusConflict
+		}
+		return http.StatusInternalServerError
+	}
+
+	router := mux.NewRouter()
+	err = adapter.Mount(router, []ServiceRoute{
+		{Pattern: "POST /accounts", Method: "Create"},
+		{Pattern: "DELETE /accounts/{id}", Method: "Delete"},
+		{Pattern: "GET /ping", Method: "Ping"},
+		{Pattern: "PUT /accounts", Method: "Rename"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		status   int
+		response string
+	}{
+		{"input and output", "POST", "/accounts", `{"name":"bob"}`, 201, `{"id":1,"name":"bob"}`},
+		{"client-facing error", "POST", "/accounts", `{"name":"ada"}`, 409, `{"message":"username ada is already taken"}`},
+		{"scalar input", "DELETE", "/accounts/2", "", 204, ""},
+		{"plain client error", "DELETE", "/accounts/3", "", 404, `{"message":"Not Found"}`},
+		{"invalid scalar input", "DELETE", "/accounts/x", "", 400, ""},
+		{"no input or output", "GET", "/ping", "", 204, ""},
+		{"server error", "PUT", "/accounts", `{"name":"bob"}`, 500, `{"message":"Internal Server Error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.response != "" && strings.TrimSpace(w.Body.String()) != tt.response {
+				t.Errorf("body = %s, want %s", w.Body, tt.response)
+			}
+		})
+	}
+}
+
+func TestNewServiceAdapterErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		iface interface{}
+		impl  interface{}
+	}{
+		{"not a pointer", accountService(nil), accounts{}},
+		{"not an interface", new(accounts), accounts{}},
+		{"nil implementation", (*accountService)(nil), nil},
+		{"not implemented", (*accountService)(nil), struct{}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewServiceAdapter(tt.iface, tt.impl); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+
+	adapter, err := NewServiceAdapter((*accountService)(nil), accounts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := adapter.Handler("Missing"); err == nil {
+		t.Error("expected an error for an unknown method")
+	}
+}
@@ -0,0 +1,446 @@
+package eswagger
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/spec"
+)
+
+// annotation is a swaggo style comment line such as
+// `@Param id path int true "User ID"`
+type annotation struct {
+	Name  string // lower-cased, e.g. param
+	Value string
+}
+
+type parsedFile struct {
+	fset *token.FileSet
+	file *ast.File
+}
+
+// annotationFiles caches the parsed source files holding handlers
+var annotationFiles sync.Map // file name -> *parsedFile, nil when it couldn't be parsed
+
+// handlerPC returns the entry of the handler function of a route, zero when
+// the handler isn't a function
+func (r Route) handlerPC() uintptr {
+	if bound, ok := r.Handler.(boundHandler); ok {
+		return bound.binding().Func
+	}
+	value := reflect.ValueOf(r.Handler)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return 0
+	}
+	return value.Pointer()
+}
+
+// handlerAnnotations reads the swaggo annotations of the function declaring
+// the handler at pc. For a closure the comments of the enclosing declaration
+// are used, as in
+//
+//	// @Summary Create a user
+//	// @Router /users [post]
+//	func CreateUser(svc model.UserInterface) http.HandlerFunc {
+//		return func(w http.ResponseWriter, r *http.Request) { ... }
+//	}
+func handlerAnnotations(pc uintptr) []annotation {
+	f := runtime.FuncForPC(pc)
+	if pc == 0 || f == nil {
+		return nil
+	}
+	fileName, line := f.FileLine(f.Entry())
+
+	cached, ok := annotationFiles.Load(fileName)
+	if !ok {
+		var parsed *parsedFile
+		fset := token.NewFileSet()
+		if file, err := parser.ParseFile(fset, fileName, nil, parser.ParseComments); err == nil {
+			parsed = &parsedFile{fset: fset, file: file}
+		}
+		cached, _ = annotationFiles.LoadOrStore(fileName, parsed)
+	}
+	parsed, _ := cached.(*parsedFile)
+	if parsed == nil {
+		return nil
+	}
+
+	for _, decl := range parsed.file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Doc == nil {
+			continue
+		}
+		if parsed.fset.Position(fn.Pos()).Line <= line && line <= parsed.fset.Position(fn.End()).Line {
+			return parseAnnotations(fn.Doc.Text())
+		}
+	}
+	return nil
+}
+
+func parseAnnotations(comment string) []annotation {
+	var annotations []annotation
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		name, value := line[1:], ""
+		if i := strings.IndexAny(name, " \t"); i >= 0 {
+			name, value = name[:i], name[i:]
+		}
+		annotations = append(annotations, annotation{Name: strings.ToLower(name), Value: strings.TrimSpace(value)})
+	}
+	return annotations
+}
+
+// routesAnnotated reports whether the @Router lines, if any, name the route
+func (g *Generator) routesAnnotated(annotations []annotation, method, path string) bool {
+	routed := false
+	for _, a := range annotations {
+		if a.Name != "router" {
+			continue
+		}
+		routed = true
+
+		fields := strings.Fields(a.Value)
+		if len(fields) != 2 {
+			continue
+		}
+		routerPath, _ := parseTemplate(ConvertPathTemplate(fields[0]))
+		routerMethod := strings.ToUpper(strings.Trim(fields[1], "[]"))
+		fullPath := strings.TrimSuffix(g.swagger.BasePath, "/") + path
+		if routerMethod == method && (routerPath == path || routerPath == fullPath) {
+			return true
+		}
+	}
+	return !routed
+}
+
+// applyAnnotations merges the annotations into an operation built by
+// reflection: the annotations supply the prose, extra parameters and
+// responses, the schemas found by reflection are kept. It returns whether
+// the operationId was set by an @ID annotation.
+func (g *Generator) applyAnnotations(operation *spec.Operation, annotations []annotation, method, path string) bool {
+	explicitID := false
+	var description []string
+
+	for _, a := range annotations {
+		switch a.Name {
+		case "summary":
+			operation.Summary = a.Value
+		case "description":
+			description = append(description, a.Value)
+		case "id":
+			operation.ID = a.Value
+			explicitID = true
+		case "tags":
+			operation.Tags = splitList(a.Value)
+		case "accept":
+			operation.Consumes = mimeTypes(a.Value)
+		case "produce":
+			operation.Produces = mimeTypes(a.Value)
+		case "deprecated":
+			operation.Deprecated = true
+		case "param":
+			g.applyParamAnnotation(operation, a.Value, method, path)
+		case "success", "failure", "response":
+			g.applyResponseAnnotation(operation, a.Value, method, path)
+		case "security":
+			requirement := make(map[string][]string)
+			for _, scheme := range strings.Split(a.Value, "&&") {
+				name, scopes, _ := strings.Cut(strings.TrimSpace(scheme), "[")
+				requirement[strings.TrimSpace(name)] = append([]string{}, splitList(strings.TrimSuffix(scopes, "]"))...)
+			}
+			operation.Security = append(operation.Security, requirement)
+		}
+	}
+
+	if len(description) > 0 {
+		operation.Description = strings.Join(description, "\n")
+	}
+	return explicitID
+}
+
+// applyParamAnnotation handles `@Param name in type required "description" attributes`
+func (g *Generator) applyParamAnnotation(operation *spec.Operation, value, method, path string) {
+	fields := annotationFields(value)
+	if len(fields) < 4 {
+		g.report(DiagInvalidAnnotation, method, path, "invalid @Param %q", value)
+		return
+	}
+	name, in, typeName := fields[0], fields[1], fields[2]
+	required, _ := strconv.ParseBool(fields[3])
+	paramDescription := ""
+	if len(fields) > 4 {
+		paramDescription = fields[4]
+	}
+
+	index := -1
+	for i, param := range operation.Parameters {
+		if param.In == in && (param.Name == name || in == "body") {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		param := spec.Parameter{ParamProps: spec.ParamProps{Name: name, In: in}}
+		if in == "body" {
+			schema := g.annotationSchema("object", typeName, method, path)
+			if schema == nil {
+				return
+			}
+			param.Schema = schema
+		} else {
+			param.SimpleSchema = annotationSimpleSchema(typeName)
+		}
+		operation.Parameters = append(operation.Parameters, param)
+		index = len(operation.Parameters) - 1
+	}
+
+	param := &operation.Parameters[index]
+	param.Required = required || in == "path"
+	if paramDescription != "" {
+		param.Description = paramDescription
+	}
+	if in != "body" {
+		applyParamAttributes(param, value)
+	}
+}
+
+var attributePattern = regexp.MustCompile(`(\w+)\(([^)]*)\)`)
+
+// applyParamAttributes reads attributes such as enums(a,b) or minimum(1)
+func applyParamAttributes(param *spec.Parameter, value string) {
+	// Attributes follow the quoted description
+	if i := strings.LastIndex(value, `"`); i >= 0 {
+		value = value[i+1:]
+	}
+
+	for _, match := range attributePattern.FindAllStringSubmatch(value, -1) {
+		switch strings.ToLower(match[1]) {
+		case "enums":
+			for _, option := range splitList(match[2]) {
+				param.Enum = append(param.Enum, typedValue(param.Type, option))
+			}
+		case "default":
+			param.Default = typedValue(param.Type, match[2])
+		case "format":
+			param.Format = match[2]
+		case "minimum":
+			if n, err := strconv.ParseFloat(match[2], 64); err == nil {
+				param.Minimum = &n
+			}
+		case "maximum":
+			if n, err := strconv.ParseFloat(match[2], 64); err == nil {
+				param.Maximum = &n
+			}
+		case "minlength":
+			if n, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+				param.MinLength = &n
+			}
+		case "maxlength":
+			if n, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+				param.MaxLength = &n
+			}
+		}
+	}
+}
+
+// typedValue converts an attribute value to the type of the parameter
+func typedValue(paramType, value string) interface{} {
+	switch paramType {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// applyResponseAnnotation handles `@Success 200 {object} model.User "description"`
+func (g *Generator) applyResponseAnnotation(operation *spec.Operation, value, method, path string) {
+	fields := annotationFields(value)
+	if len(fields) == 0 {
+		g.report(DiagInvalidAnnotation, method, path, "invalid response annotation %q", value)
+		return
+	}
+
+	var schema *spec.Schema
+	responseDescription := ""
+	rest := fields[1:]
+	if len(rest) >= 2 && strings.HasPrefix(rest[0], "{") {
+		schema = g.annotationSchema(strings.Trim(rest[0], "{}"), rest[1], method, path)
+		rest = rest[2:]
+	}
+	if len(rest) > 0 {
+		responseDescription = rest[0]
+	}
+
+	if operation.Responses == nil {
+		operation.Responses = &spec.Responses{}
+	}
+
+	if fields[0] == "default" {
+		response := spec.Response{}
+		if operation.Responses.Default != nil {
+			response = *operation.Responses.Default
+		}
+		mergeAnnotatedResponse(&response, schema, responseDescription)
+		operation.Responses.Default = &response
+		return
+	}
+
+	code, err := strconv.Atoi(fields[0])
+	if err != nil {
+		g.report(DiagInvalidAnnotation, method, path, "invalid status code in %q", value)
+		return
+	}
+	if operation.Responses.StatusCodeResponses == nil {
+		operation.Responses.StatusCodeResponses = make(map[int]spec.Response)
+	}
+	response, ok := operation.Responses.StatusCodeResponses[code]
+	if !ok {
+		response.Description = http.StatusText(code)
+	}
+	mergeAnnotatedResponse(&response, schema, responseDescription)
+	operation.Responses.StatusCodeResponses[code] = response
+}
+
+// mergeAnnotatedResponse keeps the schema found by reflection, the annotation
+// only supplies one for responses reflection knows nothing about
+func mergeAnnotatedResponse(response *spec.Response, schema *spec.Schema, description string) {
+	if response.Schema == nil {
+		response.Schema = schema
+	}
+	if description != "" {
+		response.Description = description
+	}
+}
+
+// annotationSchema builds the schema of `{kind} Type`, named types must be
+// definitions of the spec
+func (g *Generator) annotationSchema(kind, typeName, method, path string) *spec.Schema {
+	if kind == "array" || strings.HasPrefix(typeName, "[]") {
+		items := g.annotationSchema("object", strings.TrimPrefix(typeName, "[]"), method, path)
+		if items == nil {
+			return nil
+		}
+		return spec.ArrayProperty(items)
+	}
+
+	if simple := annotationSimpleSchema(typeName); simple.Type != "object" {
+		return &spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{simple.Type}, Format: simple.Format}}
+	}
+
+	// model.User refers to the definition User
+	name := typeName[strings.LastIndex(typeName, ".")+1:]
+	if _, ok := g.swagger.Definitions[name]; !ok {
+		g.report(DiagInvalidAnnotation, method, path, "type %s is not a definition of the spec", typeName)
+		return nil
+	}
+	return spec.RefSchema("#/definitions/" + name)
+}
+
+// annotationSimpleSchema maps the swaggo type names of parameters
+func annotationSimpleSchema(typeName string) spec.SimpleSchema {
+	if strings.HasPrefix(typeName, "[]") {
+		items := annotationSimpleSchema(strings.TrimPrefix(typeName, "[]"))
+		return spec.SimpleSchema{Type: "array", Items: &spec.Items{SimpleSchema: items}}
+	}
+
+	switch strings.ToLower(typeName) {
+	case "string":
+		return spec.SimpleSchema{Type: "string"}
+	case "int", "integer", "int64", "uint", "uint64":
+		return spec.SimpleSchema{Type: "integer", Format: "int64"}
+	case "int32", "uint32":
+		return spec.SimpleSchema{Type: "integer", Format: "int32"}
+	case "number", "float", "float64":
+		return spec.SimpleSchema{Type: "number"}
+	case "bool", "boolean":
+		return spec.SimpleSchema{Type: "boolean"}
+	case "file":
+		return spec.SimpleSchema{Type: "file"}
+	default:
+		return spec.SimpleSchema{Type: "object"}
+	}
+}
+
+var shortMimeTypes = map[string]string{
+	"json":                  "application/json",
+	"xml":                   "application/xml",
+	"plain":                 "text/plain",
+	"html":                  "text/html",
+	"mpfd":                  "multipart/form-data",
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+	"json-api":              "application/vnd.api+json",
+	"json-stream":           "application/x-json-stream",
+	"octet-stream":          "application/octet-stream",
+	"png":                   "image/png",
+	"jpeg":                  "image/jpeg",
+	"gif":                   "image/gif",
+}
+
+func mimeTypes(value string) []string {
+	var types []string
+	for _, name := range splitList(value) {
+		if mime, ok := shortMimeTypes[name]; ok {
+			name = mime
+		}
+		types = append(types, name)
+	}
+	return types
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// annotationFields splits an annotation on spaces, a quoted string is one
+// field
+func annotationFields(value string) []string {
+	var fields []string
+	for value = strings.TrimSpace(value); value != ""; value = strings.TrimSpace(value) {
+		if value[0] == '"' {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				fields = append(fields, value[1:])
+				break
+			}
+			fields = append(fields, value[1:end+1])
+			value = value[end+2:]
+			continue
+		}
+
+		end := strings.IndexAny(value, " \t")
+		if end < 0 {
+			fields = append(fields, value)
+			break
+		}
+		fields = append(fields, value[:end])
+		value = value[end:]
+	}
+	return fields
+}
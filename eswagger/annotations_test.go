@@ -0,0 +1,137 @@
+package eswagger
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/gorilla/mux"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	comment := "getUser returns a user.\n@Summary  Get a user\n@Param id path int true \"User ID\"\n@deprecated\nNot an @annotation\n"
+	want := []annotation{
+		{Name: "summary", Value: "Get a user"},
+		{Name: "param", Value: `id path int true "User ID"`},
+		{Name: "deprecated"},
+	}
+	if got := parseAnnotations(comment); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseAnnotations() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestAnnotationFields(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{`id path int true "User ID"`, []string{"id", "path", "int", "true", "User ID"}},
+		{`200 {object} model.User "the user" `, []string{"200", "{object}", "model.User", "the user"}},
+		{`q query string false "unterminated`, []string{"q", "query", "string", "false", "unterminated"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := annotationFields(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("annotationFields(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestApplyAnnotations(t *testing.T) {
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	g.swagger.Definitions = spec.Definitions{"User": spec.Schema{}}
+
+	minimum := 1.0
+	tests := []struct {
+		name        string
+		annotations []annotation
+		check       func(operation *spec.Operation) bool
+		explicitID  bool
+		invalid     int
+	}{
+		{"prose", []annotation{{"summary", "Get a user"}, {"description", "First line"}, {"description", "second line"}, {"tags", "users, admin"}}, func(o *spec.Operation) bool {
+			return o.Summary == "Get a user" && o.Description == "First line\nsecond line" && reflect.DeepEqual(o.Tags, []string{"users", "admin"})
+		}, false, 0},
+		{"id", []annotation{{"id", "getUser"}}, func(o *spec.Operation) bool { return o.ID == "getUser" }, true, 0},
+		{"mime types", []annotation{{"accept", "json"}, {"produce", "json, application/pdf"}}, func(o *spec.Operation) bool {
+			return reflect.DeepEqual(o.Consumes, []string{"application/json"}) && reflect.DeepEqual(o.Produces, []string{"application/json", "application/pdf"})
+		}, false, 0},
+		{"query param", []annotation{{"param", `page query int false "Page" minimum(1) default(1) enums(1,2)`}}, func(o *spec.Operation) bool {
+			p := o.Parameters[0]
+			return p.Name == "page" && p.In == "query" && p.Type == "integer" && !p.Required && p.Description == "Page" &&
+				reflect.DeepEqual(p.Minimum, &minimum) && p.Default == int64(1) && reflect.DeepEqual(p.Enum, []interface{}{int64(1), int64(2)})
+		}, false, 0},
+		{"path param always required", []annotation{{"param", `id path string false "ID"`}}, func(o *spec.Operation) bool {
+			return o.Parameters[0].Required
+		}, false, 0},
+		{"body param", []annotation{{"param", `user body model.User true "The user"`}}, func(o *spec.Operation) bool {
+			return o.Parameters[0].Schema.Ref.String() == "#/definitions/User"
+		}, false, 0},
+		{"responses", []annotation{{"success", `200 {array} model.User "Users"`}, {"failure", `404 {string} string`}, {"response", `default {object} model.User "Error"`}}, func(o *spec.Operation) bool {
+			ok := o.Responses.StatusCodeResponses[200]
+			missing := o.Responses.StatusCodeResponses[404]
+			return ok.Description == "Users" && ok.Schema.Items.Schema.Ref.String() == "#/definitions/User" &&
+				missing.Description == "Not Found" && missing.Schema.Type[0] == "string" &&
+				o.Responses.Default.Description == "Error"
+		}, false, 0},
+		{"security", []annotation{{"security", "ApiKey && OAuth2[read, write]"}}, func(o *spec.Operation) bool {
+			return reflect.DeepEqual(o.Security, []map[string][]string{{"ApiKey": {}, "OAuth2": {"read", "write"}}})
+		}, false, 0},
+		{"deprecated", []annotation{{"deprecated", ""}}, func(o *spec.Operation) bool { return o.Deprecated }, false, 0},
+		{"invalid", []annotation{{"param", "id path"}, {"success", "ok"}, {"success", `200 {object} model.Missing`}}, func(o *spec.Operation) bool {
+			return len(o.Parameters) == 0
+		}, false, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g.diagnostics = nil
+			operation := &spec.Operation{}
+			explicitID := g.applyAnnotations(operation, tt.annotations, "GET", "/users/{id}")
+			if !tt.check(operation) {
+				t.Errorf("operation = %+v", operation)
+			}
+			if explicitID != tt.explicitID {
+				t.Errorf("explicit id = %v, want %v", explicitID, tt.explicitID)
+			}
+			if invalid := len(g.diagnostics.Of(DiagInvalidAnnotation)); invalid != tt.invalid {
+				t.Errorf("%d invalid annotations reported, want %d: %v", invalid, tt.invalid, g.diagnostics)
+			}
+		})
+	}
+}
+
+// annotatedGetUser is documented by its annotations
+//
+//	@Summary	Get a user
+//	@Tags		users
+//	@Param		id	path	int	true	"User ID"
+//	@Success	200	{object}	handleUser	"The user"
+//	@Router		/users/{id} [get]
+func annotatedGetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func TestHandlerAnnotations(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", annotatedGetUser()).Methods("GET")
+	router.HandleFunc("/users/{id}/avatar", annotatedGetUser()).Methods("GET") // not its @Router
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0", Annotations: true})
+	g.RegisterEndpoint("/users/{id}", "GET", nil, handleUser{})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := g.GetSwaggerSpec().Paths.Paths
+	get := paths["/users/{id}"].Get
+	if get.Summary != "Get a user" || !reflect.DeepEqual(get.Tags, []string{"users"}) {
+		t.Errorf("GET /users/{id} = %q %q", get.Summary, get.Tags)
+	}
+	if response := get.Responses.StatusCodeResponses[200]; response.Description != "The user" || response.Schema == nil {
+		t.Errorf("200 response = %+v", response)
+	}
+	if avatar := paths["/users/{id}/avatar"].Get; avatar.Summary == "Get a user" {
+		t.Error("annotations applied to a route their @Router doesn't name")
+	}
+}
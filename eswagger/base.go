@@ -0,0 +1,388 @@
+package eswagger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-openapi/spec"
+)
+
+// Precedence picks the side kept when the base spec and the generated one
+// both set a field
+type Precedence string
+
+const (
+	// PrecedenceDefault uses the default of the field group, see BaseOptions
+	PrecedenceDefault Precedence = ""
+	PreferBase        Precedence = "base"
+	PreferGenerated   Precedence = "generated"
+)
+
+// BaseOptions configures how the generated spec is merged into a base spec
+type BaseOptions struct {
+	// Prose covers titles, summaries, descriptions and examples, the base
+	// wins by default so hand-curated text survives regeneration
+	Prose Precedence `json:"prose" yaml:"prose"`
+	// Schemas covers definitions, parameter and response types, the
+	// generated spec wins by default so they follow the Go types
+	Schemas Precedence `json:"schemas" yaml:"schemas"`
+}
+
+func (o BaseOptions) baseProse() bool {
+	return o.Prose != PreferGenerated
+}
+
+func (o BaseOptions) baseSchemas() bool {
+	return o.Schemas == PreferBase
+}
+
+// NewGeneratorFromSpec returns a generator building on a hand-written spec.
+// Generated operations and definitions are merged into a copy of base on
+// every build: those only in base are kept, those only generated are added
+// and for those in both opts decides field by field. The document level
+// settings of base, such as host and security definitions, are kept and the
+// config only fills the missing ones, except the version. An error is
+// returned when base can't be encoded, e.g. for an extension holding a
+// function.
+func NewGeneratorFromSpec(config Config, base *spec.Swagger, opts BaseOptions) (*Generator, error) {
+	g := NewGenerator(config)
+	merged, err := mergeBase(base, g.swagger, opts)
+	if err != nil {
+		return nil, err
+	}
+	g.base, g.baseOptions = base, opts
+	g.swagger = merged
+	g.matchers = newPathMatchers(g.swagger)
+	return g, nil
+}
+
+// NewGeneratorFromFile is NewGeneratorFromSpec with a base spec read from a
+// JSON or YAML file. The base must be a Swagger 2.0 document like the
+// generated spec, OpenAPI 3 files are refused.
+func NewGeneratorFromFile(config Config, path string, opts BaseOptions) (*Generator, error) {
+	base, err := LoadSpec(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewGeneratorFromSpec(config, base, opts)
+}
+
+// mergeBase returns a copy of base with the generated spec merged in
+func mergeBase(base, generated *spec.Swagger, opts BaseOptions) (*spec.Swagger, error) {
+	merged, err := copySpec(base)
+	if err != nil {
+		return nil, err
+	}
+
+	if merged.Info == nil {
+		merged.Info = generated.Info
+	} else if generated.Info != nil {
+		mergeProse(opts.baseProse(), &merged.Info.Title, generated.Info.Title)
+		mergeProse(opts.baseProse(), &merged.Info.Description, generated.Info.Description)
+		mergeProse(opts.baseProse(), &merged.Info.TermsOfService, generated.Info.TermsOfService)
+		// The version changes with every release of the code
+		if generated.Info.Version != "" {
+			merged.Info.Version = generated.Info.Version
+		}
+		if merged.Info.Contact == nil {
+			merged.Info.Contact = generated.Info.Contact
+		}
+		if merged.Info.License == nil {
+			merged.Info.License = generated.Info.License
+		}
+	}
+
+	fillString(&merged.Host, generated.Host)
+	fillString(&merged.BasePath, generated.BasePath)
+	if len(merged.Schemes) == 0 {
+		merged.Schemes = generated.Schemes
+	}
+	if len(merged.Consumes) == 0 {
+		merged.Consumes = generated.Consumes
+	}
+	if len(merged.Produces) == 0 {
+		merged.Produces = generated.Produces
+	}
+	if merged.ExternalDocs == nil {
+		merged.ExternalDocs = generated.ExternalDocs
+	}
+	if merged.Security == nil {
+		merged.Security = generated.Security
+	}
+	for name, scheme := range generated.SecurityDefinitions {
+		if merged.SecurityDefinitions == nil {
+			merged.SecurityDefinitions = make(spec.SecurityDefinitions)
+		}
+		if _, ok := merged.SecurityDefinitions[name]; !ok {
+			merged.SecurityDefinitions[name] = scheme
+		}
+	}
+	for key, value := range generated.Extensions {
+		if _, ok := merged.Extensions[key]; !ok {
+			merged.AddExtension(key, value)
+		}
+	}
+
+	merged.Tags = mergeTags(merged.Tags, generated.Tags, opts)
+
+	if merged.Paths == nil {
+		merged.Paths = &spec.Paths{}
+	}
+	if merged.Paths.Paths == nil {
+		merged.Paths.Paths = make(map[string]spec.PathItem)
+	}
+	if generated.Paths != nil {
+		for path, generatedItem := range generated.Paths.Paths {
+			item, ok := merged.Paths.Paths[path]
+			if !ok {
+				merged.Paths.Paths[path] = generatedItem
+				continue
+			}
+			for method, operation := range operationsOf(generatedItem) {
+				if existing := operationsOf(item)[method]; existing != nil {
+					operation = mergeOperation(existing, operation, opts)
+				}
+				setOperation(&item, method, operation)
+			}
+			merged.Paths.Paths[path] = item
+		}
+	}
+
+	if merged.Definitions == nil {
+		merged.Definitions = make(spec.Definitions)
+	}
+	for name, definition := range generated.Definitions {
+		if existing, ok := merged.Definitions[name]; ok {
+			definition = mergeSchema(existing, definition, opts)
+		}
+		merged.Definitions[name] = definition
+	}
+	return merged, nil
+}
+
+// copySpec deep copies a spec so merging never changes the base
+func copySpec(swagger *spec.Swagger) (*spec.Swagger, error) {
+	copied := new(spec.Swagger)
+	data, err := json.Marshal(swagger)
+	if err == nil {
+		err = json.Unmarshal(data, copied)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error copying base spec: %v", err)
+	}
+	return copied, nil
+}
+
+// mergeProse sets a text of the base from the generated one when the base
+// has none or the generated text wins
+func mergeProse(preferBase bool, base *string, generated string) {
+	if generated != "" && (*base == "" || !preferBase) {
+		*base = generated
+	}
+}
+
+func fillString(base *string, generated string) {
+	if *base == "" {
+		*base = generated
+	}
+}
+
+func mergeTags(base, generated []spec.Tag, opts BaseOptions) []spec.Tag {
+	index := make(map[string]int, len(base))
+	for i, tag := range base {
+		index[tag.Name] = i
+	}
+	for _, tag := range generated {
+		i, ok := index[tag.Name]
+		if !ok {
+			index[tag.Name] = len(base)
+			base = append(base, tag)
+			continue
+		}
+		mergeProse(opts.baseProse(), &base[i].Description, tag.Description)
+		if base[i].ExternalDocs == nil {
+			base[i].ExternalDocs = tag.ExternalDocs
+		}
+	}
+	return base
+}
+
+func setOperation(item *spec.PathItem, method string, operation *spec.Operation) {
+	switch method {
+	case "GET":
+		item.Get = operation
+	case "POST":
+		item.Post = operation
+	case "PUT":
+		item.Put = operation
+	case "PATCH":
+		item.Patch = operation
+	case "DELETE":
+		item.Delete = operation
+	case "HEAD":
+		item.Head = operation
+	case "OPTIONS":
+		item.Options = operation
+	}
+}
+
+// mergeOperation merges a generated operation into the base one. The
+// curation of the base, its operationId, tags and security, is kept.
+func mergeOperation(base, generated *spec.Operation, opts BaseOptions) *spec.Operation {
+	merged := *base
+	mergeProse(opts.baseProse(), &merged.Summary, generated.Summary)
+	mergeProse(opts.baseProse(), &merged.Description, generated.Description)
+	fillString(&merged.ID, generated.ID)
+	if len(merged.Tags) == 0 {
+		merged.Tags = generated.Tags
+	}
+	if merged.Security == nil {
+		merged.Security = generated.Security
+	}
+	if merged.ExternalDocs == nil {
+		merged.ExternalDocs = generated.ExternalDocs
+	}
+	merged.Deprecated = merged.Deprecated || generated.Deprecated
+	if len(merged.Consumes) == 0 || !opts.baseSchemas() && len(generated.Consumes) > 0 {
+		merged.Consumes = generated.Consumes
+	}
+	if len(merged.Produces) == 0 || !opts.baseSchemas() && len(generated.Produces) > 0 {
+		merged.Produces = generated.Produces
+	}
+	for key, value := range generated.Extensions {
+		if _, ok := merged.Extensions[key]; !ok {
+			merged.AddExtension(key, value)
+		}
+	}
+
+	merged.Parameters = nil
+	generatedParams := make(map[string]spec.Parameter)
+	for _, param := range generated.Parameters {
+		generatedParams[param.In+" "+param.Name] = param
+	}
+	for _, param := range base.Parameters {
+		key := param.In + " " + param.Name
+		if generatedParam, ok := generatedParams[key]; ok {
+			param = mergeParameter(param, generatedParam, opts)
+			delete(generatedParams, key)
+		}
+		merged.Parameters = append(merged.Parameters, param)
+	}
+	for _, param := range generated.Parameters {
+		if _, ok := generatedParams[param.In+" "+param.Name]; ok {
+			merged.Parameters = append(merged.Parameters, param)
+		}
+	}
+
+	if generated.Responses != nil {
+		if merged.Responses == nil {
+			merged.Responses = generated.Responses
+		} else {
+			responses := *merged.Responses
+			responses.StatusCodeResponses = make(map[int]spec.Response)
+			for code, response := range merged.Responses.StatusCodeResponses {
+				responses.StatusCodeResponses[code] = response
+			}
+			for code, response := range generated.Responses.StatusCodeResponses {
+				if existing, ok := responses.StatusCodeResponses[code]; ok {
+					response = mergeResponse(existing, response, opts)
+				}
+				responses.StatusCodeResponses[code] = response
+			}
+			if generated.Responses.Default != nil {
+				response := *generated.Responses.Default
+				if responses.Default != nil {
+					response = mergeResponse(*responses.Default, response, opts)
+				}
+				responses.Default = &response
+			}
+			merged.Responses = &responses
+		}
+	}
+	return &merged
+}
+
+func mergeParameter(base, generated spec.Parameter, opts BaseOptions) spec.Parameter {
+	merged := generated
+	if opts.baseSchemas() {
+		merged = base
+	}
+	merged.Description = base.Description
+	mergeProse(opts.baseProse(), &merged.Description, generated.Description)
+
+	switch {
+	case base.Schema != nil && generated.Schema != nil:
+		schema := mergeSchema(*base.Schema, *generated.Schema, opts)
+		merged.Schema = &schema
+	case merged.Schema == nil && base.Schema != nil:
+		merged.Schema = base.Schema
+	case merged.Schema == nil:
+		merged.Schema = generated.Schema
+	}
+	return merged
+}
+
+func mergeResponse(base, generated spec.Response, opts BaseOptions) spec.Response {
+	merged := generated
+	if opts.baseSchemas() {
+		merged = base
+	}
+	merged.Description = base.Description
+	mergeProse(opts.baseProse(), &merged.Description, generated.Description)
+
+	switch {
+	case base.Schema != nil && generated.Schema != nil:
+		schema := mergeSchema(*base.Schema, *generated.Schema, opts)
+		merged.Schema = &schema
+	case merged.Schema == nil && base.Schema != nil:
+		merged.Schema = base.Schema
+	case merged.Schema == nil:
+		merged.Schema = generated.Schema
+	}
+
+	// Examples are prose
+	switch {
+	case len(base.Examples) > 0 && (opts.baseProse() || len(generated.Examples) == 0):
+		merged.Examples = base.Examples
+	case len(generated.Examples) > 0:
+		merged.Examples = generated.Examples
+	}
+	return merged
+}
+
+// mergeSchema takes the structure of a schema from the side Schemas prefers
+// and the prose from the side Prose prefers, property by property
+func mergeSchema(base, generated spec.Schema, opts BaseOptions) spec.Schema {
+	merged, other := generated, base
+	if opts.baseSchemas() {
+		merged, other = base, generated
+	}
+	mergeSchemaProse(&merged, other, opts.baseSchemas() == opts.baseProse())
+	return merged
+}
+
+// mergeSchemaProse copies the prose of other into schema, where schema has
+// none or always when keepOwn is false
+func mergeSchemaProse(schema *spec.Schema, other spec.Schema, keepOwn bool) {
+	mergeProse(keepOwn, &schema.Title, other.Title)
+	mergeProse(keepOwn, &schema.Description, other.Description)
+	if other.Example != nil && (schema.Example == nil || !keepOwn) {
+		schema.Example = other.Example
+	}
+
+	if len(schema.Properties) > 0 && len(other.Properties) > 0 {
+		properties := make(spec.SchemaProperties, len(schema.Properties))
+		for name, property := range schema.Properties {
+			if otherProperty, ok := other.Properties[name]; ok {
+				mergeSchemaProse(&property, otherProperty, keepOwn)
+			}
+			properties[name] = property
+		}
+		schema.Properties = properties
+	}
+	if schema.Items != nil && schema.Items.Schema != nil && other.Items != nil && other.Items.Schema != nil {
+		items := *schema.Items.Schema
+		mergeSchemaProse(&items, *other.Items.Schema, keepOwn)
+		schema.Items = &spec.SchemaOrArray{Schema: &items}
+	}
+}
@@ -0,0 +1,209 @@
+package eswagger
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/gorilla/mux"
+)
+
+// baseSpec is a hand-written spec, the generated one of the tests documents
+// the same operation and definition
+const baseSpec = `
+swagger: "2.0"
+info:
+  title: Users API
+  description: Curated description
+  version: 0.1.0
+host: api.example.com
+securityDefinitions:
+  ApiKey: {type: apiKey, in: header, name: X-Api-Key}
+paths:
+  /users/{id}:
+    put:
+      operationId: updateUser
+      summary: Update a user
+      tags: [users]
+      parameters:
+        - {name: id, in: path, required: true, type: string, description: The user ID}
+      responses:
+        200:
+          description: The updated user
+          schema: {$ref: "#/definitions/handleUser"}
+  /health:
+    get:
+      summary: Health check
+      responses:
+        200: {description: OK}
+definitions:
+  handleUser:
+    type: object
+    description: A user
+    properties:
+      id: {type: string, description: Unique ID}
+      name: {type: string, description: Display name, example: Ada}
+`
+
+func TestMergeBase(t *testing.T) {
+	base, err := UnmarshalSpec([]byte(baseSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", noop).Methods("PUT")
+	router.HandleFunc("/users", noop).Methods("GET")
+
+	var metadata RouteMetadata
+	metadata.Set("PUT", "/users/{id}", EndpointMetadata{Summary: "Replace a user"})
+
+	tests := []struct {
+		name     string
+		opts     BaseOptions
+		title    string // of info
+		summary  string // of PUT /users/{id}
+		paramDoc string // of its id parameter
+		idType   string // of handleUser.id, whose description is only in the base
+	}{
+		{"defaults", BaseOptions{}, "Users API", "Update a user", "The user ID", "integer"},
+		{"generated prose", BaseOptions{Prose: PreferGenerated}, "Users", "Replace a user", "ID of the resource", "integer"},
+		{"base schemas", BaseOptions{Schemas: PreferBase}, "Users API", "Update a user", "The user ID", "string"},
+		{"generated everything", BaseOptions{Prose: PreferGenerated, Schemas: PreferGenerated}, "Users", "Replace a user", "ID of the resource", "integer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := NewGeneratorFromSpec(Config{Title: "Users", Version: "1.0.0"}, base, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			g.RegisterEndpoint("/users/{id}", "PUT", updateUserRequest{}, handleUser{})
+			if _, err := g.GenerateFromRouter(router, metadata); err != nil {
+				t.Fatal(err)
+			}
+			swagger := g.GetSwaggerSpec()
+
+			if swagger.Info.Title != tt.title || swagger.Info.Version != "1.0.0" {
+				t.Errorf("info = %q %q, want %q 1.0.0", swagger.Info.Title, swagger.Info.Version, tt.title)
+			}
+			if swagger.Host != "api.example.com" || swagger.SecurityDefinitions["ApiKey"] == nil {
+				t.Error("document settings of the base lost")
+			}
+
+			put := swagger.Paths.Paths["/users/{id}"].Put
+			if put.Summary != tt.summary {
+				t.Errorf("summary = %q, want %q", put.Summary, tt.summary)
+			}
+			if put.ID != "updateUser" || len(put.Tags) != 1 || put.Tags[0] != "users" {
+				t.Errorf("curation lost: id %q tags %q", put.ID, put.Tags)
+			}
+			var names []string
+			for _, param := range put.Parameters {
+				names = append(names, param.In+" "+param.Name)
+			}
+			if strings.Join(names, ",") != "path id,body body" {
+				t.Errorf("parameters = %q", names)
+			}
+			if put.Parameters[0].Description != tt.paramDoc {
+				t.Errorf("path parameter description = %q, want %q", put.Parameters[0].Description, tt.paramDoc)
+			}
+
+			id := swagger.Definitions["handleUser"].Properties["id"]
+			if id.Type[0] != tt.idType || id.Description != "Unique ID" {
+				t.Errorf("handleUser.id = %s %q, want %s %q", id.Type, id.Description, tt.idType, "Unique ID")
+			}
+			if name := swagger.Definitions["handleUser"].Properties["name"]; name.Example != "Ada" {
+				t.Errorf("handleUser.name example = %v", name.Example)
+			}
+
+			if _, ok := swagger.Paths.Paths["/health"]; !ok {
+				t.Error("operation only in the base dropped")
+			}
+			if _, ok := swagger.Paths.Paths["/users"]; !ok {
+				t.Error("generated operation not added")
+			}
+		})
+	}
+
+	if _, ok := base.Paths.Paths["/users"]; ok || base.Info.Version != "0.1.0" {
+		t.Error("base spec modified")
+	}
+}
+
+func TestMergeProse(t *testing.T) {
+	tests := []struct {
+		preferBase      bool
+		base, generated string
+		want            string
+	}{
+		{true, "curated", "generated", "curated"},
+		{true, "", "generated", "generated"},
+		{false, "curated", "generated", "generated"},
+		{false, "curated", "", "curated"},
+	}
+	for _, tt := range tests {
+		text := tt.base
+		mergeProse(tt.preferBase, &text, tt.generated)
+		if text != tt.want {
+			t.Errorf("mergeProse(%v, %q, %q) = %q, want %q", tt.preferBase, tt.base, tt.generated, text, tt.want)
+		}
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	base := []spec.Tag{spec.NewTag("users", "", nil)}
+	generated := []spec.Tag{spec.NewTag("orders", "Orders", nil), spec.NewTag("users", "User accounts", nil)}
+
+	tags := mergeTags(base, generated, BaseOptions{})
+	if len(tags) != 2 || tags[0].Name != "users" || tags[0].Description != "User accounts" || tags[1].Name != "orders" {
+		t.Errorf("tags = %+v", tags)
+	}
+}
+
+func TestLoadBaseSpec(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		document string
+		wantErr  string
+	}{
+		{"swagger 2.0", baseSpec, ""},
+		{"openapi 3", "openapi: 3.0.3\ninfo: {title: Users, version: 1.0.0}\npaths: {}\n", "OpenAPI 3.0.3 documents aren't supported"},
+		{"not yaml", "swagger: [", "error parsing spec file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".yaml")
+			if err := os.WriteFile(path, []byte(tt.document), 0644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := NewGeneratorFromFile(Config{Title: "Users", Version: "1.0.0"}, path, BaseOptions{})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewGeneratorFromSpecError(t *testing.T) {
+	base := &spec.Swagger{}
+	base.AddExtension("x-handler", func() {})
+
+	g, err := NewGeneratorFromSpec(Config{Title: "Users", Version: "1.0.0"}, base, BaseOptions{})
+	if err == nil || !strings.Contains(err.Error(), "error copying base spec") {
+		t.Errorf("err = %v, want the copy error", err)
+	}
+	if g != nil {
+		t.Error("generator returned along with the error")
+	}
+}
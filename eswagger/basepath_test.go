@@ -0,0 +1,84 @@
+package eswagger
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCommonPathPrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{"none", nil, ""},
+		{"shared", []string{"/api/v1/users", "/api/v1/orders/{id}"}, "/api/v1"},
+		{"last segment kept", []string{"/api/v1/users"}, "/api/v1"},
+		{"stops at a variable", []string{"/{tenant}/users", "/{tenant}/orders"}, ""},
+		{"partial segments", []string{"/api/users", "/apis/orders"}, ""},
+		{"root", []string{"/users", "/orders"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commonPathPrefix(tt.paths); got != tt.want {
+				t.Errorf("commonPathPrefix(%q) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBasePath(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/users", noop).Methods("GET")
+	api.HandleFunc("/users/{id}", noop).Methods("GET")
+	admin := api.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/stats", noop).Methods("GET")
+	router.HandleFunc("/healthz", noop).Methods("GET")
+
+	tests := []struct {
+		name     string
+		config   Config
+		source   RouteSource
+		basePath string
+		paths    []string
+		outside  int
+	}{
+		{"configured", Config{BasePath: "/api/v1"}, MuxRoutes(router), "/api/v1", []string{"/admin/stats", "/healthz", "/users", "/users/{id}"}, 1}, // kept as is and reported
+		{"none", Config{}, MuxRoutes(router), "", []string{"/api/v1/admin/stats", "/api/v1/users", "/api/v1/users/{id}", "/healthz"}, 0},
+		{"detected", Config{DetectBasePath: true}, WithPathPrefix(MuxRoutes(router), "/api/v1"), "/api/v1", []string{"/admin/stats", "/users", "/users/{id}"}, 0},
+		{"one subrouter", Config{BasePath: "/api/v1/admin"}, WithPathPrefix(MuxRoutes(router), "/api/v1/admin/"), "/api/v1/admin", []string{"/stats"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Title, config.Version = "API", "1.0.0"
+			g := NewGenerator(config)
+			diagnostics, err := g.GenerateFromRoutes(tt.source, RouteMetadata{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			swagger := g.GetSwaggerSpec()
+			if swagger.BasePath != tt.basePath {
+				t.Errorf("basePath = %q, want %q", swagger.BasePath, tt.basePath)
+			}
+			var paths []string
+			for path := range swagger.Paths.Paths {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("paths = %q, want %q", paths, tt.paths)
+			}
+			if outside := len(diagnostics.Of(DiagOutsideBasePath)); outside != tt.outside {
+				t.Errorf("%d routes reported outside the base path, want %d", outside, tt.outside)
+			}
+		})
+	}
+}
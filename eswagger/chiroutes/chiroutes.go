@@ -0,0 +1,29 @@
+// Package chiroutes lists the routes of a go-chi router for the generator.
+// It is a module of its own so only its users depend on chi.
+package chiroutes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"main/eswagger"
+)
+
+// Routes returns a route source walking the chi router
+func Routes(router chi.Routes) eswagger.RouteSource {
+	return eswagger.RouteSourceFunc(func() ([]eswagger.Route, error) {
+		var routes []eswagger.Route
+
+		err := chi.Walk(router, func(method string, route string, handler http.Handler, _ ...func(http.Handler) http.Handler) error {
+			routes = append(routes, eswagger.Route{
+				Path:    eswagger.ConvertPathTemplate(route),
+				Methods: []string{strings.ToUpper(method)},
+				Handler: handler,
+			})
+			return nil
+		})
+
+		return routes, err
+	})
+}
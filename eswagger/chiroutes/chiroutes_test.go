@@ -0,0 +1,50 @@
+package chiroutes
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRoutes(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := chi.NewRouter()
+	router.Get("/users", noop)
+	router.Post("/users", noop)
+	router.Route("/users/{id}", func(r chi.Router) {
+		r.Get("/", noop)
+		r.Delete("/", noop)
+		r.Get("/files/*", noop)
+	})
+	router.Get("/orders/{id:[0-9]+}", noop)
+
+	routes, err := Routes(router).Routes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, route := range routes {
+		if route.Handler == nil {
+			t.Errorf("%s %s has no handler", route.Methods, route.Path)
+		}
+		for _, method := range route.Methods {
+			got = append(got, method+" "+route.Path)
+		}
+	}
+	sort.Strings(got)
+
+	want := []string{
+		"DELETE /users/{id}/",
+		"GET /orders/{id}",
+		"GET /users",
+		"GET /users/{id}/",
+		"GET /users/{id}/files/{wildcard}",
+		"POST /users",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %q\nwant %q", got, want)
+	}
+}
@@ -0,0 +1,184 @@
+package eswagger
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables that override values loaded by LoadConfig
+const (
+	EnvHost        = "ESWAGGER_HOST"
+	EnvSchemes     = "ESWAGGER_SCHEMES" // comma separated, e.g. "https,http"
+	EnvEnvironment = "ESWAGGER_ENV"
+)
+
+// LoadConfig reads a Config from a YAML or JSON file and applies the
+// environment-variable overrides on top of it.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("error reading config file: %v", err)
+	}
+
+	// JSON is a subset of YAML, so a single decoder handles both formats
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("error parsing config file %s: %v", path, err)
+	}
+
+	config.applyEnvOverrides()
+	return config, nil
+}
+
+func (c *Config) applyEnvOverrides() {
+	if env := os.Getenv(EnvEnvironment); env != "" {
+		c.Environment = env
+	}
+	if host := os.Getenv(EnvHost); host != "" {
+		c.Host = host
+	}
+	if schemes := os.Getenv(EnvSchemes); schemes != "" {
+		c.Schemes = nil
+		for _, scheme := range strings.Split(schemes, ",") {
+			if scheme = strings.TrimSpace(scheme); scheme != "" {
+				c.Schemes = append(c.Schemes, scheme)
+			}
+		}
+	}
+}
+
+// currentServer returns the server matching Config.Environment, falling back
+// to the first one declared
+func (c Config) currentServer() *Server {
+	if len(c.Servers) == 0 {
+		return nil
+	}
+	for i := range c.Servers {
+		if c.Servers[i].Environment == c.Environment {
+			return &c.Servers[i]
+		}
+	}
+	return &c.Servers[0]
+}
+
+func (c Config) buildInfo() *spec.Info {
+	info := &spec.Info{
+		InfoProps: spec.InfoProps{
+			Title:          c.Title,
+			Description:    c.Description,
+			Version:        c.Version,
+			TermsOfService: c.TermsOfService,
+		},
+	}
+
+	if c.Contact != nil {
+		info.Contact = &spec.ContactInfo{
+			ContactInfoProps: spec.ContactInfoProps{
+				Name:  c.Contact.Name,
+				URL:   c.Contact.URL,
+				Email: c.Contact.Email,
+			},
+		}
+	}
+
+	if c.License != nil {
+		info.License = &spec.License{
+			LicenseProps: spec.LicenseProps{
+				Name: c.License.Name,
+				URL:  c.License.URL,
+			},
+		}
+	}
+
+	return info
+}
+
+// applyServer fills host, schemes and basePath of the spec. Explicit Host and
+// Schemes win over the ones derived from the selected server.
+func (c Config) applyServer(swagger *spec.Swagger) {
+	swagger.Host = c.Host
+	swagger.Schemes = c.Schemes
+	swagger.BasePath = c.BasePath
+
+	if server := c.currentServer(); server != nil {
+		if u, err := url.Parse(server.URL); err == nil {
+			if swagger.Host == "" {
+				swagger.Host = u.Host
+			}
+			if len(swagger.Schemes) == 0 && u.Scheme != "" {
+				swagger.Schemes = []string{u.Scheme}
+			}
+			if swagger.BasePath == "" && u.Path != "" && u.Path != "/" {
+				swagger.BasePath = strings.TrimSuffix(u.Path, "/")
+			}
+		}
+	}
+
+	// Swagger 2.0 has no notion of multiple servers, keep the full list as
+	// an extension so tooling can still offer an environment picker
+	if len(c.Servers) > 0 {
+		servers := make([]map[string]string, 0, len(c.Servers))
+		for _, server := range c.Servers {
+			entry := map[string]string{"url": server.URL}
+			if server.Description != "" {
+				entry["description"] = server.Description
+			}
+			if server.Environment != "" {
+				entry["x-environment"] = server.Environment
+			}
+			servers = append(servers, entry)
+		}
+		swagger.AddExtension("x-servers", servers)
+	}
+}
+
+func (c Config) buildTags() []spec.Tag {
+	tags := make([]spec.Tag, 0, len(c.Tags))
+	for _, tag := range c.Tags {
+		tags = append(tags, spec.NewTag(tag.Name, tag.Description, c.toExternalDocs(tag.ExternalDocs)))
+	}
+	return tags
+}
+
+func (c Config) toExternalDocs(docs *ExternalDocs) *spec.ExternalDocumentation {
+	if docs == nil {
+		return nil
+	}
+	return &spec.ExternalDocumentation{
+		Description: docs.Description,
+		URL:         docs.URL,
+	}
+}
+
+// syncTags appends the tags used by operations but missing from the
+// catalogue, so every tag is listed while the configured order is kept
+func (g *Generator) syncTags() {
+	known := make(map[string]bool)
+	for _, tag := range g.swagger.Tags {
+		known[tag.Name] = true
+	}
+
+	var missing []string
+	for _, pathItem := range g.swagger.Paths.Paths {
+		for _, operation := range operationsOf(pathItem) {
+			for _, tag := range operation.Tags {
+				if !known[tag] {
+					known[tag] = true
+					missing = append(missing, tag)
+				}
+			}
+		}
+	}
+
+	sort.Strings(missing)
+	for _, tag := range missing {
+		g.swagger.Tags = append(g.swagger.Tags, spec.NewTag(tag, "", nil))
+	}
+}
@@ -0,0 +1,134 @@
+package eswagger
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eswagger.yaml")
+	data := `title: Users
+version: 1.2.0
+host: api.example.com
+schemes: [https]
+environment: prod
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		host        string
+		schemes     []string
+		environment string
+	}{
+		{"file only", nil, "api.example.com", []string{"https"}, "prod"},
+		{"host", map[string]string{EnvHost: "localhost:8080"}, "localhost:8080", []string{"https"}, "prod"},
+		{"schemes", map[string]string{EnvSchemes: " http, https ,"}, "api.example.com", []string{"http", "https"}, "prod"},
+		{"environment", map[string]string{EnvEnvironment: "staging"}, "api.example.com", []string{"https"}, "staging"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{EnvHost, EnvSchemes, EnvEnvironment} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Title != "Users" || config.Version != "1.2.0" {
+				t.Errorf("info = %q %q, want Users 1.2.0", config.Title, config.Version)
+			}
+			if config.Host != tt.host {
+				t.Errorf("host = %q, want %q", config.Host, tt.host)
+			}
+			if !reflect.DeepEqual(config.Schemes, tt.schemes) {
+				t.Errorf("schemes = %q, want %q", config.Schemes, tt.schemes)
+			}
+			if config.Environment != tt.environment {
+				t.Errorf("environment = %q, want %q", config.Environment, tt.environment)
+			}
+		})
+	}
+}
+
+func TestConfigServers(t *testing.T) {
+	servers := []Server{
+		{Environment: "dev", URL: "http://localhost:8080/api"},
+		{Environment: "prod", URL: "https://api.example.com/v1/", Description: "Production"},
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		host     string
+		schemes  []string
+		basePath string
+	}{
+		{"first server by default", Config{Servers: servers}, "localhost:8080", []string{"http"}, "/api"},
+		{"selected environment", Config{Servers: servers, Environment: "prod"}, "api.example.com", []string{"https"}, "/v1"},
+		{"unknown environment", Config{Servers: servers, Environment: "qa"}, "localhost:8080", []string{"http"}, "/api"},
+		{"explicit values win", Config{Servers: servers, Environment: "prod", Host: "gw.example.com", Schemes: []string{"wss"}, BasePath: "/gw"}, "gw.example.com", []string{"wss"}, "/gw"},
+		{"no servers", Config{Host: "example.com"}, "example.com", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			swagger := NewGenerator(tt.config).GetSwaggerSpec()
+			if swagger.Host != tt.host {
+				t.Errorf("host = %q, want %q", swagger.Host, tt.host)
+			}
+			if !reflect.DeepEqual(swagger.Schemes, tt.schemes) {
+				t.Errorf("schemes = %q, want %q", swagger.Schemes, tt.schemes)
+			}
+			if swagger.BasePath != tt.basePath {
+				t.Errorf("basePath = %q, want %q", swagger.BasePath, tt.basePath)
+			}
+
+			_, hasServers := swagger.Extensions["x-servers"]
+			if hasServers != (len(tt.config.Servers) > 0) {
+				t.Errorf("x-servers set = %v, want %v", hasServers, len(tt.config.Servers) > 0)
+			}
+		})
+	}
+}
+
+func TestConfigInfoAndTags(t *testing.T) {
+	config := Config{
+		Title:        "Users",
+		Version:      "1.0.0",
+		Contact:      &Contact{Name: "Team", Email: "team@example.com"},
+		License:      &License{Name: "MIT"},
+		ExternalDocs: &ExternalDocs{URL: "https://docs.example.com"},
+		Tags: []Tag{
+			{Name: "users", Description: "User accounts"},
+			{Name: "admin", ExternalDocs: &ExternalDocs{URL: "https://docs.example.com/admin"}},
+		},
+	}
+
+	swagger := NewGenerator(config).GetSwaggerSpec()
+	if swagger.Info.Contact == nil || swagger.Info.Contact.Email != "team@example.com" {
+		t.Errorf("contact = %+v", swagger.Info.Contact)
+	}
+	if swagger.Info.License == nil || swagger.Info.License.Name != "MIT" {
+		t.Errorf("license = %+v", swagger.Info.License)
+	}
+	if swagger.ExternalDocs == nil || swagger.ExternalDocs.URL != "https://docs.example.com" {
+		t.Errorf("externalDocs = %+v", swagger.ExternalDocs)
+	}
+
+	var names []string
+	for _, tag := range swagger.Tags {
+		names = append(names, tag.Name)
+	}
+	if want := []string{"users", "admin"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("tags = %q, want the catalogue order %q", names, want)
+	}
+	if swagger.Tags[1].ExternalDocs == nil {
+		t.Error("tag externalDocs missing")
+	}
+}
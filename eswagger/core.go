@@ -1,24 +1,107 @@
 package eswagger
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Title       string
-	Description string
-	Version     string
-	BasePath    string
-	DocPath     string
+	Title          string        `json:"title" yaml:"title"`
+	Description    string        `json:"description" yaml:"description"`
+	Version        string        `json:"version" yaml:"version"`
+	TermsOfService string        `json:"termsOfService" yaml:"termsOfService"`
+	Contact        *Contact      `json:"contact" yaml:"contact"`
+	License        *License      `json:"license" yaml:"license"`
+	ExternalDocs   *ExternalDocs `json:"externalDocs" yaml:"externalDocs"`
+	Host           string        `json:"host" yaml:"host"`
+	Schemes        []string      `json:"schemes" yaml:"schemes"`
+	BasePath       string        `json:"basePath" yaml:"basePath"`             // stripped from the route paths
+	DetectBasePath bool          `json:"detectBasePath" yaml:"detectBasePath"` // use the prefix shared by all routes when BasePath is empty
+	Servers        []Server      `json:"servers" yaml:"servers"`               // one entry per environment
+	Environment    string        `json:"environment" yaml:"environment"`       // selects the server used for host/schemes
+	Tags           []Tag         `json:"tags" yaml:"tags"`                     // tag catalogue, in display order
+	DocPath        string        `json:"docPath" yaml:"docPath"`
+
+	// Route selection: Include, when set, keeps only matching routes and
+	// Exclude drops matching ones. Visibility is the audience of the spec,
+	// routes marked for a wider audience level are left out.
+	Include    []RouteFilter `json:"include" yaml:"include"`
+	Exclude    []RouteFilter `json:"exclude" yaml:"exclude"`
+	Visibility Visibility    `json:"visibility" yaml:"visibility"`
+
+	// Strict fails generation when the spec has defects such as unbound
+	// routes, unmapped field types, duplicate operationIds or colliding
+	// definition names, the previous spec is kept
+	Strict bool `json:"strict" yaml:"strict"`
+
+	// SourceDocs reads the doc comments of the interfaces, handlers and types
+	// from their Go source, with go/packages. Method comments become the
+	// operation summary and description, field comments the schema
+	// descriptions when there is no doc tag. The source and the go command
+	// must be available when generating, as with `eswagger generate`.
+	SourceDocs bool `json:"sourceDocs" yaml:"sourceDocs"`
+
+	// Annotations reads swaggo style annotations (@Summary, @Param, @Success,
+	// @Failure, @Router, @Security...) from the comments of the handler
+	// functions. They take precedence over doc comments, RouteMetadata over
+	// both. The handler source must be available when generating.
+	Annotations bool `json:"annotations" yaml:"annotations"`
+
+	// Overlays are OpenAPI Overlay files applied in order to the generated
+	// spec before it is served or saved, e.g. to hide internal fields or
+	// reword descriptions for the audience of the spec. They are read once,
+	// by the first build.
+	Overlays []string `json:"overlays" yaml:"overlays"`
+
+	// Logger receives the generation logs and diagnostics, nothing is logged
+	// when it is nil. The Handle handlers of the routes also log their server
+	// errors to it, to slog.Default otherwise.
+	Logger *slog.Logger `json:"-" yaml:"-"`
+}
+
+type Contact struct {
+	Name  string `json:"name" yaml:"name"`
+	URL   string `json:"url" yaml:"url"`
+	Email string `json:"email" yaml:"email"`
+}
+
+type License struct {
+	Name string `json:"name" yaml:"name"`
+	URL  string `json:"url" yaml:"url"`
+}
+
+type ExternalDocs struct {
+	Description string `json:"description" yaml:"description"`
+	URL         string `json:"url" yaml:"url"`
+}
+
+// Server describes where the API is reachable for a given environment
+type Server struct {
+	Environment string `json:"environment" yaml:"environment"`
+	URL         string `json:"url" yaml:"url"`
+	Description string `json:"description" yaml:"description"`
+}
+
+// Tag is an entry of the tag catalogue
+type Tag struct {
+	Name         string        `json:"name" yaml:"name"`
+	Description  string        `json:"description" yaml:"description"`
+	ExternalDocs *ExternalDocs `json:"externalDocs" yaml:"externalDocs"`
 }
 
 type EndpointMetadata struct {
 	Summary     string
 	Description string
+	OperationID string // overrides the one derived from the handler, must be unique
 	Tags        []string
+	Visibility  Visibility // defaults to public
 	Examples    struct {
 		Request  interface{}
 		Response interface{}
@@ -29,18 +112,31 @@ type RouteMetadata struct {
 	Endpoints map[string]map[string]EndpointMetadata // path -> method -> metadata
 }
 
-func (g *Generator) SaveSwagger(format string) error {
-	var data []byte
-	var err error
+// Set stores the metadata of the operation at the documented path and method
+func (m *RouteMetadata) Set(method, path string, metadata EndpointMetadata) {
+	if m.Endpoints == nil {
+		m.Endpoints = make(map[string]map[string]EndpointMetadata)
+	}
+	if m.Endpoints[path] == nil {
+		m.Endpoints[path] = make(map[string]EndpointMetadata)
+	}
+	m.Endpoints[path][strings.ToUpper(method)] = metadata
+}
 
-	switch format {
-	case "yaml", "json":
-		data, err = json.MarshalIndent(g.swagger, "", "  ")
-		if err != nil {
-			return fmt.Errorf("error marshalling Swagger spec: %v", err)
+// lookup returns the metadata of an operation, methods are case-insensitive
+func (m RouteMetadata) lookup(path, method string) (EndpointMetadata, bool) {
+	for key, metadata := range m.Endpoints[path] {
+		if strings.EqualFold(key, method) {
+			return metadata, true
 		}
-	default:
-		return fmt.Errorf("invalid format specified: %s", format)
+	}
+	return EndpointMetadata{}, false
+}
+
+func (g *Generator) SaveSwagger(format string) error {
+	data, err := MarshalSpec(g.GetSwaggerSpec(), format)
+	if err != nil {
+		return err
 	}
 
 	filePath := fmt.Sprintf("%s/swagger.%s", g.config.DocPath, format)
@@ -48,6 +144,119 @@ func (g *Generator) SaveSwagger(format string) error {
 		return fmt.Errorf("error writing swagger file: %v", err)
 	}
 
-	log.Printf("Swagger spec saved to: %s\n", filePath)
+	g.logger().Info("swagger spec saved", slog.String("file", filePath))
 	return nil
 }
+
+// MarshalSpec encodes the spec as "json" or "yaml", YAML keeps the key order
+// of the JSON document
+func MarshalSpec(swagger *spec.Swagger, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(swagger, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling Swagger spec: %v", err)
+	}
+
+	switch format {
+	case "json":
+		return data, nil
+	case "yaml":
+		return jsonToYAML(data)
+	default:
+		return nil, fmt.Errorf("invalid format specified: %s", format)
+	}
+}
+
+func jsonToYAML(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("error converting Swagger spec to YAML: %v", err)
+	}
+	resetYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return nil, fmt.Errorf("error converting Swagger spec to YAML: %v", err)
+	}
+	encoder.Close()
+	return buf.Bytes(), nil
+}
+
+// resetYAMLStyle drops the flow style and quoting inherited from JSON, the
+// encoder quotes the strings that need it
+func resetYAMLStyle(node *yaml.Node) {
+	if node.Kind != yaml.ScalarNode || node.Tag == "!!str" {
+		node.Style = 0
+	}
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// LoadSpec reads a JSON or YAML Swagger 2.0 spec file, such as one written by
+// SaveSwagger
+func LoadSpec(path string) (*spec.Swagger, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading spec file: %v", err)
+	}
+	swagger, err := UnmarshalSpec(data)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing spec file %s: %v", path, err)
+	}
+	return swagger, nil
+}
+
+// UnmarshalSpec decodes a JSON or YAML spec. Only Swagger 2.0 is supported,
+// OpenAPI 3 documents are rejected with an error saying so.
+func UnmarshalSpec(data []byte) (*spec.Swagger, error) {
+	// JSON is a subset of YAML, the document goes through YAML and back to
+	// JSON so the spec types decode it with their own JSON unmarshallers
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	document = yamlToJSONValue(document)
+	if fields, ok := document.(map[string]interface{}); ok {
+		if version, ok := fields["openapi"]; ok {
+			// The fields of OpenAPI 3 would silently be dropped
+			return nil, fmt.Errorf("OpenAPI %v documents aren't supported, convert it to Swagger 2.0", version)
+		}
+	}
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	swagger := new(spec.Swagger)
+	if err := json.Unmarshal(encoded, swagger); err != nil {
+		return nil, err
+	}
+	return swagger, nil
+}
+
+// yamlToJSONValue turns the maps with non-string keys YAML produces, e.g. for
+// an unquoted 200 response code, into maps encoding/json accepts
+func yamlToJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = yamlToJSONValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = yamlToJSONValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = yamlToJSONValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
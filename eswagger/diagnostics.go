@@ -0,0 +1,157 @@
+package eswagger
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// DiagnosticKind classifies a problem found while generating the spec
+type DiagnosticKind string
+
+const (
+	// DiagUnboundRoute is a route whose request and response types are unknown
+	DiagUnboundRoute DiagnosticKind = "unbound_route"
+	// DiagNoMethods is a route registered without methods, it is not documented
+	DiagNoMethods DiagnosticKind = "no_methods"
+	// DiagOutsideBasePath is a route that doesn't start with the base path
+	DiagOutsideBasePath DiagnosticKind = "outside_base_path"
+	// DiagEmptyRef is a type without a name, referenced as "#/definitions/"
+	DiagEmptyRef DiagnosticKind = "empty_ref"
+	// DiagDroppedField is a struct field whose type couldn't be mapped
+	DiagDroppedField DiagnosticKind = "dropped_field"
+	// DiagAmbiguousMatch is a handler name matching several interface methods
+	DiagAmbiguousMatch DiagnosticKind = "ambiguous_match"
+	// DiagInvalidInterface is a registered interface that couldn't be read
+	DiagInvalidInterface DiagnosticKind = "invalid_interface"
+	// DiagDuplicateOperationID is an operationId used by several operations
+	DiagDuplicateOperationID DiagnosticKind = "duplicate_operation_id"
+	// DiagDefinitionCollision is two types sharing a definition name, the
+	// last one registered wins
+	DiagDefinitionCollision DiagnosticKind = "definition_collision"
+	// DiagInvalidAnnotation is a swaggo annotation that couldn't be applied
+	DiagInvalidAnnotation DiagnosticKind = "invalid_annotation"
+	// DiagStaleOverlay is an overlay action whose target selects nothing
+	DiagStaleOverlay DiagnosticKind = "stale_overlay"
+	// DiagUnreadMatchers is a route whose matchers couldn't be read from the
+	// router, its header or scheme requirements are missing
+	DiagUnreadMatchers DiagnosticKind = "unread_matchers"
+	// DiagUnknownVisibility is a visibility other than public, partner and
+	// internal, it is treated as the most restrictive one
+	DiagUnknownVisibility DiagnosticKind = "unknown_visibility"
+)
+
+// strictKinds are the diagnostics Config.Strict turns into an error
+var strictKinds = []DiagnosticKind{
+	DiagUnboundRoute,
+	DiagNoMethods,
+	DiagEmptyRef,
+	DiagDroppedField,
+	DiagInvalidInterface,
+	DiagDuplicateOperationID,
+	DiagDefinitionCollision,
+	DiagInvalidAnnotation,
+	DiagStaleOverlay,
+	DiagUnreadMatchers,
+	DiagUnknownVisibility,
+}
+
+// Diagnostic is a problem found while generating the spec. The spec is still
+// generated, the affected part is missing or incomplete.
+type Diagnostic struct {
+	Kind    DiagnosticKind `json:"kind"`
+	Method  string         `json:"method,omitempty"`
+	Path    string         `json:"path,omitempty"`
+	Message string         `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	b.WriteString(string(d.Kind))
+	if d.Method != "" || d.Path != "" {
+		b.WriteString(": ")
+		b.WriteString(strings.TrimSpace(d.Method + " " + d.Path))
+	}
+	b.WriteString(": ")
+	b.WriteString(d.Message)
+	return b.String()
+}
+
+// Error lets a diagnostic be part of the error returned in strict mode, use
+// errors.As to get it back
+func (d Diagnostic) Error() string {
+	return d.String()
+}
+
+// Diagnostics is the report returned by GenerateFromRouter, CI can fail on a
+// non-empty report to keep the documentation complete
+type Diagnostics []Diagnostic
+
+// Of returns the diagnostics of the given kinds
+func (d Diagnostics) Of(kinds ...DiagnosticKind) Diagnostics {
+	var filtered Diagnostics
+	for _, diagnostic := range d {
+		for _, kind := range kinds {
+			if diagnostic.Kind == kind {
+				filtered = append(filtered, diagnostic)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+func (d Diagnostics) String() string {
+	lines := make([]string, len(d))
+	for i, diagnostic := range d {
+		lines[i] = diagnostic.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// strictError aggregates the defects found in strict mode, nil when there
+// are none
+func (d Diagnostics) strictError() error {
+	defects := d.Of(strictKinds...)
+	if len(defects) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(defects))
+	for i, defect := range defects {
+		errs[i] = defect
+	}
+	return fmt.Errorf("strict mode: %d documentation defects:\n%w", len(defects), errors.Join(errs...))
+}
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// logger returns Config.Logger, generation is silent when none is set
+func (g *Generator) logger() *slog.Logger {
+	if g.config.Logger != nil {
+		return g.config.Logger
+	}
+	return discardLogger
+}
+
+// report records a diagnostic once and logs it as a warning
+func (g *Generator) report(kind DiagnosticKind, method, path, format string, args ...interface{}) {
+	diagnostic := Diagnostic{Kind: kind, Method: method, Path: path, Message: fmt.Sprintf(format, args...)}
+
+	key := diagnostic.String()
+	if g.reported[key] {
+		return
+	}
+	if g.reported == nil {
+		g.reported = make(map[string]bool)
+	}
+	g.reported[key] = true
+
+	g.diagnostics = append(g.diagnostics, diagnostic)
+	g.logger().Warn(diagnostic.Message,
+		slog.String("kind", string(kind)),
+		slog.String("method", method),
+		slog.String("path", path))
+}
@@ -0,0 +1,190 @@
+package eswagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDiagnosticString(t *testing.T) {
+	tests := []struct {
+		diagnostic Diagnostic
+		want       string
+	}{
+		{Diagnostic{Kind: DiagUnboundRoute, Method: "GET", Path: "/users", Message: "no types"}, "unbound_route: GET /users: no types"},
+		{Diagnostic{Kind: DiagNoMethods, Path: "/health", Message: "no methods"}, "no_methods: /health: no methods"},
+		{Diagnostic{Kind: DiagDroppedField, Message: "field dropped"}, "dropped_field: field dropped"},
+	}
+	for _, tt := range tests {
+		if got := tt.diagnostic.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestDiagnosticsOf(t *testing.T) {
+	diagnostics := Diagnostics{
+		{Kind: DiagUnboundRoute, Path: "/a"},
+		{Kind: DiagNoMethods, Path: "/b"},
+		{Kind: DiagUnboundRoute, Path: "/c"},
+	}
+	tests := []struct {
+		kinds []DiagnosticKind
+		want  int
+	}{
+		{nil, 0},
+		{[]DiagnosticKind{DiagUnboundRoute}, 2},
+		{[]DiagnosticKind{DiagUnboundRoute, DiagNoMethods}, 3},
+		{[]DiagnosticKind{DiagEmptyRef}, 0},
+	}
+	for _, tt := range tests {
+		if got := len(diagnostics.Of(tt.kinds...)); got != tt.want {
+			t.Errorf("Of(%v) returned %d diagnostics, want %d", tt.kinds, got, tt.want)
+		}
+	}
+}
+
+func TestReport(t *testing.T) {
+	var logs bytes.Buffer
+	g := NewGenerator(Config{
+		Title:   "Users",
+		Version: "1.0.0",
+		Logger:  slog.New(slog.NewJSONHandler(&logs, nil)),
+	})
+
+	g.report(DiagUnboundRoute, "GET", "/users", "no types for %s", "listUsers")
+	g.report(DiagUnboundRoute, "GET", "/users", "no types for %s", "listUsers")
+	g.report(DiagNoMethods, "", "/health", "no methods")
+
+	want := Diagnostics{
+		{Kind: DiagUnboundRoute, Method: "GET", Path: "/users", Message: "no types for listUsers"},
+		{Kind: DiagNoMethods, Path: "/health", Message: "no methods"},
+	}
+	if g.diagnostics.String() != want.String() {
+		t.Errorf("diagnostics = %v\nwant %v", g.diagnostics, want)
+	}
+
+	var records []map[string]interface{}
+	decoder := json.NewDecoder(&logs)
+	for decoder.More() {
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("logged %d records, want 2", len(records))
+	}
+	if first := records[0]; first["level"] != "WARN" || first["msg"] != "no types for listUsers" || first["kind"] != "unbound_route" || first["path"] != "/users" {
+		t.Errorf("record = %v", first)
+	}
+}
+
+func TestGenerateDiagnostics(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+	router.HandleFunc("/health", noop)
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0", BasePath: "/api"})
+	diagnostics, err := g.GenerateFromRouter(router, RouteMetadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kind DiagnosticKind
+		want int
+	}{
+		{DiagUnboundRoute, 1},
+		{DiagNoMethods, 1},
+		{DiagOutsideBasePath, 1}, // routes without methods aren't checked
+	}
+	for _, tt := range tests {
+		if got := len(diagnostics.Of(tt.kind)); got != tt.want {
+			t.Errorf("%d %s diagnostics, want %d:\n%v", got, tt.kind, tt.want, diagnostics)
+		}
+	}
+}
+
+func TestStrictError(t *testing.T) {
+	tests := []struct {
+		name        string
+		diagnostics Diagnostics
+		defects     int
+	}{
+		{"none", nil, 0},
+		{"only warnings", Diagnostics{{Kind: DiagOutsideBasePath}, {Kind: DiagAmbiguousMatch}}, 0},
+		{"defects", Diagnostics{{Kind: DiagUnboundRoute}, {Kind: DiagOutsideBasePath}, {Kind: DiagEmptyRef}}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.diagnostics.strictError()
+			if tt.defects == 0 {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			var diagnostic Diagnostic
+			if !errors.As(err, &diagnostic) || diagnostic.Kind != DiagUnboundRoute {
+				t.Errorf("errors.As = %v", diagnostic)
+			}
+			if !strings.Contains(err.Error(), fmt.Sprintf("%d documentation defects", tt.defects)) {
+				t.Errorf("error = %v", err)
+			}
+		})
+	}
+}
+
+func TestStrictGeneration(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0", Strict: true})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err == nil {
+		t.Fatal("unbound route accepted in strict mode")
+	}
+	if _, revision := g.snapshot(); revision != 0 {
+		t.Error("spec published despite the defects")
+	}
+
+	g.RegisterEndpoint("/users", "GET", nil, handleUser{})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Errorf("complete spec rejected: %v", err)
+	}
+}
+
+func TestStrictExcludedRoutes(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+	router.PathPrefix("/static/").HandlerFunc(noop)
+
+	g := NewGenerator(Config{
+		Title:   "Users",
+		Version: "1.0.0",
+		Strict:  true,
+		Exclude: []RouteFilter{{Paths: []string{"/static/**"}}},
+	})
+	g.RegisterEndpoint("/users", "GET", nil, handleUser{})
+	diagnostics, err := g.GenerateFromRouter(router, RouteMetadata{})
+	if err != nil {
+		t.Fatalf("excluded route failed strict mode: %v", err)
+	}
+	if len(diagnostics) > 0 {
+		t.Errorf("diagnostics = %v, want none", diagnostics)
+	}
+}
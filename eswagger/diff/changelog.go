@@ -0,0 +1,354 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Bump is the part of a semantic version a release has to increment
+type Bump string
+
+const (
+	BumpNone  Bump = ""
+	BumpPatch Bump = "patch"
+	BumpMinor Bump = "minor"
+	BumpMajor Bump = "major"
+)
+
+// bumpOrder ranks the bumps, the largest one of the changes wins
+var bumpOrder = map[Bump]int{BumpNone: 0, BumpPatch: 1, BumpMinor: 2, BumpMajor: 3}
+
+// levelBumps is the bump of a change by level
+var levelBumps = map[Level]Bump{
+	Breaking:    BumpMajor,
+	NonBreaking: BumpMinor,
+	Info:        BumpPatch,
+}
+
+// kindBumps overrides levelBumps for changes the wire format doesn't show.
+// A deprecation announces a removal, clients have to be told in a minor
+// release. Moving the API to another host or base path breaks every client
+// even when the diff sees the host as environment specific.
+var kindBumps = map[Kind]Bump{
+	KindOperationDeprecated: BumpMinor,
+	KindHostChanged:         BumpMajor,
+	KindBasePathChanged:     BumpMajor,
+}
+
+// Bump returns the version increment the changes call for: major for
+// breaking changes, minor for compatible additions and deprecations, patch
+// for documentation
+func (r Report) Bump() Bump {
+	bump := BumpNone
+	for _, change := range r.Changes {
+		next, ok := kindBumps[change.Kind]
+		if !ok {
+			next = levelBumps[change.Level]
+		}
+		if bumpOrder[next] > bumpOrder[bump] {
+			bump = next
+		}
+	}
+	return bump
+}
+
+// NextVersion increments a version such as "1.4.2" or "v0.3", pre-release
+// and build suffixes are dropped. Before 1.0.0 breaking changes bump the
+// minor version, as is customary for APIs that aren't stable yet.
+func NextVersion(version string, bump Bump) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix = "v"
+	}
+	numbers, err := parseVersion(version)
+	if err != nil {
+		return "", err
+	}
+
+	if bump == BumpMajor && numbers[0] == 0 {
+		bump = BumpMinor
+	}
+	switch bump {
+	case BumpMajor:
+		numbers = [3]int{numbers[0] + 1, 0, 0}
+	case BumpMinor:
+		numbers = [3]int{numbers[0], numbers[1] + 1, 0}
+	case BumpPatch:
+		numbers[2]++
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, numbers[0], numbers[1], numbers[2]), nil
+}
+
+// parseVersion returns the major, minor and patch numbers of a version,
+// missing ones are zero
+func parseVersion(version string) ([3]int, error) {
+	var numbers [3]int
+	v := strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > 3 {
+		return numbers, fmt.Errorf("invalid version %q", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return numbers, fmt.Errorf("invalid version %q", version)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// Changelog groups the changes between two specs for release notes
+type Changelog struct {
+	OldVersion string // info.version of the specs
+	NewVersion string
+	Bump       Bump
+	Next       string // OldVersion bumped, empty when it isn't a semantic version
+	Endpoints  Section
+	Schemas    Section
+	Report     Report
+}
+
+// Section lists the endpoints or schemas by what happened to them
+type Section struct {
+	Added      []Entry
+	Changed    []Entry
+	Deprecated []Entry
+	Removed    []Entry
+}
+
+// Entry is an endpoint, e.g. "GET /users", or a schema with its changes
+type Entry struct {
+	Name     string
+	Summary  string
+	Breaking bool
+	Changes  []Change
+}
+
+// NewChangelog compares the specs and groups the changes
+func NewChangelog(old, new *spec.Swagger) Changelog {
+	report := Compare(old, new)
+	changelog := Changelog{Bump: report.Bump(), Report: report}
+	if old.Info != nil {
+		changelog.OldVersion = old.Info.Version
+	}
+	if new.Info != nil {
+		changelog.NewVersion = new.Info.Version
+	}
+	if changelog.OldVersion != "" {
+		changelog.Next, _ = NextVersion(changelog.OldVersion, changelog.Bump)
+	}
+
+	newOperations, _ := operations(new)
+	oldOperations, _ := operations(old)
+	summary := func(method, path string) string {
+		for _, entries := range []map[string]operationEntry{newOperations, oldOperations} {
+			if entry, ok := entries[method+" "+pathParam.ReplaceAllString(path, "{}")]; ok {
+				return entry.operation.Summary
+			}
+		}
+		return ""
+	}
+
+	endpoints := make(map[string]*Entry)
+	var endpointOrder []string
+	endpoint := func(change Change) *Entry {
+		name := change.Method + " " + change.Path
+		if entry, ok := endpoints[name]; ok {
+			return entry
+		}
+		endpoints[name] = &Entry{Name: name, Summary: summary(change.Method, change.Path)}
+		endpointOrder = append(endpointOrder, name)
+		return endpoints[name]
+	}
+	schemas := make(map[string]*Entry)
+	schema := func(name string) *Entry {
+		if entry, ok := schemas[name]; ok {
+			return entry
+		}
+		schemas[name] = &Entry{Name: name}
+		return schemas[name]
+	}
+
+	for _, change := range report.Changes {
+		switch {
+		case change.Kind == KindOperationAdded:
+			changelog.Endpoints.Added = append(changelog.Endpoints.Added, *endpoint(change))
+		case change.Kind == KindOperationRemoved:
+			entry := *endpoint(change)
+			entry.Breaking = true
+			changelog.Endpoints.Removed = append(changelog.Endpoints.Removed, entry)
+		case change.Kind == KindOperationDeprecated:
+			changelog.Endpoints.Deprecated = append(changelog.Endpoints.Deprecated, *endpoint(change))
+		case change.Kind == KindDefinitionAdded:
+			changelog.Schemas.Added = append(changelog.Schemas.Added, Entry{Name: change.Definition})
+		case change.Kind == KindDefinitionRemoved:
+			changelog.Schemas.Removed = append(changelog.Schemas.Removed, Entry{Name: change.Definition})
+		case change.Kind == KindDescriptionChanged:
+			// Documentation only, left out of the release notes
+		case change.Definition != "":
+			// Listed once under the schema, the endpoint refers to it
+			schema(change.Definition).add(change)
+			endpoint(change).referTo(change)
+		case change.Method != "":
+			endpoint(change).add(change)
+		}
+	}
+
+	for _, name := range endpointOrder {
+		if entry := endpoints[name]; len(entry.Changes) > 0 {
+			changelog.Endpoints.Changed = append(changelog.Endpoints.Changed, *entry)
+		}
+	}
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		changelog.Schemas.Changed = append(changelog.Schemas.Changed, *schemas[name])
+	}
+	return changelog
+}
+
+// add appends a change, the same change reached from several endpoints is
+// listed once
+func (e *Entry) add(change Change) {
+	for _, existing := range e.Changes {
+		if existing.Message == change.Message {
+			return
+		}
+	}
+	e.Changes = append(e.Changes, change)
+	e.Breaking = e.Breaking || change.Level == Breaking
+}
+
+// referTo lists a change of a schema the endpoint uses as a change of the
+// schema, the most severe level of its changes is kept
+func (e *Entry) referTo(change Change) {
+	e.Breaking = e.Breaking || change.Level == Breaking
+	message := fmt.Sprintf("schema %s changed", change.Definition)
+	for i, existing := range e.Changes {
+		if existing.Message == message {
+			if change.Level == Breaking {
+				e.Changes[i].Level = Breaking
+			}
+			return
+		}
+	}
+	change.Location, change.Field = "", ""
+	change.Message = message
+	e.Changes = append(e.Changes, change)
+}
+
+var bumpReasons = map[Bump]string{
+	BumpMajor: "breaking changes",
+	BumpMinor: "compatible additions or deprecations",
+	BumpPatch: "documentation changes",
+}
+
+// WriteMarkdown writes the changelog as Markdown release notes
+func (c Changelog) WriteMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	switch {
+	case c.OldVersion != "" && c.NewVersion != "" && c.OldVersion != c.NewVersion:
+		fmt.Fprintf(&b, "# API changes %s → %s\n\n", c.OldVersion, c.NewVersion)
+	case c.NewVersion != "":
+		fmt.Fprintf(&b, "# API changes in %s\n\n", c.NewVersion)
+	default:
+		b.WriteString("# API changes\n\n")
+	}
+
+	if len(c.Report.Changes) == 0 {
+		b.WriteString("No changes.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+
+	fmt.Fprintf(&b, "%s changes.", c.Report.Summary())
+	if c.Next != "" {
+		fmt.Fprintf(&b, " Suggested version: **%s** (%s).", c.Next, bumpReasons[c.Bump])
+		if c.NewVersion != "" && !versionAtLeast(c.NewVersion, c.Next) {
+			fmt.Fprintf(&b, "\n\n> The new spec is versioned %s, these changes call for %s or later.", c.NewVersion, c.Next)
+		}
+	}
+	b.WriteString("\n")
+
+	c.Endpoints.write(&b, "Endpoints", true)
+	c.Schemas.write(&b, "Schemas", false)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (s Section) write(b *strings.Builder, title string, code bool) {
+	if len(s.Added)+len(s.Changed)+len(s.Deprecated)+len(s.Removed) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n## %s\n", title)
+
+	for _, group := range []struct {
+		title   string
+		entries []Entry
+	}{
+		{"Added", s.Added},
+		{"Changed", s.Changed},
+		{"Deprecated", s.Deprecated},
+		{"Removed", s.Removed},
+	} {
+		if len(group.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "\n### %s\n\n", group.title)
+		for _, entry := range group.entries {
+			name := entry.Name
+			if code {
+				name = "`" + name + "`"
+			}
+			b.WriteString("- " + name)
+			if entry.Summary != "" {
+				b.WriteString(" — " + entry.Summary)
+			}
+			if entry.Breaking {
+				b.WriteString(" **(breaking)**")
+			}
+			b.WriteString("\n")
+			for _, change := range entry.Changes {
+				b.WriteString("  - ")
+				if change.Level == Breaking {
+					b.WriteString("**Breaking:** ")
+				}
+				b.WriteString(change.Message + "\n")
+			}
+		}
+	}
+}
+
+// versionAtLeast tells whether version is min or later, versions that don't
+// parse are accepted
+func versionAtLeast(version, min string) bool {
+	v, err := parseVersion(version)
+	if err != nil {
+		return true
+	}
+	m, err := parseVersion(min)
+	if err != nil {
+		return true
+	}
+	for i := range v {
+		if v[i] != m[i] {
+			return v[i] > m[i]
+		}
+	}
+	return true
+}
@@ -0,0 +1,179 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+func TestNextVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		bump    Bump
+		want    string
+		wantErr bool
+	}{
+		{"1.4.2", BumpMajor, "2.0.0", false},
+		{"1.4.2", BumpMinor, "1.5.0", false},
+		{"1.4.2", BumpPatch, "1.4.3", false},
+		{"1.4.2", BumpNone, "1.4.2", false},
+		{"v0.3", BumpPatch, "v0.3.1", false},
+		{"0.3.1", BumpMajor, "0.4.0", false}, // not stable yet
+		{"2", BumpMinor, "2.1.0", false},
+		{"1.0.0-beta.1+build.5", BumpPatch, "1.0.1", false},
+		{"1.2.3.4", BumpPatch, "", true},
+		{"latest", BumpPatch, "", true},
+		{"1.-1.0", BumpPatch, "", true},
+	}
+	for _, tt := range tests {
+		got, err := NextVersion(tt.version, tt.bump)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NextVersion(%q, %q) = %q, %v, want %q", tt.version, tt.bump, got, err, tt.want)
+		}
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version, min string
+		want         bool
+	}{
+		{"2.0.0", "2.0.0", true},
+		{"2.1.0", "2.0.0", true},
+		{"1.9.9", "2.0.0", false},
+		{"v1.3", "1.2.5", true},
+		{"latest", "2.0.0", true},
+	}
+	for _, tt := range tests {
+		if got := versionAtLeast(tt.version, tt.min); got != tt.want {
+			t.Errorf("versionAtLeast(%q, %q) = %v, want %v", tt.version, tt.min, got, tt.want)
+		}
+	}
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		levels []Level
+		want   Bump
+	}{
+		{nil, BumpNone},
+		{[]Level{Info}, BumpPatch},
+		{[]Level{Info, NonBreaking}, BumpMinor},
+		{[]Level{NonBreaking, Breaking, Info}, BumpMajor},
+	}
+	for _, tt := range tests {
+		var report Report
+		for _, level := range tt.levels {
+			report.Changes = append(report.Changes, Change{Level: level})
+		}
+		if got := report.Bump(); got != tt.want {
+			t.Errorf("Bump() of %v = %q, want %q", tt.levels, got, tt.want)
+		}
+	}
+}
+
+func TestBumpOfKinds(t *testing.T) {
+	tests := []struct {
+		change Change
+		want   Bump
+	}{
+		{Change{Level: Info, Kind: KindDescriptionChanged}, BumpPatch},
+		{Change{Level: Info, Kind: KindOperationIDChanged}, BumpPatch},
+		{Change{Level: Info, Kind: KindOperationDeprecated}, BumpMinor},
+		{Change{Level: Info, Kind: KindHostChanged}, BumpMajor},
+		{Change{Level: Breaking, Kind: KindBasePathChanged}, BumpMajor},
+		{Change{Level: NonBreaking, Kind: KindOperationAdded}, BumpMinor},
+		{Change{Level: Breaking, Kind: KindOperationRemoved}, BumpMajor},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.change.Kind), func(t *testing.T) {
+			report := Report{Changes: []Change{{Level: Info, Kind: KindDescriptionChanged}, tt.change}}
+			if got := report.Bump(); got != tt.want {
+				t.Errorf("Bump() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewChangelog(t *testing.T) {
+	old, changed := loadSpec(t), loadSpec(t)
+	changed.Info.Version = "1.3.0"
+	changed.Paths.Paths["/users/{id}"].Get.Deprecated = true
+	changed.Paths.Paths["/users"] = spec.PathItem{PathItemProps: spec.PathItemProps{
+		Post: &spec.Operation{OperationProps: spec.OperationProps{Summary: "Create a user"}},
+	}}
+	changed.Definitions["Group"] = spec.Schema{}
+	setUser(changed, func(u *spec.Schema) { u.Properties["name"] = *spec.Int64Property() })
+
+	changelog := NewChangelog(old, changed)
+
+	if changelog.Bump != BumpMajor || changelog.Next != "2.0.0" || changelog.OldVersion != "1.2.0" || changelog.NewVersion != "1.3.0" {
+		t.Errorf("versions = %q %q, bump %q to %q", changelog.OldVersion, changelog.NewVersion, changelog.Bump, changelog.Next)
+	}
+
+	names := func(entries []Entry) []string {
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name)
+		}
+		return names
+	}
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"added endpoints", names(changelog.Endpoints.Added), []string{"POST /users"}},
+		{"changed endpoints", names(changelog.Endpoints.Changed), []string{"GET /users/{id}", "PUT /users/{id}"}},
+		{"deprecated endpoints", names(changelog.Endpoints.Deprecated), []string{"GET /users/{id}"}},
+		{"removed endpoints", names(changelog.Endpoints.Removed), nil},
+		{"added schemas", names(changelog.Schemas.Added), []string{"Group"}},
+		{"changed schemas", names(changelog.Schemas.Changed), []string{"User"}},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// the schema change is listed once under the schema, endpoints refer to it
+	if user := changelog.Schemas.Changed[0]; len(user.Changes) != 2 || !user.Breaking {
+		t.Errorf("User changes = %+v", user.Changes)
+	}
+	if get := changelog.Endpoints.Changed[0]; len(get.Changes) != 1 || get.Changes[0].Message != "schema User changed" {
+		t.Errorf("GET changes = %+v", get.Changes)
+	}
+	if added := changelog.Endpoints.Added[0]; added.Summary != "Create a user" {
+		t.Errorf("summary = %q", added.Summary)
+	}
+
+	var markdown bytes.Buffer
+	if err := changelog.WriteMarkdown(&markdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# API changes 1.2.0 → 1.3.0\n",
+		"Suggested version: **2.0.0** (breaking changes).",
+		"> The new spec is versioned 1.3.0, these changes call for 2.0.0 or later.",
+		"- `POST /users` — Create a user\n",
+		"- `GET /users/{id}` — Get a user **(breaking)**\n  - **Breaking:** schema User changed\n",
+		"### Added\n\n- Group\n",
+	} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("markdown doesn't contain %q:\n%s", want, markdown.String())
+		}
+	}
+}
+
+func TestChangelogWithoutChanges(t *testing.T) {
+	var markdown bytes.Buffer
+	if err := NewChangelog(loadSpec(t), loadSpec(t)).WriteMarkdown(&markdown); err != nil {
+		t.Fatal(err)
+	}
+	if want := "# API changes in 1.2.0\n\nNo changes.\n"; markdown.String() != want {
+		t.Errorf("markdown = %q, want %q", markdown.String(), want)
+	}
+}
@@ -0,0 +1,528 @@
+// Package diff compares two specs and classifies the changes by their effect
+// on API consumers
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// Level tells whether a change breaks existing clients
+type Level string
+
+const (
+	// Breaking changes fail requests or responses of existing clients
+	Breaking Level = "breaking"
+	// NonBreaking changes are compatible additions and relaxations
+	NonBreaking Level = "non-breaking"
+	// Info changes don't affect the wire format, e.g. documentation
+	Info Level = "info"
+)
+
+// Kind classifies a change
+type Kind string
+
+const (
+	KindBasePathChanged     Kind = "base_path_changed"
+	KindHostChanged         Kind = "host_changed"
+	KindSchemeRemoved       Kind = "scheme_removed"
+	KindSchemeAdded         Kind = "scheme_added"
+	KindOperationAdded      Kind = "operation_added"
+	KindOperationRemoved    Kind = "operation_removed"
+	KindOperationDeprecated Kind = "operation_deprecated"
+	KindOperationIDChanged  Kind = "operation_id_changed"
+	KindDescriptionChanged  Kind = "description_changed"
+	KindParameterAdded      Kind = "parameter_added"
+	KindParameterRemoved    Kind = "parameter_removed"
+	KindParameterMoved      Kind = "parameter_moved" // same name, other location
+	KindMediaTypeAdded      Kind = "media_type_added"
+	KindMediaTypeRemoved    Kind = "media_type_removed"
+	KindSecurityChanged     Kind = "security_changed"
+	KindResponseAdded       Kind = "response_added"
+	KindResponseRemoved     Kind = "response_removed"
+	KindPropertyAdded       Kind = "property_added"
+	KindPropertyRemoved     Kind = "property_removed"
+	KindBecameRequired      Kind = "became_required"
+	KindBecameOptional      Kind = "became_optional"
+	KindTypeChanged         Kind = "type_changed"
+	KindFormatChanged       Kind = "format_changed"
+	KindEnumNarrowed        Kind = "enum_narrowed"
+	KindEnumWidened         Kind = "enum_widened"
+	KindConstraintNarrowed  Kind = "constraint_narrowed"
+	KindConstraintWidened   Kind = "constraint_widened"
+	KindDefinitionAdded     Kind = "definition_added"
+	KindDefinitionRemoved   Kind = "definition_removed"
+)
+
+// Change is one difference between the two specs. Method and Path are set for
+// changes of an operation, Path being the one of the new spec unless the
+// operation was removed. Location is where in the operation the change is,
+// e.g. "request body", "query parameter page" or "response 200", and Field
+// the property below it, e.g. "items[].name". Definition is the definition
+// holding the changed property, or the added or removed definition.
+type Change struct {
+	Level      Level  `json:"level"`
+	Kind       Kind   `json:"kind"`
+	Method     string `json:"method,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Location   string `json:"location,omitempty"`
+	Field      string `json:"field,omitempty"`
+	Definition string `json:"definition,omitempty"`
+	Message    string `json:"message"`
+}
+
+func (c Change) String() string {
+	if c.Method == "" {
+		return c.Message
+	}
+	return fmt.Sprintf("%s %s: %s", c.Method, c.Path, c.Message)
+}
+
+// Compare lists the changes from old to new, breaking or not, in the order
+// of the paths and methods
+func Compare(old, new *spec.Swagger) Report {
+	c := &comparer{old: old, new: new}
+	c.compareSpec()
+	c.comparePaths()
+	c.compareDefinitions()
+	return Report{Changes: c.changes}
+}
+
+type comparer struct {
+	old, new *spec.Swagger
+	changes  []Change
+}
+
+// operationScope is the operation whose parts are being compared
+type operationScope struct {
+	method, path string
+}
+
+func (c *comparer) add(op operationScope, level Level, kind Kind, format string, args ...interface{}) *Change {
+	c.changes = append(c.changes, Change{
+		Level:   level,
+		Kind:    kind,
+		Method:  op.method,
+		Path:    op.path,
+		Message: fmt.Sprintf(format, args...),
+	})
+	return &c.changes[len(c.changes)-1]
+}
+
+func (c *comparer) compareSpec() {
+	var none operationScope
+	if c.old.BasePath != c.new.BasePath {
+		c.add(none, Breaking, KindBasePathChanged, "base path changed from %q to %q", c.old.BasePath, c.new.BasePath)
+	}
+	// The host usually depends on the environment the spec was generated for
+	if c.old.Host != c.new.Host {
+		c.add(none, Info, KindHostChanged, "host changed from %q to %q", c.old.Host, c.new.Host)
+	}
+	removed, added := stringSetDiff(c.old.Schemes, c.new.Schemes)
+	for _, scheme := range removed {
+		c.add(none, Breaking, KindSchemeRemoved, "scheme %s removed", scheme)
+	}
+	for _, scheme := range added {
+		c.add(none, NonBreaking, KindSchemeAdded, "scheme %s added", scheme)
+	}
+}
+
+// pathParam matches the parameters of a path template, renaming one doesn't
+// change the operation
+var pathParam = regexp.MustCompile(`\{[^}]*\}`)
+
+type operationEntry struct {
+	path      string
+	method    string
+	item      spec.PathItem
+	operation *spec.Operation
+}
+
+// operations returns the operations of a spec keyed by method and path
+// template without parameter names
+func operations(swagger *spec.Swagger) (map[string]operationEntry, []string) {
+	entries := make(map[string]operationEntry)
+	var keys []string
+	if swagger.Paths == nil {
+		return entries, keys
+	}
+
+	paths := make([]string, 0, len(swagger.Paths.Paths))
+	for path := range swagger.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := swagger.Paths.Paths[path]
+		for _, method := range methods {
+			operation := operationOf(item, method)
+			if operation == nil {
+				continue
+			}
+			key := method + " " + pathParam.ReplaceAllString(path, "{}")
+			entries[key] = operationEntry{path: path, method: method, item: item, operation: operation}
+			keys = append(keys, key)
+		}
+	}
+	return entries, keys
+}
+
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+func operationOf(item spec.PathItem, method string) *spec.Operation {
+	switch method {
+	case "GET":
+		return item.Get
+	case "POST":
+		return item.Post
+	case "PUT":
+		return item.Put
+	case "PATCH":
+		return item.Patch
+	case "DELETE":
+		return item.Delete
+	case "HEAD":
+		return item.Head
+	case "OPTIONS":
+		return item.Options
+	}
+	return nil
+}
+
+func (c *comparer) comparePaths() {
+	oldOperations, oldKeys := operations(c.old)
+	newOperations, newKeys := operations(c.new)
+
+	for _, key := range oldKeys {
+		oldEntry := oldOperations[key]
+		newEntry, ok := newOperations[key]
+		if !ok {
+			c.add(operationScope{oldEntry.method, oldEntry.path}, Breaking, KindOperationRemoved, "operation removed")
+			continue
+		}
+		c.compareOperation(oldEntry, newEntry)
+	}
+	for _, key := range newKeys {
+		if _, ok := oldOperations[key]; !ok {
+			entry := newOperations[key]
+			c.add(operationScope{entry.method, entry.path}, NonBreaking, KindOperationAdded, "operation added")
+		}
+	}
+}
+
+func (c *comparer) compareOperation(oldEntry, newEntry operationEntry) {
+	op := operationScope{newEntry.method, newEntry.path}
+	oldOp, newOp := oldEntry.operation, newEntry.operation
+
+	if !oldOp.Deprecated && newOp.Deprecated {
+		c.add(op, Info, KindOperationDeprecated, "operation deprecated")
+	}
+	if oldOp.ID != newOp.ID && oldOp.ID != "" {
+		c.add(op, Info, KindOperationIDChanged, "operationId changed from %s to %s", oldOp.ID, newOp.ID)
+	}
+	if oldOp.Summary != newOp.Summary || oldOp.Description != newOp.Description {
+		c.add(op, Info, KindDescriptionChanged, "summary or description changed")
+	}
+
+	c.compareParameters(op, oldEntry, newEntry)
+	c.compareMediaTypes(op, "request", c.old.Consumes, oldOp.Consumes, c.new.Consumes, newOp.Consumes)
+	c.compareMediaTypes(op, "response", c.old.Produces, oldOp.Produces, c.new.Produces, newOp.Produces)
+	c.compareSecurity(op, oldOp, newOp)
+	c.compareResponses(op, oldOp, newOp)
+}
+
+// parameters returns the parameters of an operation, including those of its
+// path item, keyed by location and name. Path parameters are keyed by their
+// position in the template so renaming them isn't a change.
+func parameters(swagger *spec.Swagger, entry operationEntry) (map[string]spec.Parameter, []string) {
+	params := make(map[string]spec.Parameter)
+	for _, list := range [][]spec.Parameter{entry.item.Parameters, entry.operation.Parameters} {
+		for _, param := range list {
+			param = resolveParameter(swagger, param)
+			params[param.In+" "+param.Name] = param
+		}
+	}
+
+	keyed := make(map[string]spec.Parameter, len(params))
+	for _, param := range params {
+		key := param.In + " " + param.Name
+		if param.In == "path" {
+			if i := strings.Index(entry.path, "{"+param.Name+"}"); i >= 0 {
+				key = fmt.Sprintf("path #%d", strings.Count(entry.path[:i], "{"))
+			}
+		}
+		keyed[key] = param
+	}
+
+	keys := make([]string, 0, len(keyed))
+	for key := range keyed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keyed, keys
+}
+
+func resolveParameter(swagger *spec.Swagger, param spec.Parameter) spec.Parameter {
+	if name, ok := strings.CutPrefix(param.Ref.String(), "#/parameters/"); ok {
+		if resolved, ok := swagger.Parameters[name]; ok {
+			return resolved
+		}
+	}
+	return param
+}
+
+func parameterLocation(param spec.Parameter) string {
+	if param.In == "body" {
+		return "request body"
+	}
+	return fmt.Sprintf("%s parameter %s", param.In, param.Name)
+}
+
+func (c *comparer) compareParameters(op operationScope, oldEntry, newEntry operationEntry) {
+	oldParams, oldKeys := parameters(c.old, oldEntry)
+	newParams, newKeys := parameters(c.new, newEntry)
+
+	// A parameter moving between the query and a header keeps its name
+	moved := make(map[string]bool)
+	for _, key := range oldKeys {
+		oldParam := oldParams[key]
+		newParam, ok := newParams[key]
+		if ok {
+			c.compareParameter(op, oldParam, newParam)
+			continue
+		}
+		if other, found := findParameter(newParams, oldParam.Name, oldParam.In); found && oldParam.In != "body" && oldParam.In != "path" {
+			moved[other.In+" "+other.Name] = true
+			c.add(op, Breaking, KindParameterMoved, "%s moved to %s", parameterLocation(oldParam), other.In).Location = parameterLocation(other)
+			continue
+		}
+		// Clients still sending the parameter are ignored rather than rejected
+		c.add(op, NonBreaking, KindParameterRemoved, "%s removed", parameterLocation(oldParam)).Location = parameterLocation(oldParam)
+	}
+	for _, key := range newKeys {
+		newParam := newParams[key]
+		if _, ok := oldParams[key]; ok || moved[key] {
+			continue
+		}
+		location := parameterLocation(newParam)
+		if newParam.Required {
+			c.add(op, Breaking, KindParameterAdded, "required %s added", location).Location = location
+		} else {
+			c.add(op, NonBreaking, KindParameterAdded, "optional %s added", location).Location = location
+		}
+	}
+}
+
+func findParameter(params map[string]spec.Parameter, name, notIn string) (spec.Parameter, bool) {
+	for _, param := range params {
+		if param.Name == name && param.In != notIn && param.In != "body" && param.In != "path" {
+			return param, true
+		}
+	}
+	return spec.Parameter{}, false
+}
+
+func (c *comparer) compareParameter(op operationScope, oldParam, newParam spec.Parameter) {
+	location := parameterLocation(newParam)
+	if !oldParam.Required && newParam.Required {
+		c.add(op, Breaking, KindBecameRequired, "%s became required", location).Location = location
+	}
+	if oldParam.Required && !newParam.Required {
+		c.add(op, NonBreaking, KindBecameOptional, "%s became optional", location).Location = location
+	}
+
+	s := &schemaScope{op: op, location: location, request: true, visited: make(map[string]bool)}
+	if newParam.In == "body" {
+		c.compareSchema(s, "", oldParam.Schema, newParam.Schema)
+		return
+	}
+	c.compareSchema(s, "", simpleSchema(oldParam.SimpleSchema, oldParam.CommonValidations), simpleSchema(newParam.SimpleSchema, newParam.CommonValidations))
+}
+
+// simpleSchema turns the simple schema of a non body parameter into a schema
+// so both are compared the same way
+func simpleSchema(simple spec.SimpleSchema, validations spec.CommonValidations) *spec.Schema {
+	schema := &spec.Schema{}
+	if simple.Type != "" {
+		schema.Type = spec.StringOrArray{simple.Type}
+	}
+	schema.Format = simple.Format
+	schema.Enum = validations.Enum
+	schema.Maximum = validations.Maximum
+	schema.Minimum = validations.Minimum
+	schema.MaxLength = validations.MaxLength
+	schema.MinLength = validations.MinLength
+	schema.MaxItems = validations.MaxItems
+	schema.MinItems = validations.MinItems
+	schema.Pattern = validations.Pattern
+	if simple.Items != nil {
+		schema.Items = &spec.SchemaOrArray{Schema: simpleSchema(simple.Items.SimpleSchema, simple.Items.CommonValidations)}
+	}
+	return schema
+}
+
+func (c *comparer) compareMediaTypes(op operationScope, side string, oldGlobal, oldTypes, newGlobal, newTypes []string) {
+	if len(oldTypes) == 0 {
+		oldTypes = oldGlobal
+	}
+	if len(newTypes) == 0 {
+		newTypes = newGlobal
+	}
+	if len(oldTypes) == 0 || len(newTypes) == 0 {
+		return
+	}
+
+	removed, added := stringSetDiff(oldTypes, newTypes)
+	for _, mediaType := range removed {
+		c.add(op, Breaking, KindMediaTypeRemoved, "%s media type %s removed", side, mediaType)
+	}
+	for _, mediaType := range added {
+		c.add(op, NonBreaking, KindMediaTypeAdded, "%s media type %s added", side, mediaType)
+	}
+}
+
+// compareSecurity compares the effective requirements, any of which a request
+// has to satisfy
+func (c *comparer) compareSecurity(op operationScope, oldOp, newOp *spec.Operation) {
+	oldSecurity, newSecurity := oldOp.Security, newOp.Security
+	if oldSecurity == nil {
+		oldSecurity = c.old.Security
+	}
+	if newSecurity == nil {
+		newSecurity = c.new.Security
+	}
+
+	removed, added := stringSetDiff(securityAlternatives(oldSecurity), securityAlternatives(newSecurity))
+	switch {
+	case len(oldSecurity) == 0 && len(newSecurity) > 0:
+		c.add(op, Breaking, KindSecurityChanged, "authentication now required: %s", strings.Join(added, " or "))
+	case len(removed) > 0 && len(newSecurity) > 0:
+		c.add(op, Breaking, KindSecurityChanged, "security requirement %s removed", strings.Join(removed, " or "))
+	case len(added) > 0 || len(removed) > 0:
+		c.add(op, NonBreaking, KindSecurityChanged, "security requirements relaxed")
+	}
+}
+
+func securityAlternatives(security []map[string][]string) []string {
+	var alternatives []string
+	for _, requirement := range security {
+		var schemes []string
+		for name, scopes := range requirement {
+			scopes = append([]string(nil), scopes...)
+			sort.Strings(scopes)
+			if len(scopes) > 0 {
+				name += "[" + strings.Join(scopes, ",") + "]"
+			}
+			schemes = append(schemes, name)
+		}
+		sort.Strings(schemes)
+		if len(schemes) == 0 {
+			alternatives = append(alternatives, "anonymous")
+			continue
+		}
+		alternatives = append(alternatives, strings.Join(schemes, "+"))
+	}
+	return alternatives
+}
+
+func responses(swagger *spec.Swagger, op *spec.Operation) (map[string]spec.Response, []string) {
+	result := make(map[string]spec.Response)
+	if op.Responses == nil {
+		return result, nil
+	}
+	if op.Responses.Default != nil {
+		result["default"] = resolveResponse(swagger, *op.Responses.Default)
+	}
+	for code, response := range op.Responses.StatusCodeResponses {
+		result[fmt.Sprint(code)] = resolveResponse(swagger, response)
+	}
+
+	codes := make([]string, 0, len(result))
+	for code := range result {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return result, codes
+}
+
+func resolveResponse(swagger *spec.Swagger, response spec.Response) spec.Response {
+	if name, ok := strings.CutPrefix(response.Ref.String(), "#/responses/"); ok {
+		if resolved, ok := swagger.Responses[name]; ok {
+			return resolved
+		}
+	}
+	return response
+}
+
+func (c *comparer) compareResponses(op operationScope, oldOp, newOp *spec.Operation) {
+	oldResponses, oldCodes := responses(c.old, oldOp)
+	newResponses, newCodes := responses(c.new, newOp)
+
+	for _, code := range oldCodes {
+		location := "response " + code
+		newResponse, ok := newResponses[code]
+		if !ok {
+			c.add(op, Breaking, KindResponseRemoved, "%s removed", location).Location = location
+			continue
+		}
+		s := &schemaScope{op: op, location: location, visited: make(map[string]bool)}
+		c.compareSchema(s, "", oldResponses[code].Schema, newResponse.Schema)
+	}
+	for _, code := range newCodes {
+		if _, ok := oldResponses[code]; !ok {
+			location := "response " + code
+			c.add(op, NonBreaking, KindResponseAdded, "%s added", location).Location = location
+		}
+	}
+}
+
+// compareDefinitions reports the added and removed definitions, changes of
+// their properties are reported where the operations use them
+func (c *comparer) compareDefinitions() {
+	var none operationScope
+	for _, name := range sortedKeys(c.old.Definitions) {
+		if _, ok := c.new.Definitions[name]; !ok {
+			c.add(none, Info, KindDefinitionRemoved, "definition %s removed", name).Definition = name
+		}
+	}
+	for _, name := range sortedKeys(c.new.Definitions) {
+		if _, ok := c.old.Definitions[name]; !ok {
+			c.add(none, Info, KindDefinitionAdded, "definition %s added", name).Definition = name
+		}
+	}
+}
+
+func sortedKeys(definitions spec.Definitions) []string {
+	keys := make([]string, 0, len(definitions))
+	for key := range definitions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// stringSetDiff returns the values only in old and those only in new
+func stringSetDiff(old, new []string) (removed, added []string) {
+	inOld := make(map[string]bool, len(old))
+	for _, value := range old {
+		inOld[value] = true
+	}
+	inNew := make(map[string]bool, len(new))
+	for _, value := range new {
+		inNew[value] = true
+		if !inOld[value] {
+			added = append(added, value)
+		}
+	}
+	for _, value := range old {
+		if !inNew[value] {
+			removed = append(removed, value)
+		}
+	}
+	return removed, added
+}
@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+// usersSpec is the old spec of the tests, User is both the request body of
+// the PUT and the response of the GET
+const usersSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Users", "version": "1.2.0"},
+  "basePath": "/api",
+  "produces": ["application/json", "application/xml"],
+  "paths": {
+    "/users/{id}": {
+      "get": {
+        "operationId": "getUser",
+        "summary": "Get a user",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "integer"},
+          {"name": "fields", "in": "query", "type": "string"}
+        ],
+        "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/User"}}}
+      },
+      "put": {
+        "operationId": "updateUser",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "integer"},
+          {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}
+        ],
+        "responses": {"204": {"description": "No Content"}}
+      }
+    }
+  },
+  "definitions": {
+    "User": {
+      "type": "object",
+      "required": ["name"],
+      "properties": {
+        "name": {"type": "string", "maxLength": 50},
+        "role": {"type": "string", "enum": ["admin", "member"]}
+      }
+    }
+  }
+}`
+
+func loadSpec(t *testing.T) *spec.Swagger {
+	t.Helper()
+	var swagger spec.Swagger
+	if err := json.Unmarshal([]byte(usersSpec), &swagger); err != nil {
+		t.Fatal(err)
+	}
+	return &swagger
+}
+
+// setUser changes the User definition of a spec
+func setUser(swagger *spec.Swagger, change func(*spec.Schema)) {
+	definition := swagger.Definitions["User"]
+	change(&definition)
+	swagger.Definitions["User"] = definition
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *spec.Swagger)
+		want   []string // "level kind" in report order
+	}{
+		{"identical", func(s *spec.Swagger) {}, nil},
+		{"base path", func(s *spec.Swagger) { s.BasePath = "/api/v2" }, []string{"breaking base_path_changed"}},
+		{"host", func(s *spec.Swagger) { s.Host = "example.com" }, []string{"info host_changed"}},
+		{"operation removed", func(s *spec.Swagger) {
+			item := s.Paths.Paths["/users/{id}"]
+			item.Get = nil
+			s.Paths.Paths["/users/{id}"] = item
+		}, []string{"breaking operation_removed"}},
+		{"operation added", func(s *spec.Swagger) {
+			s.Paths.Paths["/users"] = spec.PathItem{PathItemProps: spec.PathItemProps{Post: &spec.Operation{}}}
+		}, []string{"non-breaking operation_added"}},
+		{"path parameter renamed", func(s *spec.Swagger) {
+			item := s.Paths.Paths["/users/{id}"]
+			delete(s.Paths.Paths, "/users/{id}")
+			item.Get.Parameters[0].Name = "userId"
+			item.Put.Parameters[0].Name = "userId"
+			s.Paths.Paths["/users/{userId}"] = item
+		}, nil},
+		{"documentation", func(s *spec.Swagger) {
+			get := s.Paths.Paths["/users/{id}"].Get
+			get.Summary = "Fetch a user"
+			get.Deprecated = true
+			get.ID = "fetchUser"
+		}, []string{"info operation_deprecated", "info operation_id_changed", "info description_changed"}},
+		{"required parameter added", func(s *spec.Swagger) {
+			get := s.Paths.Paths["/users/{id}"].Get
+			get.Parameters = append(get.Parameters, *spec.QueryParam("tenant").Typed("string", "").AsRequired())
+		}, []string{"breaking parameter_added"}},
+		{"optional parameter added", func(s *spec.Swagger) {
+			get := s.Paths.Paths["/users/{id}"].Get
+			get.Parameters = append(get.Parameters, *spec.QueryParam("expand").Typed("boolean", ""))
+		}, []string{"non-breaking parameter_added"}},
+		{"parameter removed", func(s *spec.Swagger) {
+			get := s.Paths.Paths["/users/{id}"].Get
+			get.Parameters = get.Parameters[:1]
+		}, []string{"non-breaking parameter_removed"}},
+		{"parameter moved", func(s *spec.Swagger) {
+			s.Paths.Paths["/users/{id}"].Get.Parameters[1].In = "header"
+		}, []string{"breaking parameter_moved"}},
+		{"parameter became required", func(s *spec.Swagger) {
+			s.Paths.Paths["/users/{id}"].Get.Parameters[1].Required = true
+		}, []string{"breaking became_required"}},
+		{"response removed and added", func(s *spec.Swagger) {
+			s.Paths.Paths["/users/{id}"].Put.Responses.StatusCodeResponses = map[int]spec.Response{200: {}}
+		}, []string{"breaking response_removed", "non-breaking response_added"}},
+		{"media type removed", func(s *spec.Swagger) {
+			s.Produces = []string{"application/json"}
+		}, []string{"breaking media_type_removed", "breaking media_type_removed"}},
+		{"authentication required", func(s *spec.Swagger) {
+			s.Security = []map[string][]string{{"ApiKey": {}}}
+		}, []string{"breaking security_changed", "breaking security_changed"}},
+		{"definition added", func(s *spec.Swagger) {
+			s.Definitions["Group"] = spec.Schema{}
+		}, []string{"info definition_added"}},
+
+		// GET responses come before the PUT request body
+		{"enum value added", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) {
+				role := u.Properties["role"]
+				role.Enum = append(role.Enum, "guest")
+				u.Properties["role"] = role
+			})
+		}, []string{"breaking enum_widened", "non-breaking enum_widened"}},
+		{"enum value removed", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) {
+				role := u.Properties["role"]
+				role.Enum = role.Enum[:1]
+				u.Properties["role"] = role
+			})
+		}, []string{"non-breaking enum_narrowed", "breaking enum_narrowed"}},
+		{"stricter constraint", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) {
+				name := u.Properties["name"]
+				name.MaxLength = new(int64)
+				*name.MaxLength = 20
+				u.Properties["name"] = name
+			})
+		}, []string{"non-breaking constraint_narrowed", "breaking constraint_narrowed"}},
+		{"looser constraint", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) {
+				name := u.Properties["name"]
+				name.MaxLength = nil
+				u.Properties["name"] = name
+			})
+		}, []string{"breaking constraint_widened", "non-breaking constraint_widened"}},
+		{"required property added", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) {
+				u.Properties["email"] = *spec.StringProperty()
+				u.Required = append(u.Required, "email")
+			})
+		}, []string{"non-breaking property_added", "breaking property_added"}},
+		{"property removed", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) { delete(u.Properties, "role") })
+		}, []string{"breaking property_removed", "non-breaking property_removed"}},
+		{"property became optional", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) { u.Required = nil })
+		}, []string{"breaking became_optional", "non-breaking became_optional"}},
+		{"type changed", func(s *spec.Swagger) {
+			setUser(s, func(u *spec.Schema) { u.Properties["name"] = *spec.Int64Property() })
+		}, []string{"breaking type_changed", "breaking type_changed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, changed := loadSpec(t), loadSpec(t)
+			tt.change(changed)
+
+			var got []string
+			for _, change := range Compare(old, changed).Changes {
+				got = append(got, string(change.Level)+" "+string(change.Kind))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changes = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeLocation(t *testing.T) {
+	old, changed := loadSpec(t), loadSpec(t)
+	setUser(changed, func(u *spec.Schema) { u.Properties["name"] = *spec.Int64Property() })
+
+	want := []Change{
+		{Level: Breaking, Kind: KindTypeChanged, Method: "GET", Path: "/users/{id}", Location: "response 200", Field: "name", Definition: "User",
+			Message: "type of response 200 property name changed from string to integer"},
+		{Level: Breaking, Kind: KindTypeChanged, Method: "PUT", Path: "/users/{id}", Location: "request body", Field: "name", Definition: "User",
+			Message: "type of request body property name changed from string to integer"},
+	}
+	if got := Compare(old, changed).Changes; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := Report{Changes: []Change{
+		{Level: Breaking, Kind: KindOperationRemoved, Method: "GET", Path: "/users", Message: "operation removed"},
+		{Level: Info, Kind: KindHostChanged, Message: "host changed"},
+	}}
+	if !report.HasBreaking() || (Report{}).HasBreaking() {
+		t.Error("HasBreaking is wrong")
+	}
+	if summary := report.Summary(); summary != "1 breaking, 0 non-breaking, 1 info" {
+		t.Errorf("Summary() = %q", summary)
+	}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text.String(), "breaking      GET /users: operation removed\n") {
+		t.Errorf("text report:\n%s", text.String())
+	}
+
+	var encoded struct {
+		Breaking int      `json:"breaking"`
+		Changes  []Change `json:"changes"`
+	}
+	var buf bytes.Buffer
+	if err := (Report{}).WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &encoded); err != nil || encoded.Changes == nil {
+		t.Errorf("empty JSON report = %s", buf.String())
+	}
+}
@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Report holds the changes found by Compare
+type Report struct {
+	Changes []Change `json:"changes"`
+}
+
+// Of returns the changes of the given levels
+func (r Report) Of(levels ...Level) []Change {
+	var changes []Change
+	for _, change := range r.Changes {
+		for _, level := range levels {
+			if change.Level == level {
+				changes = append(changes, change)
+				break
+			}
+		}
+	}
+	return changes
+}
+
+// HasBreaking tells whether any change breaks existing clients
+func (r Report) HasBreaking() bool {
+	return len(r.Of(Breaking)) > 0
+}
+
+// Summary counts the changes of each level, e.g. "2 breaking, 1 non-breaking, 0 info"
+func (r Report) Summary() string {
+	return fmt.Sprintf("%d breaking, %d non-breaking, %d info", len(r.Of(Breaking)), len(r.Of(NonBreaking)), len(r.Of(Info)))
+}
+
+// WriteText writes one line per change followed by the summary
+func (r Report) WriteText(w io.Writer) error {
+	if len(r.Changes) == 0 {
+		_, err := fmt.Fprintln(w, "no changes")
+		return err
+	}
+
+	var b strings.Builder
+	for _, change := range r.Changes {
+		fmt.Fprintf(&b, "%-13s %s\n", change.Level, change)
+	}
+	fmt.Fprintf(&b, "\n%s\n", r.Summary())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the changes with their count per level
+func (r Report) WriteJSON(w io.Writer) error {
+	changes := r.Changes
+	if changes == nil {
+		changes = []Change{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Breaking    int      `json:"breaking"`
+		NonBreaking int      `json:"nonBreaking"`
+		Info        int      `json:"info"`
+		Changes     []Change `json:"changes"`
+	}{len(r.Of(Breaking)), len(r.Of(NonBreaking)), len(r.Of(Info)), changes})
+}
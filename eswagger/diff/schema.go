@@ -0,0 +1,258 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// schemaScope is a schema of an operation being compared. Changes of a
+// request schema break clients when they restrict what is accepted, changes
+// of a response schema when they restrict what is returned.
+type schemaScope struct {
+	op         operationScope
+	location   string
+	request    bool
+	definition string          // innermost definition being compared
+	visited    map[string]bool // definition pairs already compared, for recursive types
+}
+
+func (c *comparer) addSchema(s *schemaScope, field string, level Level, kind Kind, format string, args ...interface{}) {
+	change := c.add(s.op, level, kind, format, args...)
+	change.Location = s.location
+	change.Field = field
+	change.Definition = s.definition
+}
+
+// subject names the compared value in messages
+func (s *schemaScope) subject(field string) string {
+	if field == "" {
+		return s.location
+	}
+	return fmt.Sprintf("%s property %s", s.location, field)
+}
+
+// breakingIf returns Breaking when the change restricts the side being
+// compared, i.e. when restrictsRequest matches the direction of the schema
+func (s *schemaScope) breakingIf(restrictsRequest bool) Level {
+	if s.request == restrictsRequest {
+		return Breaking
+	}
+	return NonBreaking
+}
+
+// resolve follows the definition references of a schema
+func resolve(swagger *spec.Swagger, schema *spec.Schema) (*spec.Schema, string) {
+	var name string
+	for depth := 0; schema != nil && depth < 16; depth++ {
+		ref, ok := strings.CutPrefix(schema.Ref.String(), "#/definitions/")
+		if !ok {
+			break
+		}
+		definition, found := swagger.Definitions[ref]
+		if !found {
+			break
+		}
+		name, schema = ref, &definition
+	}
+	return schema, name
+}
+
+func (c *comparer) compareSchema(s *schemaScope, field string, old, new *spec.Schema) {
+	old, oldName := resolve(c.old, old)
+	new, newName := resolve(c.new, new)
+	if old == nil || new == nil {
+		return
+	}
+
+	if newName != "" {
+		key := oldName + " " + newName
+		if s.visited[key] {
+			return
+		}
+		s.visited[key] = true
+
+		definition := s.definition
+		s.definition = newName
+		defer func() { s.definition = definition }()
+	}
+
+	subject := s.subject(field)
+	if oldType, newType := schemaType(old), schemaType(new); oldType != newType {
+		c.addSchema(s, field, Breaking, KindTypeChanged, "type of %s changed from %s to %s", subject, oldType, newType)
+		return
+	}
+	if old.Format != new.Format {
+		c.addSchema(s, field, Breaking, KindFormatChanged, "format of %s changed from %q to %q", subject, old.Format, new.Format)
+	}
+
+	c.compareEnum(s, field, old.Enum, new.Enum)
+	c.compareConstraints(s, field, old, new)
+	c.compareProperties(s, field, old, new)
+
+	if old.Items != nil && new.Items != nil && old.Items.Schema != nil && new.Items.Schema != nil {
+		c.compareSchema(s, field+"[]", old.Items.Schema, new.Items.Schema)
+	}
+	if old.AdditionalProperties != nil && new.AdditionalProperties != nil {
+		c.compareSchema(s, field+".*", old.AdditionalProperties.Schema, new.AdditionalProperties.Schema)
+	}
+}
+
+// schemaType returns the type of a schema, objects may leave it out
+func schemaType(schema *spec.Schema) string {
+	switch {
+	case len(schema.Type) > 0:
+		return strings.Join(schema.Type, ",")
+	case len(schema.Properties) > 0 || schema.AdditionalProperties != nil:
+		return "object"
+	default:
+		return "any"
+	}
+}
+
+func (c *comparer) compareEnum(s *schemaScope, field string, old, new []interface{}) {
+	subject := s.subject(field)
+	switch {
+	case len(old) == 0 && len(new) == 0:
+		return
+	case len(old) == 0:
+		c.addSchema(s, field, s.breakingIf(true), KindEnumNarrowed, "%s restricted to %s", subject, enumValues(new))
+		return
+	case len(new) == 0:
+		c.addSchema(s, field, s.breakingIf(false), KindEnumWidened, "%s no longer restricted to %s", subject, enumValues(old))
+		return
+	}
+
+	// A new value in a response breaks the clients switching over the values
+	removed, added := stringSetDiff(enumKeys(old), enumKeys(new))
+	if len(removed) > 0 {
+		c.addSchema(s, field, s.breakingIf(true), KindEnumNarrowed, "values %s removed from %s", strings.Join(removed, ", "), subject)
+	}
+	if len(added) > 0 {
+		c.addSchema(s, field, s.breakingIf(false), KindEnumWidened, "values %s added to %s", strings.Join(added, ", "), subject)
+	}
+}
+
+// enumKeys encodes enum values so values of any type are compared
+func enumKeys(values []interface{}) []string {
+	keys := make([]string, len(values))
+	for i, value := range values {
+		encoded, _ := json.Marshal(value)
+		keys[i] = string(encoded)
+	}
+	return keys
+}
+
+func enumValues(values []interface{}) string {
+	return strings.Join(enumKeys(values), ", ")
+}
+
+// compareConstraints reports the validations becoming stricter or looser
+func (c *comparer) compareConstraints(s *schemaScope, field string, old, new *spec.Schema) {
+	subject := s.subject(field)
+	report := func(name string, stricter bool) {
+		if stricter {
+			c.addSchema(s, field, s.breakingIf(true), KindConstraintNarrowed, "%s of %s is stricter", name, subject)
+		} else {
+			c.addSchema(s, field, s.breakingIf(false), KindConstraintWidened, "%s of %s is looser", name, subject)
+		}
+	}
+
+	compareBound(old.Maximum, new.Maximum, true, func(stricter bool) { report("maximum", stricter) })
+	compareBound(old.Minimum, new.Minimum, false, func(stricter bool) { report("minimum", stricter) })
+	compareBound(intBound(old.MaxLength), intBound(new.MaxLength), true, func(stricter bool) { report("maxLength", stricter) })
+	compareBound(intBound(old.MinLength), intBound(new.MinLength), false, func(stricter bool) { report("minLength", stricter) })
+	compareBound(intBound(old.MaxItems), intBound(new.MaxItems), true, func(stricter bool) { report("maxItems", stricter) })
+	compareBound(intBound(old.MinItems), intBound(new.MinItems), false, func(stricter bool) { report("minItems", stricter) })
+
+	// Whether a pattern accepts more or less than another can't be told
+	if old.Pattern != new.Pattern {
+		c.addSchema(s, field, s.breakingIf(true), KindConstraintNarrowed, "pattern of %s changed from %q to %q", subject, old.Pattern, new.Pattern)
+	}
+}
+
+func intBound(value *int64) *float64 {
+	if value == nil {
+		return nil
+	}
+	bound := float64(*value)
+	return &bound
+}
+
+// compareBound calls changed when an upper or lower bound moved, stricter
+// telling whether fewer values are valid
+func compareBound(old, new *float64, upper bool, changed func(stricter bool)) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		changed(true)
+	case new == nil:
+		changed(false)
+	case *old != *new:
+		changed((*new < *old) == upper)
+	}
+}
+
+func (c *comparer) compareProperties(s *schemaScope, field string, old, new *spec.Schema) {
+	oldRequired := stringSet(old.Required)
+	newRequired := stringSet(new.Required)
+
+	for _, name := range sortedProperties(old.Properties) {
+		property := joinField(field, name)
+		newProperty, ok := new.Properties[name]
+		if !ok {
+			// Clients may still send a removed request property, it is ignored
+			c.addSchema(s, property, s.breakingIf(false), KindPropertyRemoved, "%s removed", s.subject(property))
+			continue
+		}
+
+		switch {
+		case !oldRequired[name] && newRequired[name]:
+			c.addSchema(s, property, s.breakingIf(true), KindBecameRequired, "%s became required", s.subject(property))
+		case oldRequired[name] && !newRequired[name]:
+			c.addSchema(s, property, s.breakingIf(false), KindBecameOptional, "%s became optional", s.subject(property))
+		}
+
+		oldProperty := old.Properties[name]
+		c.compareSchema(s, property, &oldProperty, &newProperty)
+	}
+
+	for _, name := range sortedProperties(new.Properties) {
+		if _, ok := old.Properties[name]; ok {
+			continue
+		}
+		property := joinField(field, name)
+		if newRequired[name] {
+			c.addSchema(s, property, s.breakingIf(true), KindPropertyAdded, "required %s added", s.subject(property))
+		} else {
+			c.addSchema(s, property, NonBreaking, KindPropertyAdded, "%s added", s.subject(property))
+		}
+	}
+}
+
+func joinField(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func sortedProperties(properties spec.SchemaProperties) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,44 @@
+// Package echoroutes lists the routes of an echo instance for the generator.
+// Its own module keeps echo out of the eswagger dependencies.
+package echoroutes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"main/eswagger"
+)
+
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// Routes returns a route source reading e.Routes()
+func Routes(e *echo.Echo) eswagger.RouteSource {
+	return eswagger.RouteSourceFunc(func() ([]eswagger.Route, error) {
+		var routes []eswagger.Route
+
+		for _, route := range e.Routes() {
+			// Skip echo's internal routes such as the "echo_route_any" entries
+			method := strings.ToUpper(route.Method)
+			if !knownMethods[method] {
+				continue
+			}
+
+			routes = append(routes, eswagger.Route{
+				Path:        eswagger.ConvertPathTemplate(route.Path),
+				Methods:     []string{method},
+				HandlerName: route.Name,
+			})
+		}
+
+		return routes, nil
+	})
+}
@@ -0,0 +1,41 @@
+package echoroutes
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func listUsers(echo.Context) error { return nil }
+
+func TestRoutes(t *testing.T) {
+	e := echo.New()
+	e.GET("/users", listUsers)
+	e.PUT("/users/:id", func(echo.Context) error { return nil })
+	e.GET("/static/*", func(echo.Context) error { return nil })
+	e.Any("/legacy", func(echo.Context) error { return nil })
+
+	routes, err := Routes(e).Routes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, route := range routes {
+		if route.Path == "/legacy" {
+			continue // Any registers one route per method
+		}
+		got = append(got, strings.Join(route.Methods, ",")+" "+route.Path)
+		if route.Path == "/users" && !strings.HasSuffix(route.HandlerName, ".listUsers") {
+			t.Errorf("handler name = %q, want the listUsers function", route.HandlerName)
+		}
+	}
+	sort.Strings(got)
+
+	want := []string{"GET /static/{wildcard}", "GET /users", "PUT /users/{id}"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %q\nwant %q", got, want)
+	}
+}
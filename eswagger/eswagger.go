@@ -2,33 +2,220 @@ package eswagger
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"reflect"
 	"regexp"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 
 	"github.com/fatih/structtag"
 	"github.com/go-openapi/spec"
 	"github.com/gorilla/mux"
+	"main/eswagger/overlay"
 	"main/pkg/model"
 )
 
-func (g *Generator) GenerateFromRouter(router *mux.Router, _ RouteMetadata) error {
-	pathItems := make(map[string]spec.PathItem)
+// GenerateFromRouter documents the routes of a gorilla/mux router. The
+// returned diagnostics list the problems found, an error is only returned
+// when no spec could be generated.
+func (g *Generator) GenerateFromRouter(router *mux.Router, metadata RouteMetadata) (Diagnostics, error) {
+	return g.GenerateFromRoutes(MuxRoutes(router), metadata)
+}
+
+// GenerateFromRoutes documents every route listed by the source. The spec is
+// built from scratch and replaces the previous one, the source and metadata
+// are kept for Regenerate.
+func (g *Generator) GenerateFromRoutes(source RouteSource, metadata RouteMetadata) (Diagnostics, error) {
+	diagnostics, err := g.rebuild(source, metadata)
+	if err != nil {
+		return diagnostics, err
+	}
+
+	g.mu.Lock()
+	g.source, g.metadata = source, metadata
+	g.mu.Unlock()
+	return diagnostics, nil
+}
+
+// Regenerate walks the routes given to the last GenerateFromRouter call again
+// and atomically swaps in the new spec, so routes added after startup get
+// documented. Requests served meanwhile keep seeing the previous spec.
+func (g *Generator) Regenerate() (Diagnostics, error) {
+	g.mu.RLock()
+	source, metadata := g.source, g.metadata
+	g.mu.RUnlock()
+
+	return g.rebuild(source, metadata)
+}
+
+// manualEndpoint is an endpoint registered through RegisterEndpoint, it is
+// replayed on every build
+type manualEndpoint struct {
+	path, method      string
+	request, response interface{}
+}
+
+// rebuild generates a spec on a fresh generator and publishes it
+func (g *Generator) rebuild(source RouteSource, metadata RouteMetadata) (Diagnostics, error) {
+	g.buildMu.Lock()
+	defer g.buildMu.Unlock()
+	return g.rebuildLocked(source, metadata)
+}
+
+// refresh rebuilds the spec when endpoints were registered since the last
+// build, see RegisterEndpoint
+func (g *Generator) refresh() {
+	g.mu.RLock()
+	stale := g.stale
+	g.mu.RUnlock()
+	if !stale {
+		return
+	}
+
+	g.buildMu.Lock()
+	defer g.buildMu.Unlock()
+
+	// Another reader may have rebuilt it meanwhile
+	g.mu.RLock()
+	stale, source, metadata := g.stale, g.source, g.metadata
+	g.mu.RUnlock()
+	if !stale {
+		return
+	}
+	if _, err := g.rebuildLocked(source, metadata); err != nil {
+		g.logger().Warn("couldn't regenerate spec", slog.Any("error", err))
+	}
+}
+
+// rebuildLocked is rebuild for callers holding buildMu
+func (g *Generator) rebuildLocked(source RouteSource, metadata RouteMetadata) (Diagnostics, error) {
+	g.mu.Lock()
+	g.stale = false
+	build := &Generator{
+		swagger:          newSpec(g.config),
+		config:           g.config,
+		typeMappings:     make(map[string]map[string]TypeMapping),
+		interfaces:       append([]interface{}(nil), g.interfaces...),
+		exampleGenerator: g.exampleGenerator,
+		docs:             g.docs,
+	}
+	manual := append([]manualEndpoint(nil), g.manual...)
+	added := append([]*overlay.Overlay(nil), g.overlays...)
+	g.mu.Unlock()
+
+	overlays, err := g.configOverlays()
+	if err != nil {
+		return nil, err
+	}
+	overlays = append(overlays[:len(overlays):len(overlays)], added...)
+
+	for _, endpoint := range manual {
+		build.registerEndpoint(endpoint.path, endpoint.method, endpoint.request, endpoint.response)
+	}
+
+	if source != nil {
+		if err := build.generate(source, metadata); err != nil {
+			return build.diagnostics, err
+		}
+	}
 
-	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
-		pathTemplate, err := route.GetPathTemplate()
+	if g.base != nil {
+		merged, err := mergeBase(g.base, build.swagger, g.baseOptions)
 		if err != nil {
-			return nil
+			return build.diagnostics, err
+		}
+		build.swagger = merged
+	}
+	if err := build.applyOverlays(overlays); err != nil {
+		return build.diagnostics, err
+	}
+
+	if g.config.Strict {
+		if err := build.diagnostics.strictError(); err != nil {
+			return build.diagnostics, err
 		}
+	}
+
+	matchers := newPathMatchers(build.swagger)
+
+	g.mu.Lock()
+	g.swagger = build.swagger
+	g.matchers = matchers
+	g.typeMappings = build.typeMappings
+	g.revision++
+	g.mu.Unlock()
+	return build.diagnostics, nil
+}
+
+// generate documents the routes of source into g, which must not be shared yet
+func (g *Generator) generate(source RouteSource, metadata RouteMetadata) error {
+	routes, err := source.Routes()
+	if err != nil {
+		return fmt.Errorf("error walking routes: %v", err)
+	}
+	routes = g.relativeRoutes(routes)
+
+	if !g.config.Visibility.known() {
+		g.report(DiagUnknownVisibility, "", "", "unknown visibility %q, only public routes are documented", g.config.Visibility)
+	}
 
-		methods, err := route.GetMethods()
+	pathItems := make(map[string]spec.PathItem)
+	explicitIDs := make(map[string]bool) // "METHOD path" of operationIds set in the metadata
+
+	interfaceMethods := make(map[string]*MethodStructs)
+	methodOwners := make(map[string]reflect.Type) // method name -> interface declaring it
+	for _, iface := range g.interfaces {
+		methodStructs, err := GetInterfaceMethodsFromType(iface)
 		if err != nil {
-			log.Printf("Warning: couldn't get methods for route %s: %v", pathTemplate, err)
-			return nil
+			g.report(DiagInvalidInterface, "", "", "couldn't get interface methods: %v", err)
+			continue
+		}
+		for name, structs := range methodStructs {
+			interfaceMethods[name] = structs
+			methodOwners[name] = reflect.TypeOf(iface).Elem()
+		}
+	}
+
+	for _, route := range routes {
+		pathTemplate, templateVars := parseTemplate(route.Path)
+		if len(route.Methods) == 0 {
+			// Excluded catch-all routes, e.g. of static files, aren't defects
+			candidate := g.routeCandidate(route, pathTemplate, "", []string{g.extractResourceName(pathTemplate)})
+			if g.includeRoute(candidate, "") {
+				g.report(DiagNoMethods, "", pathTemplate, "route has no methods, it is not documented")
+			}
+			continue
+		}
+
+		// Drop the methods filtered out before any type gets registered, so
+		// excluded routes don't leak definitions into the spec
+		var methods []string
+		for _, method := range route.Methods {
+			endpoint, _ := metadata.lookup(pathTemplate, method)
+			if !endpoint.Visibility.known() {
+				g.report(DiagUnknownVisibility, method, pathTemplate, "unknown visibility %q, the route is only documented in unfiltered specs", endpoint.Visibility)
+			}
+			tags := endpoint.Tags
+			if len(tags) == 0 {
+				tags = []string{g.extractResourceName(pathTemplate)}
+			}
+
+			candidate := g.routeCandidate(route, pathTemplate, method, tags)
+			if g.includeRoute(candidate, endpoint.Visibility) {
+				methods = append(methods, method)
+			}
+		}
+		if len(methods) == 0 {
+			continue
+		}
+		for _, unread := range route.Unread {
+			g.report(DiagUnreadMatchers, "", pathTemplate, "couldn't read the %s", unread)
 		}
 
 		// Get existing PathItem or create new one
@@ -37,49 +224,234 @@ func (g *Generator) GenerateFromRouter(router *mux.Router, _ RouteMetadata) erro
 			pathItem = spec.PathItem{}
 		}
 
-		methodStructs, err := GetInterfaceMethodsFromType((*model.UserInterface)(nil))
-		if err != nil {
-			log.Printf("Warning: couldn't get interface methods: %v", err)
-			return nil
+		methodStructs := interfaceMethods
+
+		fullName := route.handlerFullName()
+		handlerName := strings.Replace(g.cleanHandlerName(fullName), " ", "", -1)
+
+		// Handlers registered through Handle carry their own types
+		var boundParams []spec.Parameter
+		if bound, ok := route.Handler.(boundHandler); ok {
+			boundParams = g.bindingParameters(bound.binding().RequestType, templateVars)
+			if g.config.Logger != nil {
+				bound.setLogger(g.config.Logger)
+			}
+			for _, method := range methods {
+				g.registerBinding(pathTemplate, method, bound.binding())
+			}
+			methodStructs = nil
+			fullName = bound.binding().Operation
 		}
 
-		handler := route.GetHandler()
-		handlerName := g.getHandlerFunctionName(handler)
+		// The operationId follows the interface method the handler is bound to,
+		// or the handler itself, so do the doc comments
+		operationName := handlerOperationName(fullName)
+		comment, commentName := "", ""
+		if g.docs != nil {
+			comment, commentName = g.funcComment(fullName)
+		}
+		var annotations []annotation
+		if g.config.Annotations {
+			annotations = handlerAnnotations(route.handlerPC())
+		}
 
 		// Match handler with method structs and register endpoints
-		for methodName, structs := range methodStructs {
-			if strings.Contains(handlerName, methodName) {
-				for _, method := range methods {
-					log.Printf("Registering endpoint [%v] for method [%v], input [%v], output [%v]",
-						pathTemplate, method, structs.Input, structs.Output)
-					g.RegisterEndpoint(pathTemplate, method, structs.Input, structs.Output)
+		if methodName := g.matchInterfaceMethod(handlerName, methodStructs, methods, pathTemplate); methodName != "" {
+			operationName = methodName
+			if owner := methodOwners[methodName]; g.docs != nil && owner != nil {
+				if methodComment := g.sourceComment(owner.PkgPath(), owner.Name()+"."+methodName); methodComment != "" {
+					comment, commentName = methodComment, methodName
 				}
 			}
+			structs := methodStructs[methodName]
+			for _, method := range methods {
+				g.logger().Debug("registering endpoint",
+					slog.String("path", pathTemplate),
+					slog.String("method", method),
+					slog.String("interfaceMethod", methodName))
+				g.registerEndpoint(pathTemplate, method, structs.Input, structs.Output)
+			}
 		}
 
 		// Generate operations for each HTTP method
 		for _, method := range methods {
-			operation := g.generateOperationFromHandler(handler, method, pathTemplate)
+			// Annotated handlers document their own types
+			annotated := len(annotations) > 0 && g.routesAnnotated(annotations, method, pathTemplate)
+			if _, ok := g.typeMappings[pathTemplate][method]; !ok && !annotated {
+				g.report(DiagUnboundRoute, method, pathTemplate, "no request or response type found for handler %s", fullName)
+			}
+
+			operation := g.generateOperationFromHandler(fullName, method, pathTemplate)
+			operation.ID = operationName
+			if summary, description := commentSummary(comment, commentName); summary != "" {
+				operation.Summary, operation.Description = summary, description
+			}
+			operation.Parameters = append(operation.Parameters, mergeParameters(g.routeParameters(route, templateVars), boundParams)...)
+			if len(route.Schemes) > 0 {
+				operation.Schemes = route.Schemes
+			}
+			if servers := g.hostServers(route); servers != nil {
+				operation.AddExtension("x-servers", servers)
+			}
+			if annotated {
+				if g.applyAnnotations(operation, annotations, method, pathTemplate) {
+					explicitIDs[method+" "+pathTemplate] = true
+				}
+			}
+			if endpoint, ok := metadata.lookup(pathTemplate, method); ok {
+				g.applyEndpointMetadata(operation, method, endpoint)
+				if endpoint.OperationID != "" {
+					explicitIDs[method+" "+pathTemplate] = true
+				}
+			}
 			g.addOperationToPathItem(&pathItem, method, operation)
 		}
 
 		// Store updated PathItem
 		pathItems[pathTemplate] = pathItem
 		g.swagger.Paths.Paths[pathTemplate] = pathItem
+	}
 
-		return nil
+	g.syncTags()
+	g.assignOperationIDs(explicitIDs)
+	g.checkOperationIDs()
+
+	return nil
+}
+
+// checkOperationIDs reports operationIds shared by several operations
+func (g *Generator) checkOperationIDs() {
+	paths := make([]string, 0, len(g.swagger.Paths.Paths))
+	for path := range g.swagger.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	seen := make(map[string]string)
+	for _, path := range paths {
+		operations := operationsOf(g.swagger.Paths.Paths[path])
+		for _, method := range sortedMethods(operations) {
+			id := operations[method].ID
+			if id == "" {
+				continue
+			}
+			if first, ok := seen[id]; ok {
+				g.report(DiagDuplicateOperationID, method, path, "operationId %s is already used by %s", id, first)
+				continue
+			}
+			seen[id] = method + " " + path
+		}
+	}
+}
+
+// matchInterfaceMethod returns the interface method a handler is named after.
+// An exact match wins, otherwise the longest method name contained in the
+// handler name is used and the ambiguity reported.
+func (g *Generator) matchInterfaceMethod(handlerName string, methodStructs map[string]*MethodStructs, methods []string, path string) string {
+	if _, ok := methodStructs[handlerName]; ok {
+		return handlerName
+	}
+
+	var candidates []string
+	for methodName := range methodStructs {
+		if strings.Contains(handlerName, methodName) {
+			candidates = append(candidates, methodName)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if len(candidates[i]) != len(candidates[j]) {
+			return len(candidates[i]) > len(candidates[j])
+		}
+		return candidates[i] < candidates[j]
 	})
+	if len(candidates) > 1 {
+		g.report(DiagAmbiguousMatch, strings.Join(methods, ","), path,
+			"handler %s matches interface methods %s, using %s", handlerName, strings.Join(candidates, ", "), candidates[0])
+	}
+	return candidates[0]
+}
 
-	if err != nil {
-		return fmt.Errorf("error walking routes: %v", err)
+// applyEndpointMetadata overrides the generated prose and tags with the ones
+// given in RouteMetadata
+func (g *Generator) applyEndpointMetadata(operation *spec.Operation, method string, endpoint EndpointMetadata) {
+	if endpoint.Summary != "" {
+		operation.Summary = endpoint.Summary
+	}
+	if endpoint.Description != "" {
+		operation.Description = endpoint.Description
+	}
+	if endpoint.OperationID != "" {
+		operation.ID = endpoint.OperationID
+	}
+	if len(endpoint.Tags) > 0 {
+		operation.Tags = endpoint.Tags
+	}
+	if endpoint.Visibility != "" {
+		operation.AddExtension("x-visibility", string(endpoint.Visibility))
 	}
 
-	return nil
+	if endpoint.Examples.Response != nil && operation.Responses != nil {
+		status := defaultStatusCode(method)
+		if response, ok := operation.Responses.StatusCodeResponses[status]; ok {
+			response.Examples = map[string]interface{}{"application/json": endpoint.Examples.Response}
+			operation.Responses.StatusCodeResponses[status] = response
+		}
+	}
+	if endpoint.Examples.Request != nil {
+		for i, param := range operation.Parameters {
+			if param.In == "body" {
+				operation.Parameters[i].AddExtension("x-example", endpoint.Examples.Request)
+			}
+		}
+	}
+}
+
+// relativeRoutes makes the route paths relative to the base path. Routes
+// registered on a PathPrefix subrouter carry the prefix in their template,
+// it must not be repeated after basePath in the spec.
+func (g *Generator) relativeRoutes(routes []Route) []Route {
+	basePath := strings.TrimSuffix(g.swagger.BasePath, "/")
+
+	if basePath == "" && g.config.DetectBasePath {
+		var paths []string
+		for _, route := range routes {
+			if len(route.Methods) > 0 {
+				paths = append(paths, route.Path)
+			}
+		}
+		if basePath = commonPathPrefix(paths); basePath != "" {
+			g.swagger.BasePath = basePath
+		}
+	}
+
+	if basePath == "" {
+		return routes
+	}
+
+	relative := make([]Route, 0, len(routes))
+	for _, route := range routes {
+		if !hasPathPrefix(route.Path, basePath) {
+			if len(route.Methods) > 0 {
+				g.report(DiagOutsideBasePath, strings.Join(route.Methods, ","), route.Path, "route is outside of base path %s", basePath)
+			}
+			relative = append(relative, route)
+			continue
+		}
+
+		route.Path = strings.TrimPrefix(route.Path, basePath)
+		if route.Path == "" {
+			route.Path = "/"
+		}
+		relative = append(relative, route)
+	}
+	return relative
 }
 
-func (g *Generator) generateOperationFromHandler(handler interface{}, method string, path string) *spec.Operation {
-	handlerValue := reflect.ValueOf(handler)
-	handlerName := runtime.FuncForPC(handlerValue.Pointer()).Name()
+func (g *Generator) generateOperationFromHandler(handlerName string, method string, path string) *spec.Operation {
 
 	operation := &spec.Operation{
 		OperationProps: spec.OperationProps{
@@ -96,7 +468,6 @@ func (g *Generator) generateOperationFromHandler(handler interface{}, method str
 	//if method == "POST" || method == "PUT" || method == "PATCH" {
 	reqSchema := g.getRequestSchema(path, method)
 
-	log.Println(">>>>>>>>>>> Request schema:", reqSchema)
 	if reqSchema != "" {
 		operation.Parameters = append(operation.Parameters, spec.Parameter{
 			ParamProps: spec.ParamProps{
@@ -113,22 +484,6 @@ func (g *Generator) generateOperationFromHandler(handler interface{}, method str
 	}
 	//}
 
-	// Add path parameters - Fixed version using proper Swagger 2.0 format
-	if strings.Contains(path, "{id}") {
-		operation.Parameters = append(operation.Parameters, spec.Parameter{
-			SimpleSchema: spec.SimpleSchema{
-				Type:   "integer",
-				Format: "int64",
-			},
-			ParamProps: spec.ParamProps{
-				Description: "ID of the resource",
-				Name:        "id",
-				In:          "path",
-				Required:    true,
-			},
-		})
-	}
-
 	return operation
 }
 
@@ -179,6 +534,16 @@ func (g *Generator) generateRequest(t reflect.Type) *spec.Schema {
 		return nil
 	}
 
+	// time.Time is marshalled as an RFC 3339 string
+	if t == timeType {
+		return &spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:   []string{"string"},
+				Format: "date-time",
+			},
+		}
+	}
+
 	// Handle struct types
 	if t.Kind() != reflect.Struct {
 		// If it's not a struct, try to get field schema
@@ -233,6 +598,9 @@ func (g *Generator) generateRequest(t reflect.Type) *spec.Schema {
 		}
 
 		fieldSchema := g.generateRequest(fieldType)
+		if fieldSchema == nil {
+			g.report(DiagDroppedField, "", "", "field %s.%s of type %s couldn't be mapped, it is left out", t.Name(), field.Name, field.Type)
+		}
 		if fieldSchema != nil {
 			// If the field is a pointer, mark it as nullable
 			if isPointer {
@@ -243,14 +611,23 @@ func (g *Generator) generateRequest(t reflect.Type) *spec.Schema {
 			docTag := field.Tag.Get("doc")
 			if docTag != "" {
 				fieldSchema.Description = docTag
+			} else if comment := g.fieldComment(t, field.Name); comment != "" {
+				fieldSchema.Description = comment
 			}
 
-			// Set example from example tag
+			// Set example from example tag, converted to the type of the field
 			exampleTag := field.Tag.Get("example")
 			if exampleTag != "" {
-				fieldSchema.Example = exampleTag
+				fieldType := ""
+				if len(fieldSchema.Type) > 0 {
+					fieldType = fieldSchema.Type[0]
+				}
+				fieldSchema.Example = typedValue(fieldType, exampleTag)
 			}
 
+			// Constraints from the validate tag, e.g. `validate:"min=1,max=20"`
+			g.applyValidateTag(fieldSchema, field)
+
 			schema.Properties[fieldName] = *fieldSchema
 
 			// Only add to required if it's not a pointer field
@@ -270,21 +647,8 @@ func (g *Generator) generateResponses(method, path string) *spec.Responses {
 		},
 	}
 
-	var statusCode int
 	var schema *spec.Schema
-
-	switch method {
-	case "GET":
-		statusCode = http.StatusOK
-	case "POST":
-		statusCode = http.StatusCreated
-	case "PUT":
-		statusCode = http.StatusOK
-	case "DELETE":
-		statusCode = http.StatusNoContent
-	default:
-		statusCode = http.StatusOK
-	}
+	statusCode := defaultStatusCode(method)
 
 	response := spec.Response{
 		ResponseProps: spec.ResponseProps{
@@ -310,11 +674,96 @@ func (g *Generator) generateResponses(method, path string) *spec.Responses {
 	return responses
 }
 
+// defaultStatusCode is the success status documented, and returned by typed
+// handlers, for a given HTTP method
+func defaultStatusCode(method string) int {
+	switch strings.ToUpper(method) {
+	case "POST":
+		return http.StatusCreated
+	case "DELETE":
+		return http.StatusNoContent
+	default:
+		return http.StatusOK
+	}
+}
+
 func (g *Generator) isRequiredField(field reflect.StructField) bool {
 	jsonTag := field.Tag.Get("validate")
 	return strings.Contains(jsonTag, "required")
 }
 
+// applyValidateTag translates the rules of a `validate` struct tag into schema
+// constraints so that they are documented and enforced by the validation
+// middleware
+func (g *Generator) applyValidateTag(schema *spec.Schema, field reflect.StructField) {
+	validateTag := field.Tag.Get("validate")
+	if validateTag == "" {
+		return
+	}
+
+	isString := schema.Type.Contains("string")
+	isArray := schema.Type.Contains("array")
+
+	for _, rule := range strings.Split(validateTag, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		switch name {
+		case "min", "gte", "max", "lte", "len", "gt", "lt":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			size := int64(n)
+
+			switch {
+			case isString:
+				if name == "min" || name == "gte" || name == "len" {
+					schema.MinLength = &size
+				}
+				if name == "max" || name == "lte" || name == "len" {
+					schema.MaxLength = &size
+				}
+			case isArray:
+				if name == "min" || name == "gte" || name == "len" {
+					schema.MinItems = &size
+				}
+				if name == "max" || name == "lte" || name == "len" {
+					schema.MaxItems = &size
+				}
+			default:
+				switch name {
+				case "min", "gte":
+					schema.Minimum = &n
+				case "max", "lte":
+					schema.Maximum = &n
+				case "len":
+					schema.Minimum, schema.Maximum = &n, &n
+				case "gt":
+					schema.Minimum, schema.ExclusiveMinimum = &n, true
+				case "lt":
+					schema.Maximum, schema.ExclusiveMaximum = &n, true
+				}
+			}
+		case "oneof":
+			for _, option := range strings.Fields(value) {
+				if schema.Type.Contains("integer") {
+					if n, err := strconv.ParseInt(option, 10, 64); err == nil {
+						schema.Enum = append(schema.Enum, n)
+						continue
+					}
+				}
+				schema.Enum = append(schema.Enum, option)
+			}
+		case "email", "uuid", "uri", "date", "hostname", "ipv4", "ipv6":
+			schema.Format = name
+		case "url":
+			schema.Format = "uri"
+		case "datetime":
+			schema.Format = "date-time"
+		}
+	}
+}
+
 func (g *Generator) getFieldSchema(t reflect.Type) *spec.Schema {
 	// Handle slice types
 	if t.Kind() == reflect.Slice {
@@ -365,6 +814,8 @@ func (g *Generator) getFieldSchema(t reflect.Type) *spec.Schema {
 	}
 }
 
+var timeType = reflect.TypeOf(time.Time{})
+
 type TypeMapping struct {
 	RequestType  reflect.Type
 	ResponseType reflect.Type
@@ -375,7 +826,35 @@ type Generator struct {
 	config           Config
 	routes           map[string]map[string]interface{}
 	typeMappings     map[string]map[string]TypeMapping // path -> method -> types
+	interfaces       []interface{}                     // service interfaces handlers are matched against
 	exampleGenerator *ExampleGenerator
+	revision         uint64         // bumped whenever the spec changes, used to cache encodings
+	matchers         []*pathMatcher // path templates of swagger, see findOperation
+
+	// mu guards the fields above once the generator is shared, a published
+	// spec is never modified, builds happen on a separate Generator
+	mu       sync.RWMutex
+	buildMu  sync.Mutex // serializes builds
+	source   RouteSource
+	metadata RouteMetadata
+	manual   []manualEndpoint
+	stale    bool        // endpoints were registered since the last build
+	docs     *sourceDocs // doc comments read from the source, when Config.SourceDocs is set
+
+	// Hand-written spec the generated one is merged into, see NewGeneratorFromSpec
+	base        *spec.Swagger
+	baseOptions BaseOptions
+	overlays    []*overlay.Overlay // applied after Config.Overlays, see AddOverlays
+
+	// Config.Overlays, read by the first build
+	overlaysOnce   sync.Once
+	loadedOverlays []*overlay.Overlay
+	overlaysErr    error
+
+	// Problems found by the build in progress
+	diagnostics     Diagnostics
+	reported        map[string]bool
+	definitionTypes map[string]reflect.Type // definition name -> type, to detect collisions
 }
 
 // DocTag represents the structure for documentation tags
@@ -491,43 +970,70 @@ func (g *ExampleGenerator) getExampleFromTag(field reflect.StructField) interfac
 		return nil
 	}
 
-	example, err := tags.Get("example")
-	if err != nil {
+	if _, err := tags.Get("example"); err != nil {
 		return nil
 	}
-	log.Println(example)
 	return nil
 	// return convertExample(example.Value, field.Type)
 }
 
 func NewGenerator(config Config) *Generator {
-	return &Generator{
-		swagger: &spec.Swagger{
-			SwaggerProps: spec.SwaggerProps{
-				Swagger: "2.0",
-				Info: &spec.Info{
-					InfoProps: spec.InfoProps{
-						Title:       config.Title,
-						Description: config.Description,
-						Version:     config.Version,
-					},
-				},
-				BasePath: config.BasePath,
-				Paths: &spec.Paths{
-					Paths: make(map[string]spec.PathItem),
-				},
-				Definitions: make(map[string]spec.Schema),
-			},
-		},
+	g := &Generator{
+		swagger:          newSpec(config),
 		config:           config,
 		routes:           make(map[string]map[string]interface{}),
 		typeMappings:     make(map[string]map[string]TypeMapping),
 		exampleGenerator: NewExampleGenerator(),
 	}
+	if config.SourceDocs {
+		g.docs = newSourceDocs()
+	}
+	return g
+}
+
+// newSpec returns an empty spec holding the document level settings of config
+func newSpec(config Config) *spec.Swagger {
+	swagger := &spec.Swagger{
+		SwaggerProps: spec.SwaggerProps{
+			Swagger:      "2.0",
+			Info:         config.buildInfo(),
+			ExternalDocs: config.toExternalDocs(config.ExternalDocs),
+			Tags:         config.buildTags(),
+			Paths: &spec.Paths{
+				Paths: make(map[string]spec.PathItem),
+			},
+			Definitions: make(map[string]spec.Schema),
+		},
+	}
+	config.applyServer(swagger)
+	return swagger
 }
 
-// RegisterEndpoint registers the request and response types for an endpoint
+// RegisterInterface adds a service interface, given as a pointer to the
+// interface, whose methods are matched against handler names to find the
+// request and response types of a route
+func (g *Generator) RegisterInterface(i interface{}) error {
+	if _, err := GetInterfaceMethodsFromType(i); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	g.interfaces = append(g.interfaces, i)
+	g.mu.Unlock()
+	return nil
+}
+
+// RegisterEndpoint registers the request and response types for an endpoint.
+// Registrations are kept and applied again on every Regenerate. The spec is
+// rebuilt when it is next read, so registering many endpoints at startup
+// costs a single build.
 func (g *Generator) RegisterEndpoint(path, method string, requestType, responseType interface{}) {
+	g.mu.Lock()
+	g.manual = append(g.manual, manualEndpoint{path: path, method: method, request: requestType, response: responseType})
+	g.stale = true
+	g.mu.Unlock()
+}
+
+func (g *Generator) registerEndpoint(path, method string, requestType, responseType interface{}) {
 	if g.typeMappings[path] == nil {
 		g.typeMappings[path] = make(map[string]TypeMapping)
 	}
@@ -542,7 +1048,6 @@ func (g *Generator) RegisterEndpoint(path, method string, requestType, responseT
 			reqType = reqType.Elem()
 		}
 
-		log.Println(">>> Request type:", reqType)
 		mapping.RequestType = reqType
 		g.registerType(requestType)
 	}
@@ -556,8 +1061,6 @@ func (g *Generator) RegisterEndpoint(path, method string, requestType, responseT
 			respType = respType.Elem()
 		}
 
-		log.Println(">>> Response type:", responseType)
-
 		mapping.ResponseType = respType
 		g.registerType(responseType)
 	}
@@ -565,46 +1068,68 @@ func (g *Generator) RegisterEndpoint(path, method string, requestType, responseT
 	g.typeMappings[path][strings.ToUpper(method)] = mapping
 }
 
+// registerBinding registers the types of a handler created by Handle
+func (g *Generator) registerBinding(path, method string, binding endpointBinding) {
+	var request, response interface{}
+	if binding.RequestType != nil && binding.RequestType.Kind() != reflect.Interface && hasBody(binding.RequestType) {
+		request = reflect.New(binding.RequestType).Elem().Interface()
+	}
+	if binding.ResponseType != nil && !isEmptyStruct(binding.ResponseType) {
+		response = reflect.New(binding.ResponseType).Elem().Interface()
+	}
+	g.registerEndpoint(path, method, request, response)
+}
+
+func isEmptyStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.NumField() == 0
+}
+
 func (g *Generator) registerType(t interface{}) {
 	typ := reflect.TypeOf(t)
 	if typ.Kind() == reflect.Ptr {
 		typ = typ.Elem()
 	}
 	schema := g.generateRequest(typ)
+
+	if previous, ok := g.definitionTypes[typ.Name()]; ok && previous != typ {
+		g.report(DiagDefinitionCollision, "", "", "definition %s is used by both %s and %s", typ.Name(), previous, typ)
+	}
+	if g.definitionTypes == nil {
+		g.definitionTypes = make(map[string]reflect.Type)
+	}
+	g.definitionTypes[typ.Name()] = typ
+
+	if schema.Description == "" && typ.Name() != "" {
+		schema.Description = g.typeComment(typ)
+	}
 	g.swagger.Definitions[typ.Name()] = *schema
 }
 
 func (g *Generator) getRequestSchema(path, method string) string {
-	log.Printf("[INFO] getRequestSchema called with path: %s, method: %s", path, method)
-	if mapping, ok := g.typeMappings[path][method]; ok {
-		log.Printf("[INFO] Mapping found for path: %s, method: %s", path, method)
-		if mapping.RequestType != nil {
-			log.Printf("[INFO] Request type found for path: %s, method: %s - %s", path, method, mapping.RequestType.Name())
-			return "#/definitions/" + mapping.RequestType.Name()
-		} else {
-			log.Printf("[ERROR] No request type found for path: %s, method: %s", path, method)
-		}
-	} else {
-		log.Printf("[ERROR] No mapping found for path: %s, method: %s", path, method)
+	if mapping, ok := g.typeMappings[path][method]; ok && mapping.RequestType != nil {
+		return g.definitionRef(mapping.RequestType, method, path)
 	}
+	g.logger().Debug("no request type", slog.String("path", path), slog.String("method", method))
 	return ""
 }
 
 func (g *Generator) getResponseSchema(path, method string) string {
-	log.Printf("[INFO] getResponseSchema called with path: %s, method: %s", path, method)
-	if mapping, ok := g.typeMappings[path][method]; ok {
-		log.Printf("[INFO] Mapping found for path: %s, method: %s", path, method)
-		if mapping.ResponseType != nil { // Corrected to check ResponseType
-			log.Printf("[INFO] Response type found for path: %s, method: %s - %s", path, method, mapping.ResponseType.Name())
-			return "#/definitions/" + mapping.ResponseType.Name()
-		} else {
-			log.Printf("[ERROR] No response type found for path: %s, method: %s", path, method)
-		}
-	} else {
-		log.Printf("[ERROR] No mapping found for path: %s, method: %s", path, method)
+	if mapping, ok := g.typeMappings[path][method]; ok && mapping.ResponseType != nil {
+		return g.definitionRef(mapping.ResponseType, method, path)
 	}
+	g.logger().Debug("no response type", slog.String("path", path), slog.String("method", method))
 	return ""
 }
+
+// definitionRef is the reference to the definition of t. Unnamed types such
+// as slices end up as an empty "#/definitions/" reference and are reported.
+func (g *Generator) definitionRef(t reflect.Type, method, path string) string {
+	if t.Name() == "" {
+		g.report(DiagEmptyRef, method, path, "type %s has no name, its reference is empty", t)
+	}
+	return "#/definitions/" + t.Name()
+}
+
 func (g *Generator) getResponseType(path, method string) reflect.Type {
 	if mapping, ok := g.typeMappings[path][method]; ok {
 		return mapping.ResponseType
@@ -799,11 +1324,8 @@ func ExtractFuncName(input string) string {
 
 	if len(matches) > 1 {
 		// Extract the function name
-		functionName := matches[1]
-		log.Println("Extracted function name:", functionName)
-		return functionName
+		return matches[1]
 	}
-	log.Println("No match found.")
 	return input
 
 }
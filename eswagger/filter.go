@@ -0,0 +1,176 @@
+package eswagger
+
+import (
+	"path"
+	"strings"
+)
+
+// Visibility is the audience of a route, or of a generated spec
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"
+	VisibilityPartner  Visibility = "partner"
+	VisibilityInternal Visibility = "internal"
+)
+
+// level orders the visibilities. Unknown ones rank above internal, so a
+// misspelled route visibility hides the route instead of publishing it.
+func (v Visibility) level() int {
+	switch v {
+	case "", VisibilityPublic:
+		return 0
+	case VisibilityPartner:
+		return 1
+	case VisibilityInternal:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// known reports whether v is empty or one of the declared visibilities
+func (v Visibility) known() bool {
+	return v.level() < 3
+}
+
+// Allows reports whether a route of visibility route belongs in a spec
+// generated for the audience v. An empty audience allows every route, an
+// unknown one only the public routes.
+func (v Visibility) Allows(route Visibility) bool {
+	if v == "" {
+		return true
+	}
+	if !v.known() {
+		return route.level() == 0
+	}
+	return route.level() <= v.level()
+}
+
+// RouteFilter selects routes. Every criterion set must match, a criterion
+// matches when any of its values does.
+type RouteFilter struct {
+	Paths   []string `json:"paths" yaml:"paths"`     // globs, "*" matches one segment and "**" any number
+	Methods []string `json:"methods" yaml:"methods"` // e.g. GET
+	Tags    []string `json:"tags" yaml:"tags"`
+	Names   []string `json:"names" yaml:"names"` // mux route names
+}
+
+// routeCandidate is what filters are evaluated against
+type routeCandidate struct {
+	Path     string // documented path
+	FullPath string // path including the base path
+	Method   string
+	Tags     []string
+	Name     string
+}
+
+func (g *Generator) routeCandidate(route Route, pathTemplate, method string, tags []string) routeCandidate {
+	return routeCandidate{
+		Path:     pathTemplate,
+		FullPath: strings.TrimSuffix(g.swagger.BasePath, "/") + pathTemplate,
+		Method:   method,
+		Tags:     tags,
+		Name:     route.Name,
+	}
+}
+
+func (f RouteFilter) matches(c routeCandidate) bool {
+	if len(f.Paths) > 0 && !anyMatch(f.Paths, func(glob string) bool {
+		return matchGlob(glob, c.Path) || matchGlob(glob, c.FullPath)
+	}) {
+		return false
+	}
+
+	if len(f.Methods) > 0 && !anyMatch(f.Methods, func(method string) bool {
+		return strings.EqualFold(method, c.Method)
+	}) {
+		return false
+	}
+
+	if len(f.Tags) > 0 && !anyMatch(f.Tags, func(tag string) bool {
+		for _, t := range c.Tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}) {
+		return false
+	}
+
+	if len(f.Names) > 0 && !anyMatch(f.Names, func(name string) bool {
+		return c.Name != "" && (name == c.Name || matchGlob(name, c.Name))
+	}) {
+		return false
+	}
+
+	return true
+}
+
+func anyMatch(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash separated path against a glob where "*" stands
+// for one segment (or part of it) and "**" for any number of segments
+func matchGlob(glob, p string) bool {
+	return matchSegments(strings.Split(strings.Trim(glob, "/"), "/"), strings.Split(strings.Trim(p, "/"), "/"))
+}
+
+func matchSegments(glob, segments []string) bool {
+	if len(glob) == 0 {
+		return len(segments) == 0
+	}
+
+	if glob[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(glob[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, err := path.Match(glob[0], segments[0]); err != nil || !ok {
+		return false
+	}
+	return matchSegments(glob[1:], segments[1:])
+}
+
+// includeRoute applies the Include and Exclude filters and the visibility of
+// the spec to a route
+func (g *Generator) includeRoute(c routeCandidate, visibility Visibility) bool {
+	if !g.config.Visibility.Allows(visibility) {
+		return false
+	}
+
+	if len(g.config.Include) > 0 {
+		included := false
+		for _, filter := range g.config.Include {
+			if filter.matches(c) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+
+	for _, filter := range g.config.Exclude {
+		if filter.matches(c) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,148 @@
+package eswagger
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		glob, path string
+		want       bool
+	}{
+		{"/users", "/users", true},
+		{"/users/*", "/users/{id}", true},
+		{"/users/*", "/users/{id}/files", false},
+		{"/users/**", "/users/{id}/files", true},
+		{"/users/**", "/users", true},
+		{"/**/files", "/users/{id}/files", true},
+		{"/admin*", "/admin-tools", true},
+		{"/admin", "/administration", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.path, func(t *testing.T) {
+			if got := matchGlob(tt.glob, tt.path); got != tt.want {
+				t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.glob, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisibilityAllows(t *testing.T) {
+	tests := []struct {
+		audience, route Visibility
+		want            bool
+	}{
+		{"", VisibilityInternal, true},
+		{VisibilityPublic, "", true},
+		{VisibilityPublic, VisibilityPartner, false},
+		{VisibilityPartner, VisibilityPublic, true},
+		{VisibilityPartner, VisibilityInternal, false},
+		{VisibilityInternal, VisibilityInternal, true},
+		{"", "Internal", true},
+		{VisibilityPublic, "Internal", false},
+		{VisibilityInternal, "Internal", false},
+		{"Internal", VisibilityPublic, true},
+		{"Internal", VisibilityPartner, false},
+	}
+	for _, tt := range tests {
+		if got := tt.audience.Allows(tt.route); got != tt.want {
+			t.Errorf("%q.Allows(%q) = %v, want %v", tt.audience, tt.route, got, tt.want)
+		}
+	}
+}
+
+func TestRouteFilters(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET", "POST")
+	router.HandleFunc("/users/{id}", noop).Methods("DELETE").Name("deleteUser")
+	router.HandleFunc("/admin/stats", noop).Methods("GET")
+	router.HandleFunc("/partners/orders", noop).Methods("GET")
+
+	var metadata RouteMetadata
+	metadata.Set("GET", "/admin/stats", EndpointMetadata{Visibility: VisibilityInternal})
+	metadata.Set("GET", "/partners/orders", EndpointMetadata{Visibility: VisibilityPartner, Tags: []string{"orders"}})
+
+	tests := []struct {
+		name   string
+		config Config
+		want   []string
+	}{
+		{"everything", Config{}, []string{"DELETE /users/{id}", "GET /admin/stats", "GET /partners/orders", "GET /users", "POST /users"}},
+		{"public", Config{Visibility: VisibilityPublic}, []string{"DELETE /users/{id}", "GET /users", "POST /users"}},
+		{"partner", Config{Visibility: VisibilityPartner}, []string{"DELETE /users/{id}", "GET /partners/orders", "GET /users", "POST /users"}},
+		{"include paths", Config{Include: []RouteFilter{{Paths: []string{"/users/**"}}}}, []string{"DELETE /users/{id}", "GET /users", "POST /users"}},
+		{"include method and path", Config{Include: []RouteFilter{{Paths: []string{"/users"}, Methods: []string{"get"}}}}, []string{"GET /users"}},
+		{"exclude by name", Config{Exclude: []RouteFilter{{Names: []string{"delete*"}}}}, []string{"GET /admin/stats", "GET /partners/orders", "GET /users", "POST /users"}},
+		{"exclude by tag", Config{Exclude: []RouteFilter{{Tags: []string{"orders"}}}}, []string{"DELETE /users/{id}", "GET /admin/stats", "GET /users", "POST /users"}},
+		{"include then exclude", Config{Include: []RouteFilter{{Paths: []string{"/users/**"}}}, Exclude: []RouteFilter{{Methods: []string{"POST"}}}}, []string{"DELETE /users/{id}", "GET /users"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Title, config.Version = "API", "1.0.0"
+			g := NewGenerator(config)
+			if _, err := g.GenerateFromRouter(router, metadata); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for path, item := range g.GetSwaggerSpec().Paths.Paths {
+				for method := range operationsOf(item) {
+					got = append(got, method+" "+path)
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("operations = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnknownVisibility(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+	router.HandleFunc("/admin/stats", noop).Methods("GET")
+
+	var metadata RouteMetadata
+	metadata.Set("GET", "/admin/stats", EndpointMetadata{Visibility: "Internal"})
+
+	tests := []struct {
+		name        string
+		visibility  Visibility
+		want        []string
+		diagnostics int
+	}{
+		{"unknown route visibility", VisibilityInternal, []string{"/users"}, 1},
+		{"unknown audience", "Partners", []string{"/users"}, 2},
+		{"unfiltered", "", []string{"/admin/stats", "/users"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGenerator(Config{Title: "API", Version: "1.0.0", Visibility: tt.visibility})
+			diagnostics, err := g.GenerateFromRouter(router, metadata)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for path := range g.GetSwaggerSpec().Paths.Paths {
+				got = append(got, path)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %q, want %q", got, tt.want)
+			}
+			if unknown := diagnostics.Of(DiagUnknownVisibility); len(unknown) != tt.diagnostics {
+				t.Errorf("diagnostics = %v, want %d unknown_visibility", unknown, tt.diagnostics)
+			}
+		})
+	}
+}
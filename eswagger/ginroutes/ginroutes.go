@@ -0,0 +1,28 @@
+// Package ginroutes lists the routes of a gin engine for the generator. It
+// is a separate module, gin and its dependencies stay out of eswagger.
+package ginroutes
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"main/eswagger"
+)
+
+// Routes returns a route source reading engine.Routes()
+func Routes(engine *gin.Engine) eswagger.RouteSource {
+	return eswagger.RouteSourceFunc(func() ([]eswagger.Route, error) {
+		var routes []eswagger.Route
+
+		for _, info := range engine.Routes() {
+			routes = append(routes, eswagger.Route{
+				Path:        eswagger.ConvertPathTemplate(info.Path),
+				Methods:     []string{strings.ToUpper(info.Method)},
+				Handler:     info.HandlerFunc,
+				HandlerName: info.Handler,
+			})
+		}
+
+		return routes, nil
+	})
+}
@@ -0,0 +1,38 @@
+package ginroutes
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func listUsers(*gin.Context) {}
+
+func TestRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/users", listUsers)
+	engine.DELETE("/users/:id", func(*gin.Context) {})
+	engine.GET("/files/*path", func(*gin.Context) {})
+
+	routes, err := Routes(engine).Routes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, route := range routes {
+		got = append(got, strings.Join(route.Methods, ",")+" "+route.Path)
+		if route.Path == "/users" && !strings.HasSuffix(route.HandlerName, ".listUsers") {
+			t.Errorf("handler name = %q, want the listUsers function", route.HandlerName)
+		}
+	}
+	sort.Strings(got)
+
+	want := []string{"DELETE /users/{id}", "GET /files/{path}", "GET /users"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("routes = %q\nwant %q", got, want)
+	}
+}
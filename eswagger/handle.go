@@ -0,0 +1,433 @@
+package eswagger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gorilla/mux"
+)
+
+// HTTPError lets a typed handler choose the status code returned for a failure
+type HTTPError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Validator is implemented by request types that need checks beyond the
+// `validate:"required"` struct tag
+type Validator interface {
+	Validate() error
+}
+
+// endpointBinding ties a handler to the types documented for it
+type endpointBinding struct {
+	Operation    string
+	Func         uintptr // entry of the handler function, to find its source
+	RequestType  reflect.Type
+	ResponseType reflect.Type
+}
+
+// boundHandler is implemented by handlers which know their own request and
+// response types, GenerateFromRouter uses it instead of guessing from names
+// and hands them Config.Logger
+type boundHandler interface {
+	http.Handler
+	binding() endpointBinding
+	setLogger(logger *slog.Logger)
+}
+
+type typedHandler struct {
+	endpoint endpointBinding
+	serve    http.HandlerFunc
+	logger   atomic.Pointer[slog.Logger]
+}
+
+func (h *typedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.serve(w, r)
+}
+
+func (h *typedHandler) binding() endpointBinding {
+	return h.endpoint
+}
+
+func (h *typedHandler) setLogger(logger *slog.Logger) {
+	h.logger.Store(logger)
+}
+
+// fail writes the error response, the server errors are logged to
+// Config.Logger, or slog.Default until a spec is generated from the handler
+func (h *typedHandler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if status := writeError(w, err); status >= http.StatusInternalServerError {
+		logger := h.logger.Load()
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Error("request failed",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("operation", h.endpoint.Operation),
+			slog.Any("error", err))
+	}
+}
+
+// Handle registers fn on the router for a pattern such as "POST /users" or
+// "GET /users/{id}". The request is decoded from the JSON body and the path
+// and query parameters, validated and passed to fn; the result is encoded as
+// JSON. The request and response types are recorded for GenerateFromRouter.
+func Handle[Req, Resp any](router *mux.Router, pattern string, fn func(context.Context, Req) (Resp, error)) *mux.Route {
+	method, path := splitPattern(pattern)
+
+	route := router.Handle(path, NewHandler(fn))
+	if method != "" {
+		route.Methods(method)
+	}
+	return route
+}
+
+// NewHandler wraps fn like Handle does, for routers other than gorilla/mux
+func NewHandler[Req, Resp any](fn func(context.Context, Req) (Resp, error)) http.Handler {
+	handler := &typedHandler{
+		endpoint: endpointBinding{
+			Operation:    funcName(fn),
+			Func:         reflect.ValueOf(fn).Pointer(),
+			RequestType:  reflect.TypeOf((*Req)(nil)).Elem(),
+			ResponseType: reflect.TypeOf((*Resp)(nil)).Elem(),
+		},
+	}
+	handler.serve = func(w http.ResponseWriter, r *http.Request) {
+		var req Req
+		if err := bindRequest(r, reflect.ValueOf(&req).Elem()); err != nil {
+			handler.fail(w, r, err)
+			return
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			handler.fail(w, r, err)
+			return
+		}
+
+		writeResponse(w, r.Method, resp)
+	}
+	return handler
+}
+
+// splitPattern splits "POST /users" into its method and path, the method is
+// empty when the pattern only holds a path
+func splitPattern(pattern string) (string, string) {
+	pattern = strings.TrimSpace(pattern)
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		return strings.ToUpper(pattern[:i]), strings.TrimSpace(pattern[i:])
+	}
+	return "", pattern
+}
+
+func funcName(fn interface{}) string {
+	value := reflect.ValueOf(fn)
+	if value.Kind() != reflect.Func || value.IsNil() {
+		return ""
+	}
+	if f := runtime.FuncForPC(value.Pointer()); f != nil {
+		return f.Name()
+	}
+	return ""
+}
+
+// bindRequest fills target from the request body, the path variables and the
+// query string, then validates it
+func bindRequest(r *http.Request, target reflect.Value) error {
+	if err := decodeBody(r, target); err != nil {
+		return err
+	}
+
+	if err := bindParams(r, target); err != nil {
+		return err
+	}
+
+	return validateRequest(target)
+}
+
+func decodeBody(r *http.Request, target reflect.Value) error {
+	if r.Body == nil || r.ContentLength == 0 || !hasBody(target.Type()) {
+		return nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(target.Addr().Interface()); err != nil && !errors.Is(err, io.EOF) {
+		return &HTTPError{Status: http.StatusBadRequest, Message: "invalid request body", Err: err}
+	}
+	return nil
+}
+
+// hasBody reports whether a request type is read from the body. Scalars are
+// taken from path variables and structs only made of path/query fields have
+// nothing to decode.
+func hasBody(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.Tag.Get("path") == "" && field.Tag.Get("query") == "" && field.IsExported() {
+				return true
+			}
+		}
+		return false
+	case reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	default:
+		return false
+	}
+}
+
+func bindParams(r *http.Request, target reflect.Value) error {
+	vars := pathVars(r)
+
+	// A scalar request such as DeleteUser(id int) takes the single path variable
+	if isScalar(target.Kind()) {
+		if len(vars) != 1 {
+			return nil
+		}
+		for name, value := range vars {
+			if err := setScalar(target, value); err != nil {
+				return &HTTPError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid path parameter %s", name), Err: err}
+			}
+		}
+		return nil
+	}
+
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := target.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		var value, source string
+		var ok bool
+		if name := field.Tag.Get("path"); name != "" {
+			value, ok = vars[name]
+			if !ok {
+				value = r.PathValue(name)
+				ok = value != ""
+			}
+			source = "path"
+		} else if name := field.Tag.Get("query"); name != "" {
+			ok = r.URL.Query().Has(name)
+			value = r.URL.Query().Get(name)
+			source = "query"
+		}
+		if !ok {
+			continue
+		}
+
+		if err := setScalar(target.Field(i), value); err != nil {
+			return &HTTPError{Status: http.StatusBadRequest, Message: fmt.Sprintf("invalid %s parameter %s", source, field.Name), Err: err}
+		}
+	}
+
+	return nil
+}
+
+type pathParamsKey struct{}
+
+// pathVars returns the path variables matched for the request, either by
+// gorilla/mux or by a ServeMux pattern recorded through NewServeMux
+func pathVars(r *http.Request) map[string]string {
+	if vars := mux.Vars(r); len(vars) > 0 {
+		return vars
+	}
+
+	names, _ := r.Context().Value(pathParamsKey{}).([]string)
+	vars := make(map[string]string, len(names))
+	for _, name := range names {
+		vars[name] = r.PathValue(name)
+	}
+	return vars
+}
+
+func isScalar(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func setScalar(target reflect.Value, value string) error {
+	if target.Kind() == reflect.Ptr {
+		ptr := reflect.New(target.Type().Elem())
+		if err := setScalar(ptr.Elem(), value); err != nil {
+			return err
+		}
+		target.Set(ptr)
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.String:
+		target.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		target.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, target.Type().Bits())
+		if err != nil {
+			return err
+		}
+		target.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported parameter type %s", target.Type())
+	}
+	return nil
+}
+
+// validateRequest checks the `validate:"required"` fields and runs the
+// Validator implementation of the request, if any
+func validateRequest(target reflect.Value) error {
+	var missing []string
+	collectMissing(target, &missing)
+	if len(missing) > 0 {
+		return NewHTTPError(http.StatusBadRequest, "missing required fields: "+strings.Join(missing, ", "))
+	}
+
+	if target.CanAddr() {
+		if v, ok := target.Addr().Interface().(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return &HTTPError{Status: http.StatusBadRequest, Message: "invalid request", Err: err}
+			}
+			return nil
+		}
+	}
+	if v, ok := target.Interface().(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return &HTTPError{Status: http.StatusBadRequest, Message: "invalid request", Err: err}
+		}
+	}
+	return nil
+}
+
+func collectMissing(v reflect.Value, missing *[]string) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			collectMissing(v.Field(i), missing)
+			continue
+		}
+		if strings.Contains(field.Tag.Get("validate"), "required") && v.Field(i).IsZero() {
+			*missing = append(*missing, jsonFieldName(field))
+		}
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
+
+func writeResponse(w http.ResponseWriter, method string, resp interface{}) {
+	status := defaultStatusCode(method)
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// writeError sends the error as {"message": ...} and returns the status. Only
+// the client errors carry their details, the server errors send the message
+// of the HTTPError or the status text so internals don't leak.
+func writeError(w http.ResponseWriter, err error) int {
+	status := http.StatusInternalServerError
+	message := http.StatusText(status)
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		status = httpErr.Status
+		switch {
+		case status < http.StatusInternalServerError:
+			message = httpErr.Error()
+		case httpErr.Message != "":
+			message = httpErr.Message
+		default:
+			message = http.StatusText(status)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+	return status
+}
@@ -0,0 +1,169 @@
+package eswagger
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type handleUser struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type updateUserRequest struct {
+	ID      int    `path:"id"`
+	Notify  bool   `query:"notify"`
+	Version *int64 `query:"version" validate:"required" doc:"expected version"`
+	Name    string `json:"name" validate:"required"`
+}
+
+func TestHandleBinding(t *testing.T) {
+	router := mux.NewRouter()
+	Handle(router, "PUT /users/{id}", func(ctx context.Context, req updateUserRequest) (handleUser, error) {
+		if req.Notify && *req.Version > 0 {
+			return handleUser{ID: req.ID, Name: req.Name + "!"}, nil
+		}
+		return handleUser{ID: req.ID, Name: req.Name}, nil
+	})
+	Handle(router, "DELETE /users/{id}", func(ctx context.Context, id int) (struct{}, error) {
+		if id == 0 {
+			return struct{}{}, NewHTTPError(http.StatusNotFound, "no such user")
+		}
+		return struct{}{}, nil
+	})
+	Handle(router, "GET /fail", func(ctx context.Context, _ struct{}) (handleUser, error) {
+		return handleUser{}, errors.New("dial tcp 10.0.0.3:5432: connection refused")
+	})
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		status   int
+		response string
+	}{
+		{"path, query and body", "PUT", "/users/7?notify=true&version=2", `{"name":"ada"}`, 200, `{"id":7,"name":"ada!"}`},
+		{"query left out", "PUT", "/users/7?version=1", `{"name":"ada"}`, 200, `{"id":7,"name":"ada"}`},
+		{"missing required query", "PUT", "/users/7", `{"name":"ada"}`, 400, `{"message":"missing required fields: Version"}`},
+		{"missing required body field", "PUT", "/users/7?version=1", `{}`, 400, `{"message":"missing required fields: name"}`},
+		{"invalid path parameter", "PUT", "/users/x?version=1", `{"name":"ada"}`, 400, ""},
+		{"invalid body", "PUT", "/users/7?version=1", `{"name":`, 400, ""},
+		{"scalar request", "DELETE", "/users/3", "", 204, ""},
+		{"HTTPError status", "DELETE", "/users/0", "", 404, `{"message":"no such user"}`},
+		{"internal error hidden", "GET", "/fail", "", 500, `{"message":"Internal Server Error"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.response != "" && strings.TrimSpace(w.Body.String()) != tt.response {
+				t.Errorf("body = %s, want %s", w.Body, tt.response)
+			}
+		})
+	}
+}
+
+func TestHandleParameters(t *testing.T) {
+	router := mux.NewRouter()
+	Handle(router, "PUT /users/{id:[0-9]+}", func(ctx context.Context, req updateUserRequest) (handleUser, error) {
+		return handleUser{}, nil
+	})
+	Handle(router, "DELETE /users/{id}", func(ctx context.Context, id string) (struct{}, error) {
+		return struct{}{}, nil
+	})
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	item := g.GetSwaggerSpec().Paths.Paths["/users/{id}"]
+
+	type param struct {
+		in, typ, format string
+		required        bool
+		description     string
+	}
+	tests := []struct {
+		name      string
+		operation string
+		param     string
+		want      *param
+	}{
+		{"path field", "PUT", "id", &param{"path", "integer", "int64", true, "ID of the resource"}},
+		{"optional query field", "PUT", "notify", &param{"query", "boolean", "", false, ""}},
+		{"required query field", "PUT", "version", &param{"query", "integer", "int64", true, "expected version"}},
+		{"body field", "PUT", "name", nil},
+		{"scalar request", "DELETE", "id", &param{"path", "string", "", true, "ID of the resource"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation := operationsOf(item)[tt.operation]
+			if operation == nil {
+				t.Fatalf("no %s operation", tt.operation)
+			}
+
+			var got *param
+			count := 0
+			for _, p := range operation.Parameters {
+				if p.Name == tt.param && p.In != "body" {
+					got = &param{p.In, p.Type, p.Format, p.Required, p.Description}
+					count++
+				}
+			}
+			if count > 1 {
+				t.Fatalf("%s is documented %d times", tt.param, count)
+			}
+			if tt.want == nil || got == nil {
+				if (tt.want == nil) != (got == nil) {
+					t.Fatalf("parameter %s = %+v, want %+v", tt.param, got, tt.want)
+				}
+				return
+			}
+			if *got != *tt.want {
+				t.Errorf("parameter %s = %+v, want %+v", tt.param, *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"plain error", errors.New("pq: password authentication failed"), 500, "Internal Server Error"},
+		{"client error with cause", &HTTPError{Status: 400, Message: "invalid body", Err: errors.New("unexpected EOF")}, 400, "invalid body: unexpected EOF"},
+		{"server error keeps its message only", &HTTPError{Status: 503, Message: "try again later", Err: errors.New("pool exhausted")}, 503, "try again later"},
+		{"server error without message", &HTTPError{Status: 502, Err: errors.New("upstream reset")}, 502, "Bad Gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			if status := writeError(w, tt.err); status != tt.status {
+				t.Errorf("returned status %d, want %d", status, tt.status)
+			}
+
+			var body map[string]string
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.status || body["message"] != tt.message {
+				t.Errorf("got %d %q, want %d %q", w.Code, body["message"], tt.status, tt.message)
+			}
+		})
+	}
+}
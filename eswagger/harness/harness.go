@@ -0,0 +1,188 @@
+// Package harness runs a router constructor and writes the spec of its
+// routes. It is imported by the program `eswagger generate` builds next to
+// the target package and isn't meant to be used directly.
+package harness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"reflect"
+
+	"main/eswagger"
+
+	"github.com/gorilla/mux"
+)
+
+// Options is passed by the CLI to the harness as JSON
+type Options struct {
+	Config    string          `json:"config"` // config file loaded with eswagger.LoadConfig
+	Overrides eswagger.Config `json:"overrides"`
+	Format    string          `json:"format"` // json or yaml
+	Out       string          `json:"out"`    // file path, "-" for stdout
+	Strict    bool            `json:"strict"`
+
+	Base        string               `json:"base"` // hand-written Swagger 2.0 spec the generated one is merged into
+	BaseOptions eswagger.BaseOptions `json:"baseOptions"`
+}
+
+// Main is the entry point of the generated program, the options are read
+// from the first argument
+func Main(constructor, metadata interface{}) {
+	var opts Options
+	if len(os.Args) > 1 {
+		if err := json.Unmarshal([]byte(os.Args[1]), &opts); err != nil {
+			fmt.Fprintln(os.Stderr, "eswagger: invalid harness options:", err)
+			os.Exit(2)
+		}
+	}
+
+	diagnostics, err := Run(constructor, metadata, opts)
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, "eswagger:", diagnostic)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "eswagger:", err)
+		os.Exit(1)
+	}
+}
+
+var (
+	generatorType = reflect.TypeOf((*eswagger.Generator)(nil))
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Run calls the constructor and generates the spec of the router it returns.
+//
+// The constructor is a function returning a *mux.Router, an
+// *eswagger.ServeMux or any eswagger.RouteSource, optionally followed by an
+// error. A *eswagger.Generator parameter receives the generator so the
+// constructor can register interfaces, other parameters get their zero value.
+// metadata is nil or a function returning eswagger.RouteMetadata.
+func Run(constructor, metadata interface{}, opts Options) (eswagger.Diagnostics, error) {
+	config := opts.Overrides
+	if opts.Config != "" {
+		loaded, err := eswagger.LoadConfig(opts.Config)
+		if err != nil {
+			return nil, err
+		}
+		config = mergeConfig(loaded, opts.Overrides)
+	}
+	config.Strict = config.Strict || opts.Strict
+
+	generator := eswagger.NewGenerator(config)
+	if opts.Base != "" {
+		var err error
+		if generator, err = eswagger.NewGeneratorFromFile(config, opts.Base, opts.BaseOptions); err != nil {
+			return nil, err
+		}
+	}
+
+	source, err := callConstructor(constructor, generator)
+	if err != nil {
+		return nil, err
+	}
+
+	var routeMetadata eswagger.RouteMetadata
+	if metadata != nil {
+		fn, ok := metadata.(func() eswagger.RouteMetadata)
+		if !ok {
+			return nil, fmt.Errorf("metadata function must be a func() eswagger.RouteMetadata, got %T", metadata)
+		}
+		routeMetadata = fn()
+	}
+
+	diagnostics, err := generator.GenerateFromRoutes(source, routeMetadata)
+	if err != nil {
+		return diagnostics, err
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = "json"
+	}
+	data, err := eswagger.MarshalSpec(generator.GetSwaggerSpec(), format)
+	if err != nil {
+		return diagnostics, err
+	}
+
+	if opts.Out == "" || opts.Out == "-" {
+		_, err = os.Stdout.Write(data)
+		return diagnostics, err
+	}
+	if err := os.WriteFile(opts.Out, data, 0644); err != nil {
+		return diagnostics, fmt.Errorf("error writing swagger file: %v", err)
+	}
+	return diagnostics, nil
+}
+
+func callConstructor(constructor interface{}, generator *eswagger.Generator) (eswagger.RouteSource, error) {
+	fn := reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func {
+		return nil, fmt.Errorf("router constructor must be a function, got %T", constructor)
+	}
+
+	t := fn.Type()
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		if t.In(i) == generatorType {
+			args[i] = reflect.ValueOf(generator)
+		} else {
+			args[i] = reflect.Zero(t.In(i))
+		}
+	}
+
+	results := fn.Call(args)
+	if n := len(results); n > 0 && t.Out(n-1) == errorType {
+		if err, _ := results[n-1].Interface().(error); err != nil {
+			return nil, fmt.Errorf("router constructor failed: %w", err)
+		}
+		results = results[:n-1]
+	}
+	if len(results) != 1 {
+		return nil, errors.New("router constructor must return a router, optionally followed by an error")
+	}
+
+	return routeSource(results[0].Interface())
+}
+
+func routeSource(router interface{}) (eswagger.RouteSource, error) {
+	switch r := router.(type) {
+	case *mux.Router:
+		return eswagger.MuxRoutes(r), nil
+	case eswagger.RouteSource:
+		return r, nil
+	case http.Handler:
+		return nil, fmt.Errorf("unsupported router %T, return an eswagger.RouteSource such as chiroutes.Routes(router)", router)
+	default:
+		return nil, fmt.Errorf("unsupported router %T", router)
+	}
+}
+
+// mergeConfig applies the fields set on the command line over the loaded file
+func mergeConfig(config, overrides eswagger.Config) eswagger.Config {
+	if overrides.Title != "" {
+		config.Title = overrides.Title
+	}
+	if overrides.Description != "" {
+		config.Description = overrides.Description
+	}
+	if overrides.Version != "" {
+		config.Version = overrides.Version
+	}
+	if overrides.Host != "" {
+		config.Host = overrides.Host
+	}
+	if overrides.BasePath != "" {
+		config.BasePath = overrides.BasePath
+	}
+	if overrides.Environment != "" {
+		config.Environment = overrides.Environment
+	}
+	config.SourceDocs = config.SourceDocs || overrides.SourceDocs
+	config.Annotations = config.Annotations || overrides.Annotations
+	config.Overlays = append(config.Overlays, overrides.Overlays...)
+	return config
+}
@@ -0,0 +1,126 @@
+package harness
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"main/eswagger"
+
+	"github.com/gorilla/mux"
+)
+
+func listUsers(http.ResponseWriter, *http.Request) {}
+
+func newRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/users", listUsers).Methods("GET")
+	return router
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "eswagger.yaml")
+	config := "title: Users\nversion: 1.0.0\ndescription: From the file\n"
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		constructor interface{}
+		metadata    interface{}
+		opts        Options
+		wantErr     bool
+		title       string
+		description string
+	}{
+		{"router", newRouter, nil, Options{Overrides: eswagger.Config{Title: "Users", Version: "1.0.0"}}, false, "Users", ""},
+		{"router and error", func() (*mux.Router, error) { return newRouter(), nil }, nil, Options{Overrides: eswagger.Config{Title: "Users", Version: "1.0.0"}}, false, "Users", ""},
+		{"generator parameter", func(g *eswagger.Generator, prefix string) *mux.Router {
+			g.RegisterEndpoint("/users", "GET", nil, struct{ ID int }{})
+			return newRouter()
+		}, nil, Options{Overrides: eswagger.Config{Title: "Users", Version: "1.0.0"}}, false, "Users", ""},
+		{"serve mux", func() *eswagger.ServeMux {
+			m := eswagger.NewServeMux()
+			m.HandleFunc("GET /users", listUsers)
+			return m
+		}, nil, Options{Overrides: eswagger.Config{Title: "Users", Version: "1.0.0"}}, false, "Users", ""},
+		{"config file", newRouter, nil, Options{Config: configPath}, false, "Users", "From the file"},
+		{"config overridden", newRouter, nil, Options{Config: configPath, Overrides: eswagger.Config{Title: "Accounts"}}, false, "Accounts", "From the file"},
+		{"metadata", newRouter, func() eswagger.RouteMetadata { return eswagger.RouteMetadata{} }, Options{Overrides: eswagger.Config{Title: "Users", Version: "1.0.0"}}, false, "Users", ""},
+		{"strict", newRouter, nil, Options{Overrides: eswagger.Config{Title: "Users", Version: "1.0.0"}, Strict: true}, true, "", ""},
+		{"constructor error", func() (*mux.Router, error) { return nil, errors.New("no database") }, nil, Options{}, true, "", ""},
+		{"not a function", newRouter(), nil, Options{}, true, "", ""},
+		{"plain handler", func() http.Handler { return http.NewServeMux() }, nil, Options{}, true, "", ""},
+		{"invalid metadata", newRouter, func() map[string]string { return nil }, Options{}, true, "", ""},
+		{"missing config", newRouter, nil, Options{Config: filepath.Join(dir, "missing.yaml")}, true, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Out = filepath.Join(t.TempDir(), "swagger.json")
+			_, err := Run(tt.constructor, tt.metadata, opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(opts.Out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var swagger struct {
+				Info struct {
+					Title, Description string
+				}
+				Paths map[string]interface{}
+			}
+			if err := json.Unmarshal(data, &swagger); err != nil {
+				t.Fatal(err)
+			}
+			if swagger.Info.Title != tt.title || swagger.Info.Description != tt.description {
+				t.Errorf("info = %+v, want %q %q", swagger.Info, tt.title, tt.description)
+			}
+			if _, ok := swagger.Paths["/users"]; !ok {
+				t.Errorf("/users not documented: %s", data)
+			}
+		})
+	}
+}
+
+func TestMergeConfig(t *testing.T) {
+	loaded := eswagger.Config{
+		Title:      "Users",
+		Version:    "1.0.0",
+		BasePath:   "/api",
+		SourceDocs: true,
+		Overlays:   []string{"a.yaml"},
+	}
+	tests := []struct {
+		name      string
+		overrides eswagger.Config
+		want      eswagger.Config
+	}{
+		{"nothing set", eswagger.Config{}, loaded},
+		{"strings", eswagger.Config{Title: "Accounts", Host: "example.com"}, eswagger.Config{Title: "Accounts", Version: "1.0.0", Host: "example.com", BasePath: "/api", SourceDocs: true, Overlays: []string{"a.yaml"}}},
+		{"flags only enable", eswagger.Config{Annotations: true}, eswagger.Config{Title: "Users", Version: "1.0.0", BasePath: "/api", SourceDocs: true, Annotations: true, Overlays: []string{"a.yaml"}}},
+		{"overlays appended", eswagger.Config{Overlays: []string{"b.yaml"}}, eswagger.Config{Title: "Users", Version: "1.0.0", BasePath: "/api", SourceDocs: true, Overlays: []string{"a.yaml", "b.yaml"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeConfig(loaded, tt.overrides); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeConfig() = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -31,10 +31,44 @@ func (g *Generator) addOperationToPathItem(pathItem *spec.PathItem, method strin
 	}
 }
 
+// operationsOf returns the operations defined on a path item keyed by method
+func operationsOf(pathItem spec.PathItem) map[string]*spec.Operation {
+	operations := make(map[string]*spec.Operation)
+	for method, operation := range map[string]*spec.Operation{
+		"GET":     pathItem.Get,
+		"POST":    pathItem.Post,
+		"PUT":     pathItem.Put,
+		"DELETE":  pathItem.Delete,
+		"PATCH":   pathItem.Patch,
+		"HEAD":    pathItem.Head,
+		"OPTIONS": pathItem.Options,
+	} {
+		if operation != nil {
+			operations[method] = operation
+		}
+	}
+	return operations
+}
+
+// GetSwaggerSpec returns the current spec. It is shared with the handlers
+// serving it and must not be modified.
 func (g *Generator) GetSwaggerSpec() *spec.Swagger {
+	g.refresh()
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.swagger
 }
 
+// snapshot returns the spec along with its revision
+func (g *Generator) snapshot() (*spec.Swagger, uint64) {
+	g.refresh()
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.swagger, g.revision
+}
+
 func (g *Generator) extractResourceName(path string) string {
 
 	parts := strings.Split(path, "/")
@@ -0,0 +1,290 @@
+// Package lint checks a spec against API design rules, such as operations
+// having a summary or error responses sharing a schema
+package lint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is how much a rule matters, Off disables it
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Hint    Severity = "hint"
+	Off     Severity = "off"
+)
+
+func (s Severity) valid() bool {
+	switch s {
+	case Error, Warning, Hint, Off:
+		return true
+	}
+	return false
+}
+
+// Finding is a rule violation. Pointer is the JSON pointer of the offending
+// element, e.g. "/paths/~1users/get/responses/200".
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Method   string   `json:"method,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Pointer  string   `json:"pointer"`
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	if f.Method == "" {
+		return fmt.Sprintf("%s: %s (%s)", f.Pointer, f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%s %s: %s (%s)", f.Method, f.Path, f.Message, f.Rule)
+}
+
+// Rule checks one aspect of a spec
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity // default severity, Config.Rules overrides it
+	Check       func(c *Context)
+}
+
+// Config selects the severity of the rules
+type Config struct {
+	Rules       map[string]Severity `json:"rules" yaml:"rules"`             // rule ID -> severity
+	ErrorSchema string              `json:"errorSchema" yaml:"errorSchema"` // definition of error responses, the most used one when empty
+}
+
+// LoadConfig reads a Config from a YAML or JSON file
+func LoadConfig(path string) (Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("error reading lint config: %v", err)
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("error parsing lint config %s: %v", path, err)
+	}
+	return config, config.validate(Rules())
+}
+
+func (c Config) validate(rules []Rule) error {
+	known := make(map[string]bool)
+	for _, rule := range rules {
+		known[rule.ID] = true
+	}
+	for id, severity := range c.Rules {
+		if !known[id] {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		if !severity.valid() {
+			return fmt.Errorf("invalid severity %q for lint rule %s, expected error, warning, hint or off", severity, id)
+		}
+	}
+	return nil
+}
+
+// severity returns the configured severity of a rule
+func (c Config) severity(rule Rule) Severity {
+	if severity, ok := c.Rules[rule.ID]; ok {
+		return severity
+	}
+	return rule.Severity
+}
+
+// Context is passed to the rules, it gives access to the spec and collects
+// the findings
+type Context struct {
+	Swagger *spec.Swagger
+	Config  Config
+
+	rule     Rule
+	severity Severity
+	findings []Finding
+}
+
+// Report records a finding of the current rule. op is nil for findings
+// outside of an operation.
+func (c *Context) Report(op *Operation, pointer, format string, args ...interface{}) {
+	finding := Finding{
+		Rule:     c.rule.ID,
+		Severity: c.severity,
+		Pointer:  pointer,
+		Message:  fmt.Sprintf(format, args...),
+	}
+	if op != nil {
+		finding.Method, finding.Path = op.Method, op.Path
+	}
+	c.findings = append(c.findings, finding)
+}
+
+// Result holds the findings of Run
+type Result struct {
+	Rules    []Rule // the rules that ran, with their configured severity
+	Findings []Finding
+}
+
+// Run checks the spec with the built-in rules. Findings are sorted by their
+// location in the document.
+func Run(swagger *spec.Swagger, config Config) (Result, error) {
+	return RunRules(swagger, config, Rules())
+}
+
+// RunRules checks the spec with the given rules, e.g. the built-in ones
+// followed by rules of the project
+func RunRules(swagger *spec.Swagger, config Config, rules []Rule) (Result, error) {
+	if err := config.validate(rules); err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	c := &Context{Swagger: swagger, Config: config}
+	for _, rule := range rules {
+		severity := config.severity(rule)
+		if severity == Off {
+			continue
+		}
+		c.rule, c.severity = rule, severity
+		rule.Check(c)
+
+		rule.Severity = severity
+		result.Rules = append(result.Rules, rule)
+	}
+
+	result.Findings = c.findings
+	sort.SliceStable(result.Findings, func(i, j int) bool {
+		return comparePointers(result.Findings[i].Pointer, result.Findings[j].Pointer) < 0
+	})
+	return result, nil
+}
+
+// comparePointers orders JSON pointers token by token, array indexes as
+// numbers so /parameters/2 comes before /parameters/10
+func comparePointers(a, b string) int {
+	x, y := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] == y[i] {
+			continue
+		}
+		m, errM := strconv.Atoi(x[i])
+		n, errN := strconv.Atoi(y[i])
+		if errM == nil && errN == nil {
+			return m - n
+		}
+		return strings.Compare(x[i], y[i])
+	}
+	return len(x) - len(y)
+}
+
+// Of returns the findings of the given severities
+func (r Result) Of(severities ...Severity) []Finding {
+	var findings []Finding
+	for _, finding := range r.Findings {
+		for _, severity := range severities {
+			if finding.Severity == severity {
+				findings = append(findings, finding)
+				break
+			}
+		}
+	}
+	return findings
+}
+
+// Operation is an operation of the spec being linted
+type Operation struct {
+	Method    string
+	Path      string
+	Pointer   string
+	Item      spec.PathItem
+	Operation *spec.Operation
+}
+
+var methods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// Operations returns the operations of the spec by path and method
+func (c *Context) Operations() []Operation {
+	var ops []Operation
+	if c.Swagger.Paths == nil {
+		return ops
+	}
+
+	paths := make([]string, 0, len(c.Swagger.Paths.Paths))
+	for path := range c.Swagger.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		item := c.Swagger.Paths.Paths[path]
+		operations := map[string]*spec.Operation{
+			"GET": item.Get, "POST": item.Post, "PUT": item.Put, "PATCH": item.Patch,
+			"DELETE": item.Delete, "HEAD": item.Head, "OPTIONS": item.Options,
+		}
+		for _, method := range methods {
+			if operations[method] == nil {
+				continue
+			}
+			ops = append(ops, Operation{
+				Method:    method,
+				Path:      path,
+				Pointer:   Pointer("paths", path, strings.ToLower(method)),
+				Item:      item,
+				Operation: operations[method],
+			})
+		}
+	}
+	return ops
+}
+
+// Parameter is a parameter of an operation with the pointer of its
+// declaration, shared parameters being resolved
+type Parameter struct {
+	spec.Parameter
+	Pointer string
+}
+
+// Parameters returns the parameters of the operation followed by those of
+// its path item it doesn't override
+func (c *Context) Parameters(op Operation) []Parameter {
+	var params []Parameter
+	add := func(pointer string, param spec.Parameter) {
+		if name, ok := strings.CutPrefix(param.Ref.String(), "#/parameters/"); ok {
+			if shared, found := c.Swagger.Parameters[name]; found {
+				param = shared
+			}
+		}
+		for _, existing := range params {
+			if existing.In == param.In && existing.Name == param.Name {
+				return
+			}
+		}
+		params = append(params, Parameter{param, pointer})
+	}
+
+	for i, param := range op.Operation.Parameters {
+		add(op.Pointer+fmt.Sprintf("/parameters/%d", i), param)
+	}
+	for i, param := range op.Item.Parameters {
+		add(Pointer("paths", op.Path, "parameters", fmt.Sprint(i)), param)
+	}
+	return params
+}
+
+// Pointer builds a JSON pointer from unescaped reference tokens
+func Pointer(tokens ...string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return b.String()
+}
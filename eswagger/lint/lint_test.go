@@ -0,0 +1,237 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+// parseSpec reads the paths and definitions of a Swagger 2.0 document
+func parseSpec(t *testing.T, document string) *spec.Swagger {
+	t.Helper()
+	var swagger spec.Swagger
+	if err := json.Unmarshal([]byte(`{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},`+document+`}`), &swagger); err != nil {
+		t.Fatal(err)
+	}
+	return &swagger
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule     string
+		document string
+		want     []string // pointers of the findings
+	}{
+		{"operation-summary", `"paths":{"/users":{
+			"get":{"summary":"List users"},
+			"post":{},
+			"put":{"operationId":"replaceUsers","summary":"replaceUsers"},
+			"delete":{"summary":"delete_users"},
+			"patch":{"summary":"API"}}}`,
+			[]string{"/paths/~1users/delete/summary", "/paths/~1users/post", "/paths/~1users/put/summary"}},
+		{"no-empty-ref", `"paths":{},"definitions":{
+			"User":{"properties":{"group":{"$ref":"#/definitions/Group"},"tags":{"items":{"$ref":"#/definitions/"}}}},
+			"Group":{"properties":{"owner":{"$ref":"#/definitions/User"}}},
+			"Other":{"$ref":"other.json#/definitions/Thing"}}`,
+			[]string{"/definitions/User/properties/tags/items/$ref"}},
+		{"no-empty-ref", `"paths":{},"definitions":{"User":{"properties":{"group":{"$ref":"#/definitions/Missing"}}}}`,
+			[]string{"/definitions/User/properties/group/$ref"}},
+		{"path-parameters", `"paths":{
+			"/users/{id}":{"parameters":[{"name":"id","in":"path","required":true,"type":"string"}],"get":{},"put":{}},
+			"/groups/{id}":{"get":{"parameters":[{"name":"groupId","in":"path","required":true,"type":"string"}]}},
+			"/orgs/{id}":{"get":{"parameters":[{"name":"id","in":"path","type":"string"}]}}}`,
+			[]string{"/paths/~1groups~1{id}/get", "/paths/~1groups~1{id}/get/parameters/0", "/paths/~1orgs~1{id}/get/parameters/0"}},
+		{"plural-resources", `"paths":{
+			"/users/{id}":{"get":{}},
+			"/user/{id}":{"get":{},"put":{}},
+			"/me/{field}":{"get":{}},
+			"/users/{id}/profile":{"get":{}}}`,
+			[]string{"/paths/~1user~1{id}"}},
+		{"no-body-on-get-delete", `"paths":{"/users":{
+			"get":{"parameters":[{"name":"filter","in":"body","schema":{}}]},
+			"delete":{"parameters":[{"name":"id","in":"formData","type":"string"}]},
+			"post":{"parameters":[{"name":"user","in":"body","schema":{}}]}}}`,
+			[]string{"/paths/~1users/delete/parameters/0", "/paths/~1users/get/parameters/0"}},
+		{"success-response-schema", `"paths":{"/users":{
+			"get":{"responses":{"200":{"description":"OK"},"404":{"description":"Not Found"}}},
+			"post":{"responses":{"201":{"$ref":"#/responses/Created"}}},
+			"delete":{"responses":{"204":{"description":"No Content"}}},
+			"head":{"responses":{"200":{"description":"OK"}}}}},
+			"responses":{"Created":{"description":"Created","schema":{}}}`,
+			[]string{"/paths/~1users/get/responses/200"}},
+		{"snake-case-properties", `"paths":{},"definitions":{"User":{"properties":{"user_id":{},"firstName":{},"Address":{"properties":{"zip_code":{},"streetName":{}}}}}}`,
+			[]string{"/definitions/User/properties/Address", "/definitions/User/properties/Address/properties/streetName", "/definitions/User/properties/firstName"}},
+		{"shared-error-schema", `"paths":{"/users":{
+			"get":{"responses":{"404":{"schema":{"$ref":"#/definitions/Error"}},"500":{"schema":{"$ref":"#/definitions/Error"}}}},
+			"post":{"responses":{"400":{"schema":{"$ref":"#/definitions/Problem"}},"default":{"description":"Error"}}}}},
+			"definitions":{"Error":{},"Problem":{}}`,
+			[]string{"/paths/~1users/post/responses/400/schema", "/paths/~1users/post/responses/default"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rule, func(t *testing.T) {
+			var rules []Rule
+			for _, rule := range Rules() {
+				if rule.ID == tt.rule {
+					rules = append(rules, rule)
+				}
+			}
+			result, err := RunRules(parseSpec(t, tt.document), Config{}, rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, finding := range result.Findings {
+				got = append(got, finding.Pointer)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings at %q\nwant %q\n%v", got, tt.want, result.Findings)
+			}
+		})
+	}
+}
+
+func TestConfiguredErrorSchema(t *testing.T) {
+	swagger := parseSpec(t, `"paths":{"/users":{"get":{"responses":{
+		"404":{"schema":{"$ref":"#/definitions/Error"}},
+		"500":{"schema":{"$ref":"#/definitions/Error"}}}}}},
+		"definitions":{"Error":{},"Problem":{}}`)
+
+	result, err := Run(swagger, Config{
+		ErrorSchema: "Problem",
+		Rules:       map[string]Severity{"operation-summary": Off},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings := result.Of(Warning); len(findings) != 2 || findings[0].Rule != "shared-error-schema" {
+		t.Errorf("findings = %v", findings)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	swagger := parseSpec(t, `"paths":{"/users":{"get":{}}}`)
+
+	tests := []struct {
+		name     string
+		rules    map[string]Severity
+		severity Severity // of the operation-summary finding, empty when there is none
+		wantErr  bool
+	}{
+		{"default", nil, Warning, false},
+		{"raised", map[string]Severity{"operation-summary": Error}, Error, false},
+		{"lowered", map[string]Severity{"operation-summary": Hint}, Hint, false},
+		{"off", map[string]Severity{"operation-summary": Off}, "", false},
+		{"unknown rule", map[string]Severity{"summary": Error}, "", true},
+		{"invalid severity", map[string]Severity{"operation-summary": "fatal"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Run(swagger, Config{Rules: tt.rules})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+
+			var severity Severity
+			for _, finding := range result.Findings {
+				if finding.Rule == "operation-summary" {
+					severity = finding.Severity
+				}
+			}
+			if severity != tt.severity {
+				t.Errorf("severity = %q, want %q", severity, tt.severity)
+			}
+		})
+	}
+}
+
+func TestComparePointers(t *testing.T) {
+	pointers := []string{
+		"/paths/~1users/get/parameters/10",
+		"/definitions/User",
+		"/paths/~1users/get/parameters/2",
+		"/paths/~1users/get",
+		"/paths/~1users/get/parameters/2/schema",
+	}
+	want := []string{
+		"/definitions/User",
+		"/paths/~1users/get",
+		"/paths/~1users/get/parameters/2",
+		"/paths/~1users/get/parameters/2/schema",
+		"/paths/~1users/get/parameters/10",
+	}
+	sort.Slice(pointers, func(i, j int) bool { return comparePointers(pointers[i], pointers[j]) < 0 })
+	if !reflect.DeepEqual(pointers, want) {
+		t.Errorf("sorted = %q\nwant %q", pointers, want)
+	}
+}
+
+func TestLooksLikeIdentifier(t *testing.T) {
+	tests := []struct {
+		summary string
+		want    bool
+	}{
+		{"getUser", true},
+		{"get_user", true},
+		{"GetUser", true},
+		{"List users", false},
+		{"Users", false},
+		{"API", false},
+		{"HTTP_PROXY", false},
+	}
+	for _, tt := range tests {
+		if got := looksLikeIdentifier(tt.summary); got != tt.want {
+			t.Errorf("looksLikeIdentifier(%q) = %v, want %v", tt.summary, got, tt.want)
+		}
+	}
+}
+
+func TestPointer(t *testing.T) {
+	if got, want := Pointer("paths", "/users/{id}", "get"), "/paths/~1users~1{id}/get"; got != want {
+		t.Errorf("Pointer() = %q, want %q", got, want)
+	}
+	if got, want := Pointer("definitions", "a~b"), "/definitions/a~0b"; got != want {
+		t.Errorf("Pointer() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	source := "swagger: \"2.0\"\ninfo:\n  title: Users\n  version: 1.0.0\npaths:\n  /users:\n    get:\n      responses: {}\n"
+	var swagger spec.Swagger
+	if err := json.Unmarshal([]byte(`{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},"paths":{"/users":{"get":{"responses":{}}}}}`), &swagger); err != nil {
+		t.Fatal(err)
+	}
+	result, err := Run(&swagger, Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text bytes.Buffer
+	if err := result.WriteText(&text, Document{URI: "swagger.yaml", Source: []byte(source)}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "warning  swagger.yaml:7: GET /users: operation has no summary (operation-summary)\n"; !strings.HasPrefix(text.String(), want) {
+		t.Errorf("text = %q, want it to start with %q", text.String(), want)
+	}
+	if !strings.HasSuffix(text.String(), "\n0 errors, 1 warning, 0 hints\n") {
+		t.Errorf("text = %q", text.String())
+	}
+
+	var sarif bytes.Buffer
+	if err := result.WriteSARIF(&sarif, Document{URI: "swagger.yaml", Source: []byte(source)}); err != nil {
+		t.Fatal(err)
+	}
+	var log map[string]interface{}
+	if err := json.Unmarshal(sarif.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF: %v", err)
+	}
+	if !strings.Contains(sarif.String(), `"startLine": 7`) {
+		t.Errorf("SARIF without the line of the finding:\n%s", sarif.String())
+	}
+}
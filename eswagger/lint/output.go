@@ -0,0 +1,223 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is the linted file. When Source is set the findings are reported
+// with their line in it.
+type Document struct {
+	URI    string
+	Source []byte
+}
+
+// position returns the 1-based line and column of the element at a JSON
+// pointer, the closest existing parent when it is missing. It returns 0 when
+// the source isn't available.
+func position(root *yaml.Node, pointer string) (int, int) {
+	if root == nil {
+		return 0, 0
+	}
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	line, column := node.Line, node.Column
+
+	if pointer == "" {
+		return line, column
+	}
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		var next *yaml.Node
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == token {
+					// The key is where a reader looks for the element
+					line, column = node.Content[i].Line, node.Content[i].Column
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yaml.SequenceNode:
+			if i, err := strconv.Atoi(token); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+				line, column = next.Line, next.Column
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return line, column
+}
+
+// parse returns the YAML tree of the source, nil when there is none
+func (d Document) parse() *yaml.Node {
+	if len(d.Source) == 0 {
+		return nil
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(d.Source, &root); err != nil {
+		return nil
+	}
+	return &root
+}
+
+// Summary counts the findings of each severity, e.g. "1 error, 2 warnings, 0 hints"
+func (r Result) Summary() string {
+	plural := func(n int, word string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s", n, word)
+		}
+		return fmt.Sprintf("%d %ss", n, word)
+	}
+	return fmt.Sprintf("%s, %s, %s", plural(len(r.Of(Error)), "error"), plural(len(r.Of(Warning)), "warning"), plural(len(r.Of(Hint)), "hint"))
+}
+
+// WriteText writes one line per finding followed by the summary
+func (r Result) WriteText(w io.Writer, doc Document) error {
+	if len(r.Findings) == 0 {
+		_, err := fmt.Fprintln(w, "no findings")
+		return err
+	}
+
+	root := doc.parse()
+	var b strings.Builder
+	for _, finding := range r.Findings {
+		fmt.Fprintf(&b, "%-8s ", finding.Severity)
+		if line, _ := position(root, finding.Pointer); line > 0 {
+			fmt.Fprintf(&b, "%s:%d: ", doc.URI, line)
+		}
+		fmt.Fprintln(&b, finding)
+	}
+	fmt.Fprintf(&b, "\n%s\n", r.Summary())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// SARIF 2.1.0 log, only the properties code scanning tools read
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation struct {
+		URI string `json:"uri"`
+	} `json:"artifactLocation"`
+	Region *sarifRegion `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// sarifLevels maps the severities to SARIF levels
+var sarifLevels = map[Severity]string{Error: "error", Warning: "warning", Hint: "note"}
+
+// WriteSARIF writes the findings as a SARIF 2.1.0 log, e.g. for GitHub code
+// scanning. The JSON pointer of each finding is its logical location.
+func (r Result) WriteSARIF(w io.Writer, doc Document) error {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: "eswagger lint", Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+
+	ruleIndex := make(map[string]int)
+	for i, rule := range r.Rules {
+		ruleIndex[rule.ID] = i
+		sarif := sarifRule{ID: rule.ID, ShortDescription: sarifMessage{rule.Description}}
+		sarif.DefaultConfiguration.Level = sarifLevels[rule.Severity]
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarif)
+	}
+
+	root := doc.parse()
+	for _, finding := range r.Findings {
+		location := sarifLocation{
+			LogicalLocations: []sarifLogicalLocation{{FullyQualifiedName: finding.Pointer, Kind: "element"}},
+		}
+		if doc.URI != "" {
+			location.PhysicalLocation = &sarifPhysicalLocation{}
+			location.PhysicalLocation.ArtifactLocation.URI = doc.URI
+			if line, column := position(root, finding.Pointer); line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{StartLine: line, StartColumn: column}
+			}
+		}
+
+		message := finding.Message
+		if finding.Method != "" {
+			message = fmt.Sprintf("%s %s: %s", finding.Method, finding.Path, message)
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    finding.Rule,
+			RuleIndex: ruleIndex[finding.Rule],
+			Level:     sarifLevels[finding.Severity],
+			Message:   sarifMessage{message},
+			Locations: []sarifLocation{location},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
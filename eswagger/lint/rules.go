@@ -0,0 +1,309 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-openapi/spec"
+)
+
+// Rules returns the built-in rules with their default severity
+func Rules() []Rule {
+	return []Rule{
+		{"operation-summary", "Every operation has a human readable summary", Warning, checkOperationSummary},
+		{"no-empty-ref", "Every $ref points to an existing definition", Error, checkRefs},
+		{"path-parameters", "Path template parameters and declared path parameters match", Error, checkPathParameters},
+		{"plural-resources", "Collection segments followed by an ID are plural", Warning, checkPluralResources},
+		{"no-body-on-get-delete", "GET, HEAD and DELETE operations take no request body", Warning, checkNoBody},
+		{"success-response-schema", "Every 2xx response other than 204 has a schema", Warning, checkSuccessSchema},
+		{"snake-case-properties", "Property names are snake_case", Warning, checkSnakeCase},
+		{"shared-error-schema", "Error responses use the shared error schema", Warning, checkErrorSchema},
+	}
+}
+
+func checkOperationSummary(c *Context) {
+	for _, op := range c.Operations() {
+		summary := strings.TrimSpace(op.Operation.Summary)
+		switch {
+		case summary == "":
+			c.Report(&op, op.Pointer, "operation has no summary")
+		case summary == op.Operation.ID || looksLikeIdentifier(summary):
+			// A summary repeating the handler name doesn't help
+			c.Report(&op, op.Pointer+"/summary", "summary %q reads like an identifier, describe the operation", summary)
+		}
+	}
+}
+
+func checkRefs(c *Context) {
+	c.Schemas(func(pointer string, schema *spec.Schema) {
+		ref := schema.Ref.String()
+		if ref == "" {
+			return
+		}
+		name, ok := strings.CutPrefix(ref, "#/definitions/")
+		switch {
+		case !ok:
+			// External and other local references are left to the validator
+		case name == "":
+			c.Report(nil, pointer+"/$ref", "empty $ref %q, the type has no name", ref)
+		default:
+			if _, found := c.Swagger.Definitions[name]; !found {
+				c.Report(nil, pointer+"/$ref", "$ref %q points to a missing definition", ref)
+			}
+		}
+	})
+}
+
+// looksLikeIdentifier tells whether a summary is a single camelCase or
+// snake_case word such as "getUser", acronyms such as "API" are words
+func looksLikeIdentifier(summary string) bool {
+	if strings.ContainsAny(summary, " \t") || strings.ToUpper(summary) == summary {
+		return false
+	}
+	if strings.Contains(summary, "_") {
+		return true
+	}
+	for i := 1; i < len(summary); i++ {
+		if unicode.IsLower(rune(summary[i-1])) && unicode.IsUpper(rune(summary[i])) {
+			return true
+		}
+	}
+	return false
+}
+
+var pathTemplateParam = regexp.MustCompile(`\{([^}]*)\}`)
+
+func checkPathParameters(c *Context) {
+	for _, op := range c.Operations() {
+		inTemplate := make(map[string]bool)
+		for _, match := range pathTemplateParam.FindAllStringSubmatch(op.Path, -1) {
+			inTemplate[match[1]] = true
+		}
+
+		declared := make(map[string]bool)
+		for _, param := range c.Parameters(op) {
+			if param.In != "path" {
+				continue
+			}
+			declared[param.Name] = true
+			if !inTemplate[param.Name] {
+				c.Report(&op, param.Pointer, "path parameter %s is not in the path", param.Name)
+			} else if !param.Required {
+				c.Report(&op, param.Pointer, "path parameter %s must be required", param.Name)
+			}
+		}
+
+		for _, match := range pathTemplateParam.FindAllStringSubmatch(op.Path, -1) {
+			if !declared[match[1]] {
+				c.Report(&op, op.Pointer, "path parameter %s is not declared", match[1])
+			}
+		}
+	}
+}
+
+// singularExceptions are segments followed by an ID that aren't collections
+var singularExceptions = map[string]bool{"api": true, "me": true}
+
+func checkPluralResources(c *Context) {
+	reported := make(map[string]bool)
+	for _, op := range c.Operations() {
+		segments := strings.Split(strings.Trim(op.Path, "/"), "/")
+		for i := 0; i+1 < len(segments); i++ {
+			segment := segments[i]
+			if !pathTemplateParam.MatchString(segments[i+1]) || pathTemplateParam.MatchString(segment) {
+				continue
+			}
+			if strings.HasSuffix(segment, "s") || singularExceptions[strings.ToLower(segment)] || reported[op.Path] {
+				continue
+			}
+			reported[op.Path] = true
+			c.Report(&op, Pointer("paths", op.Path), "collection %q should be plural", segment)
+		}
+	}
+}
+
+func checkNoBody(c *Context) {
+	for _, op := range c.Operations() {
+		if op.Method != "GET" && op.Method != "HEAD" && op.Method != "DELETE" {
+			continue
+		}
+		for _, param := range c.Parameters(op) {
+			if param.In == "body" || param.In == "formData" {
+				c.Report(&op, param.Pointer, "%s operation takes a request body", op.Method)
+				break
+			}
+		}
+	}
+}
+
+func checkSuccessSchema(c *Context) {
+	for _, op := range c.Operations() {
+		if op.Method == "HEAD" || op.Operation.Responses == nil {
+			continue
+		}
+		for _, code := range sortedCodes(op.Operation.Responses) {
+			if code < 200 || code > 299 || code == 204 {
+				continue
+			}
+			response := c.resolveResponse(op.Operation.Responses.StatusCodeResponses[code])
+			if response.Schema == nil {
+				c.Report(&op, op.Pointer+fmt.Sprintf("/responses/%d", code), "response %d has no schema", code)
+			}
+		}
+	}
+}
+
+var snakeCase = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+func checkSnakeCase(c *Context) {
+	c.Schemas(func(pointer string, schema *spec.Schema) {
+		for _, name := range sortedKeys(schema.Properties) {
+			if !snakeCase.MatchString(name) {
+				c.Report(nil, pointer+Pointer("properties", name), "property %s is not snake_case", name)
+			}
+		}
+	})
+}
+
+// checkErrorSchema compares the schema of the 4xx, 5xx and default responses
+// to Config.ErrorSchema, or to the schema most of them use
+func checkErrorSchema(c *Context) {
+	type errorResponse struct {
+		op      Operation
+		pointer string
+		code    string
+		ref     string
+	}
+
+	var responses []errorResponse
+	uses := make(map[string]int)
+	for _, op := range c.Operations() {
+		if op.Operation.Responses == nil {
+			continue
+		}
+		add := func(code string, response spec.Response) {
+			response = c.resolveResponse(response)
+			ref := ""
+			if response.Schema != nil {
+				ref = strings.TrimPrefix(response.Schema.Ref.String(), "#/definitions/")
+			}
+			responses = append(responses, errorResponse{op, op.Pointer + "/responses/" + code, code, ref})
+			if ref != "" {
+				uses[ref]++
+			}
+		}
+		for _, code := range sortedCodes(op.Operation.Responses) {
+			if code >= 400 {
+				add(fmt.Sprint(code), op.Operation.Responses.StatusCodeResponses[code])
+			}
+		}
+		if op.Operation.Responses.Default != nil {
+			add("default", *op.Operation.Responses.Default)
+		}
+	}
+
+	shared := c.Config.ErrorSchema
+	if shared == "" {
+		for name, count := range uses {
+			if count > uses[shared] || count == uses[shared] && name < shared {
+				shared = name
+			}
+		}
+	}
+	if shared == "" {
+		return
+	}
+
+	for _, response := range responses {
+		switch response.ref {
+		case shared:
+		case "":
+			c.Report(&response.op, response.pointer, "response %s doesn't use the error schema %s", response.code, shared)
+		default:
+			c.Report(&response.op, response.pointer+"/schema", "response %s uses %s instead of the error schema %s", response.code, response.ref, shared)
+		}
+	}
+}
+
+func (c *Context) resolveResponse(response spec.Response) spec.Response {
+	if name, ok := strings.CutPrefix(response.Ref.String(), "#/responses/"); ok {
+		if shared, found := c.Swagger.Responses[name]; found {
+			return shared
+		}
+	}
+	return response
+}
+
+// Schemas calls fn for every schema of the document, nested ones included,
+// with its JSON pointer
+func (c *Context) Schemas(fn func(pointer string, schema *spec.Schema)) {
+	var walk func(pointer string, schema *spec.Schema)
+	walk = func(pointer string, schema *spec.Schema) {
+		if schema == nil {
+			return
+		}
+		fn(pointer, schema)
+		for _, name := range sortedKeys(schema.Properties) {
+			property := schema.Properties[name]
+			walk(pointer+Pointer("properties", name), &property)
+		}
+		if schema.Items != nil {
+			walk(pointer+"/items", schema.Items.Schema)
+			for i := range schema.Items.Schemas {
+				walk(pointer+fmt.Sprintf("/items/%d", i), &schema.Items.Schemas[i])
+			}
+		}
+		if schema.AdditionalProperties != nil {
+			walk(pointer+"/additionalProperties", schema.AdditionalProperties.Schema)
+		}
+		for i := range schema.AllOf {
+			walk(pointer+fmt.Sprintf("/allOf/%d", i), &schema.AllOf[i])
+		}
+	}
+
+	for _, name := range sortedKeys(c.Swagger.Definitions) {
+		definition := c.Swagger.Definitions[name]
+		walk(Pointer("definitions", name), &definition)
+	}
+	for _, name := range sortedKeys(c.Swagger.Parameters) {
+		walk(Pointer("parameters", name, "schema"), c.Swagger.Parameters[name].Schema)
+	}
+	for _, name := range sortedKeys(c.Swagger.Responses) {
+		walk(Pointer("responses", name, "schema"), c.Swagger.Responses[name].Schema)
+	}
+	for _, op := range c.Operations() {
+		for i, param := range op.Operation.Parameters {
+			walk(op.Pointer+fmt.Sprintf("/parameters/%d/schema", i), param.Schema)
+		}
+		if op.Operation.Responses == nil {
+			continue
+		}
+		for _, code := range sortedCodes(op.Operation.Responses) {
+			walk(op.Pointer+fmt.Sprintf("/responses/%d/schema", code), op.Operation.Responses.StatusCodeResponses[code].Schema)
+		}
+		if op.Operation.Responses.Default != nil {
+			walk(op.Pointer+"/responses/default/schema", op.Operation.Responses.Default.Schema)
+		}
+	}
+}
+
+func sortedCodes(responses *spec.Responses) []int {
+	codes := make([]int, 0, len(responses.StatusCodeResponses))
+	for code := range responses.StatusCodeResponses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,725 @@
+// Package merge combines the specs of several services into one, e.g. the
+// spec of an API gateway. Paths are prefixed per service, definitions
+// colliding between services are namespaced and identical ones shared.
+package merge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"main/eswagger"
+
+	"github.com/go-openapi/spec"
+)
+
+// Service is a spec to merge
+type Service struct {
+	Name   string // namespaces its definitions and operationIds on collisions, e.g. "users"
+	Prefix string // prepended to its paths, e.g. "/users", may be empty
+	Spec   *spec.Swagger
+}
+
+// FromGenerator returns the service of an in-process generator, its current
+// spec is merged
+func FromGenerator(name, prefix string, g *eswagger.Generator) Service {
+	return Service{Name: name, Prefix: prefix, Spec: g.GetSwaggerSpec()}
+}
+
+// FromFile returns the service of a JSON or YAML spec file
+func FromFile(name, prefix, path string) (Service, error) {
+	swagger, err := eswagger.LoadSpec(path)
+	if err != nil {
+		return Service{}, err
+	}
+	return Service{Name: name, Prefix: prefix, Spec: swagger}, nil
+}
+
+// Options sets the document level fields of the merged spec
+type Options struct {
+	Title       string // the titles of the services joined when empty
+	Description string
+	Version     string // the version shared by the services, else 1.0.0, when empty
+	Host        string
+	BasePath    string
+	Schemes     []string
+}
+
+// ConflictKind classifies what two services disagree on
+type ConflictKind string
+
+const (
+	// ConflictPath is a path claimed by several services. Their operations
+	// are merged into one path item, for a method declared twice the
+	// operation of the first service is kept. The path parameters of the
+	// later services are renamed after the first template, e.g. {userId}
+	// merged into /users/{id}.
+	ConflictPath ConflictKind = "path"
+	// ConflictDefinition is a definition name used for different schemas,
+	// they are namespaced by service. Shared parameters and responses are
+	// handled the same way.
+	ConflictDefinition ConflictKind = "definition"
+	// ConflictSecurityScheme is a security scheme name used for different
+	// schemes, they are namespaced by service
+	ConflictSecurityScheme ConflictKind = "security_scheme"
+	// ConflictOperationID is an operationId used by several services, the
+	// later ones are namespaced
+	ConflictOperationID ConflictKind = "operation_id"
+	// ConflictTag is a tag described differently, the first description wins
+	ConflictTag ConflictKind = "tag"
+)
+
+// Conflict is a disagreement between services and how Merge resolved it
+type Conflict struct {
+	Kind     ConflictKind `json:"kind"`
+	Name     string       `json:"name"` // the path, definition, scheme, operationId or tag
+	Services []string     `json:"services"`
+	Message  string       `json:"message"`
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s: %s", c.Kind, c.Name, c.Message)
+}
+
+// Result is the merged spec with the conflicts found on the way
+type Result struct {
+	Swagger   *spec.Swagger
+	Conflicts []Conflict
+}
+
+// Of returns the conflicts of the given kinds
+func (r Result) Of(kinds ...ConflictKind) []Conflict {
+	var conflicts []Conflict
+	for _, conflict := range r.Conflicts {
+		for _, kind := range kinds {
+			if conflict.Kind == kind {
+				conflicts = append(conflicts, conflict)
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// componentKinds are the sections of objects referenced with $ref
+var componentKinds = []string{"definitions", "parameters", "responses"}
+
+var methods = []string{"get", "put", "post", "delete", "options", "head", "patch"}
+
+// service is a Service being merged, its spec decoded as a JSON document
+type service struct {
+	Service
+	doc     map[string]interface{}
+	renames map[string]map[string]string // kind -> name -> merged name
+}
+
+type merger struct {
+	services  []*service
+	conflicts []Conflict
+	same      map[sameKey]bool
+}
+
+// Merge combines the specs in the order of services. The services keep
+// their own base path under their prefix, the top level consumes, produces
+// and security of each service are copied to its operations.
+func Merge(services []Service, opts Options) (Result, error) {
+	if len(services) == 0 {
+		return Result{}, errors.New("no spec to merge")
+	}
+
+	m := &merger{same: make(map[sameKey]bool)}
+	names := make(map[string]bool)
+	for _, s := range services {
+		if s.Name == "" {
+			return Result{}, errors.New("every service needs a name")
+		}
+		if names[s.Name] {
+			return Result{}, fmt.Errorf("service %s is given twice", s.Name)
+		}
+		names[s.Name] = true
+
+		doc, err := decode(s.Spec)
+		if err != nil {
+			return Result{}, fmt.Errorf("service %s: %v", s.Name, err)
+		}
+		m.services = append(m.services, &service{Service: s, doc: doc, renames: make(map[string]map[string]string)})
+	}
+
+	for _, kind := range componentKinds {
+		m.nameComponents(kind)
+	}
+	m.nameSecuritySchemes()
+	for _, s := range m.services {
+		rewriteRefs(s.doc, s.renames)
+		rewriteSecurity(s.doc, s.renames["securityDefinitions"])
+		pushDown(s.doc)
+	}
+
+	merged := map[string]interface{}{
+		"swagger": "2.0",
+		"info":    m.info(opts),
+		"paths":   m.paths(),
+	}
+	if opts.Host != "" {
+		merged["host"] = opts.Host
+	}
+	if opts.BasePath != "" {
+		merged["basePath"] = opts.BasePath
+	}
+	if len(opts.Schemes) > 0 {
+		merged["schemes"] = opts.Schemes
+	}
+	for _, kind := range append(componentKinds, "securityDefinitions") {
+		if components := m.components(kind); len(components) > 0 {
+			merged[kind] = components
+		}
+	}
+	if tags := m.tags(); len(tags) > 0 {
+		merged["tags"] = tags
+	}
+
+	data, err := json.Marshal(merged)
+	if err != nil {
+		return Result{}, fmt.Errorf("error encoding the merged spec: %v", err)
+	}
+	swagger := new(spec.Swagger)
+	if err := json.Unmarshal(data, swagger); err != nil {
+		return Result{}, fmt.Errorf("error decoding the merged spec: %v", err)
+	}
+	return Result{Swagger: swagger, Conflicts: m.conflicts}, nil
+}
+
+func decode(swagger *spec.Swagger) (map[string]interface{}, error) {
+	data, err := json.Marshal(swagger)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	return doc, json.Unmarshal(data, &doc)
+}
+
+func (m *merger) conflict(kind ConflictKind, name string, services []string, format string, args ...interface{}) {
+	m.conflicts = append(m.conflicts, Conflict{Kind: kind, Name: name, Services: services, Message: fmt.Sprintf(format, args...)})
+}
+
+// qualify namespaces a name with the service, e.g. "users.User"
+func qualify(s *service, name string) string {
+	return s.Name + "." + name
+}
+
+func section(doc map[string]interface{}, kind string) map[string]interface{} {
+	objects, _ := doc[kind].(map[string]interface{})
+	return objects
+}
+
+// nameComponents picks the merged name of the components of a kind. A name
+// used by several services for identical components stays as is and the
+// component is shared, otherwise every variant is namespaced.
+func (m *merger) nameComponents(kind string) {
+	m.nameSections(kind, ConflictDefinition, strings.TrimSuffix(kind, "s"), func(a, b *service, name string) bool {
+		return m.sameComponent(a, b, kind, name)
+	})
+}
+
+func (m *merger) nameSecuritySchemes() {
+	m.nameSections("securityDefinitions", ConflictSecurityScheme, "security scheme", func(a, b *service, name string) bool {
+		return reflect.DeepEqual(section(a.doc, "securityDefinitions")[name], section(b.doc, "securityDefinitions")[name])
+	})
+}
+
+func (m *merger) nameSections(kind string, conflictKind ConflictKind, label string, same func(a, b *service, name string) bool) {
+	users := make(map[string][]*service)
+	for _, s := range m.services {
+		s.renames[kind] = make(map[string]string)
+		for name := range section(s.doc, kind) {
+			users[name] = append(users[name], s)
+		}
+	}
+
+	for _, name := range sortedKeys(users) {
+		// Group the services by identical variant, in the order of services
+		var variants [][]*service
+		for _, s := range users[name] {
+			found := false
+			for i, variant := range variants {
+				if same(variant[0], s, name) {
+					variants[i], found = append(variant, s), true
+					break
+				}
+			}
+			if !found {
+				variants = append(variants, []*service{s})
+			}
+		}
+
+		if len(variants) == 1 {
+			for _, s := range variants[0] {
+				s.renames[kind][name] = name
+			}
+			continue
+		}
+		var renamed []string
+		for _, variant := range variants {
+			for _, s := range variant {
+				s.renames[kind][name] = qualify(variant[0], name)
+			}
+			renamed = append(renamed, qualify(variant[0], name))
+		}
+		m.conflict(conflictKind, name, serviceNames(users[name]), "%d different %ss, renamed %s", len(variants), label, strings.Join(renamed, ", "))
+	}
+}
+
+type sameKey struct {
+	a, b       *service
+	kind, name string
+}
+
+// sameComponent tells whether two services declare the same component: the
+// same JSON and the components it references the same too
+func (m *merger) sameComponent(a, b *service, kind, name string) bool {
+	key := sameKey{a, b, kind, name}
+	if same, ok := m.same[key]; ok {
+		return same
+	}
+	// Recursive schemas are assumed identical while being compared
+	m.same[key] = true
+
+	x, okX := section(a.doc, kind)[name]
+	y, okY := section(b.doc, kind)[name]
+	same := okX && okY && reflect.DeepEqual(x, y)
+	if same {
+		for _, ref := range localRefs(x, nil) {
+			if !m.sameComponent(a, b, ref.kind, ref.name) {
+				same = false
+				break
+			}
+		}
+	}
+	m.same[key] = same
+	return same
+}
+
+type ref struct {
+	kind, name string
+}
+
+var unescapeToken = strings.NewReplacer("~1", "/", "~0", "~")
+var escapeToken = strings.NewReplacer("~", "~0", "/", "~1")
+
+// parseRef splits a local reference such as "#/definitions/User"
+func parseRef(value string) (ref, bool) {
+	rest, ok := strings.CutPrefix(value, "#/")
+	if !ok {
+		return ref{}, false
+	}
+	kind, name, ok := strings.Cut(rest, "/")
+	if !ok || strings.Contains(name, "/") {
+		return ref{}, false
+	}
+	return ref{kind, unescapeToken.Replace(name)}, true
+}
+
+// namedMaps are the keys whose value maps names, rather than keywords, to
+// objects: a property called "example" is a schema like any other
+var namedMaps = map[string]bool{
+	"properties":          true,
+	"definitions":         true,
+	"parameters":          true,
+	"securityDefinitions": true,
+	"headers":             true,
+}
+
+// walkRefs calls fn on every object holding a $ref, examples and extensions
+// are free form and skipped
+func walkRefs(value interface{}, fn func(object map[string]interface{}, r ref)) {
+	walkObject(value, false, fn)
+}
+
+// walkObject is walkRefs for a value whose keys are names when named is set
+func walkObject(value interface{}, named bool, fn func(object map[string]interface{}, r ref)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if named {
+			for _, item := range v {
+				walkObject(item, false, fn)
+			}
+			return
+		}
+		if s, ok := v["$ref"].(string); ok {
+			if r, ok := parseRef(s); ok {
+				fn(v, r)
+			}
+		}
+		for key, item := range v {
+			if key == "example" || key == "examples" || strings.HasPrefix(key, "x-") {
+				continue
+			}
+			walkObject(item, namedMaps[key], fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkObject(item, false, fn)
+		}
+	}
+}
+
+func localRefs(value interface{}, refs []ref) []ref {
+	walkRefs(value, func(_ map[string]interface{}, r ref) {
+		refs = append(refs, r)
+	})
+	return refs
+}
+
+// rewriteRefs points the references of a service to the merged names and
+// renames its components
+func rewriteRefs(doc map[string]interface{}, renames map[string]map[string]string) {
+	walkRefs(doc, func(object map[string]interface{}, r ref) {
+		if name, ok := renames[r.kind][r.name]; ok && name != r.name {
+			object["$ref"] = "#/" + r.kind + "/" + escapeToken.Replace(name)
+		}
+	})
+	for _, kind := range componentKinds {
+		objects := section(doc, kind)
+		renamed := make(map[string]interface{}, len(objects))
+		for name, object := range objects {
+			renamed[renames[kind][name]] = object
+		}
+		if objects != nil {
+			doc[kind] = renamed
+		}
+	}
+}
+
+// rewriteSecurity renames the schemes in the security requirements and the
+// security definitions of a service
+func rewriteSecurity(doc map[string]interface{}, renames map[string]string) {
+	rename := func(requirements interface{}) {
+		list, _ := requirements.([]interface{})
+		for i, item := range list {
+			requirement, _ := item.(map[string]interface{})
+			renamed := make(map[string]interface{}, len(requirement))
+			for name, scopes := range requirement {
+				if merged, ok := renames[name]; ok {
+					name = merged
+				}
+				renamed[name] = scopes
+			}
+			list[i] = renamed
+		}
+	}
+
+	rename(doc["security"])
+	for _, item := range section(doc, "paths") {
+		pathItem, _ := item.(map[string]interface{})
+		for _, method := range methods {
+			if operation, ok := pathItem[method].(map[string]interface{}); ok {
+				rename(operation["security"])
+			}
+		}
+	}
+	if schemes := section(doc, "securityDefinitions"); schemes != nil {
+		renamed := make(map[string]interface{}, len(schemes))
+		for name, scheme := range schemes {
+			renamed[renames[name]] = scheme
+		}
+		doc["securityDefinitions"] = renamed
+	}
+}
+
+// pushDown copies what a service sets at the top level or on a path item
+// to its operations, the merged spec has no room for it
+func pushDown(doc map[string]interface{}) {
+	for _, item := range section(doc, "paths") {
+		pathItem, _ := item.(map[string]interface{})
+		shared, _ := pathItem["parameters"].([]interface{})
+		delete(pathItem, "parameters")
+
+		for _, method := range methods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			for _, key := range []string{"consumes", "produces", "security", "schemes"} {
+				if _, set := operation[key]; !set && doc[key] != nil {
+					operation[key] = doc[key]
+				}
+			}
+
+			params, _ := operation["parameters"].([]interface{})
+			declared := make(map[string]bool)
+			for _, param := range params {
+				declared[parameterKey(doc, param)] = true
+			}
+			for _, param := range shared {
+				if !declared[parameterKey(doc, param)] {
+					params = append(params, param)
+				}
+			}
+			if len(params) > 0 {
+				operation["parameters"] = params
+			}
+		}
+	}
+}
+
+// parameterKey identifies a parameter by location and name, references to
+// shared parameters being resolved
+func parameterKey(doc map[string]interface{}, value interface{}) string {
+	param, _ := value.(map[string]interface{})
+	if s, ok := param["$ref"].(string); ok {
+		if r, ok := parseRef(s); ok && r.kind == "parameters" {
+			param, _ = section(doc, "parameters")[r.name].(map[string]interface{})
+		}
+	}
+	return fmt.Sprintf("%v %v", param["in"], param["name"])
+}
+
+var templateParam = regexp.MustCompile(`\{[^}]*\}`)
+
+// paths merges the paths of the services under their prefix
+func (m *merger) paths() map[string]interface{} {
+	paths := make(map[string]interface{})
+	type claim struct {
+		path     string
+		services []*service
+		methods  map[string]*service
+		kept     []string // methods declared again by a later service
+		renamed  []string // parameters renamed after the template kept
+	}
+	claims := make(map[string]*claim) // path with unnamed parameters -> claim
+	var claimed []*claim
+	operationIDs := make(map[string]*service)
+
+	for _, s := range m.services {
+		basePath, _ := s.doc["basePath"].(string)
+		items := section(s.doc, "paths")
+		for _, path := range sortedKeys(items) {
+			item, _ := items[path].(map[string]interface{})
+			merged := joinPaths(s.Prefix, basePath, path)
+
+			// /users/{id} and /users/{userId} are the same route
+			key := templateParam.ReplaceAllString(merged, "{}")
+			c, ok := claims[key]
+			if !ok {
+				c = &claim{path: merged, methods: make(map[string]*service)}
+				claims[key] = c
+				claimed = append(claimed, c)
+				paths[merged] = make(map[string]interface{})
+			}
+			if len(c.services) == 0 || c.services[len(c.services)-1] != s {
+				c.services = append(c.services, s)
+			}
+			pathItem := paths[c.path].(map[string]interface{})
+			renames := paramRenames(merged, c.path)
+
+			for key, value := range item {
+				if strings.HasPrefix(key, "x-") {
+					if _, set := pathItem[key]; !set {
+						pathItem[key] = value
+					}
+				}
+			}
+			for _, method := range methods {
+				operation, ok := item[method].(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if owner, taken := c.methods[method]; taken {
+					c.kept = append(c.kept, fmt.Sprintf("%s of %s", strings.ToUpper(method), owner.Name))
+					continue
+				}
+				c.methods[method] = s
+				for _, renamed := range renamePathParams(s.doc, operation, renames) {
+					c.renamed = append(c.renamed, fmt.Sprintf("%s in the %s of %s", renamed, strings.ToUpper(method), s.Name))
+				}
+
+				if id, ok := operation["operationId"].(string); ok && id != "" {
+					if owner, taken := operationIDs[id]; taken {
+						m.conflict(ConflictOperationID, id, serviceNames([]*service{owner, s}), "used by %s and %s, renamed %s in %s", owner.Name, s.Name, qualify(s, id), s.Name)
+						id = qualify(s, id)
+						operation["operationId"] = id
+					}
+					operationIDs[id] = s
+				}
+				pathItem[method] = operation
+			}
+		}
+	}
+
+	for _, c := range claimed {
+		if len(c.services) < 2 && len(c.kept) == 0 {
+			continue
+		}
+		message := "claimed by " + strings.Join(serviceNames(c.services), " and ")
+		if len(c.kept) > 0 {
+			message += ", kept the " + strings.Join(c.kept, ", ")
+		}
+		if len(c.renamed) > 0 {
+			message += ", renamed " + strings.Join(c.renamed, ", ")
+		}
+		m.conflict(ConflictPath, c.path, serviceNames(c.services), "%s", message)
+	}
+	return paths
+}
+
+// paramRenames maps the parameters of a path template to those of the
+// template it was merged into, at the same positions
+func paramRenames(path, kept string) map[string]string {
+	if path == kept {
+		return nil
+	}
+	from, to := templateParam.FindAllString(path, -1), templateParam.FindAllString(kept, -1)
+	renames := make(map[string]string, len(from))
+	for i := range from {
+		if i < len(to) && from[i] != to[i] {
+			renames[strings.Trim(from[i], "{}")] = strings.Trim(to[i], "{}")
+		}
+	}
+	return renames
+}
+
+// renamePathParams renames the path parameters of an operation after the
+// template it is merged into and returns them as "{old} to {new}". Shared
+// parameters are copied into the operation rather than renamed in place.
+func renamePathParams(doc, operation map[string]interface{}, renames map[string]string) []string {
+	if len(renames) == 0 {
+		return nil
+	}
+	var renamed []string
+	params, _ := operation["parameters"].([]interface{})
+	for i, value := range params {
+		param, _ := value.(map[string]interface{})
+		if s, ok := param["$ref"].(string); ok {
+			if r, ok := parseRef(s); ok && r.kind == "parameters" {
+				param, _ = section(doc, "parameters")[r.name].(map[string]interface{})
+			}
+		}
+		name, _ := param["name"].(string)
+		to, ok := renames[name]
+		if param["in"] != "path" || !ok {
+			continue
+		}
+
+		copied := make(map[string]interface{}, len(param))
+		for key, field := range param {
+			copied[key] = field
+		}
+		copied["name"] = to
+		params[i] = copied
+		renamed = append(renamed, fmt.Sprintf("{%s} to {%s}", name, to))
+	}
+	return renamed
+}
+
+// joinPaths joins path segments with single slashes
+func joinPaths(parts ...string) string {
+	var segments []string
+	for _, part := range parts {
+		for _, segment := range strings.Split(part, "/") {
+			if segment != "" {
+				segments = append(segments, segment)
+			}
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// components collects the renamed components of a kind, shared ones once
+func (m *merger) components(kind string) map[string]interface{} {
+	components := make(map[string]interface{})
+	for _, s := range m.services {
+		for name, object := range section(s.doc, kind) {
+			if _, ok := components[name]; !ok {
+				components[name] = object
+			}
+		}
+	}
+	return components
+}
+
+func (m *merger) tags() []interface{} {
+	var tags []interface{}
+	index := make(map[string]map[string]interface{})
+	owners := make(map[string]*service)
+	for _, s := range m.services {
+		list, _ := s.doc["tags"].([]interface{})
+		for _, item := range list {
+			tag, _ := item.(map[string]interface{})
+			name, _ := tag["name"].(string)
+			existing, ok := index[name]
+			if !ok {
+				index[name], owners[name] = tag, s
+				tags = append(tags, tag)
+				continue
+			}
+			description, _ := tag["description"].(string)
+			kept, _ := existing["description"].(string)
+			switch {
+			case kept == "":
+				existing["description"] = description
+			case description != "" && description != kept:
+				m.conflict(ConflictTag, name, serviceNames([]*service{owners[name], s}), "described differently, the description of %s is kept", owners[name].Name)
+			}
+		}
+	}
+	return tags
+}
+
+func (m *merger) info(opts Options) map[string]interface{} {
+	title, version := opts.Title, opts.Version
+	if title == "" {
+		var titles []string
+		for _, s := range m.services {
+			if s.Spec.Info != nil && s.Spec.Info.Title != "" {
+				titles = append(titles, s.Spec.Info.Title)
+			} else {
+				titles = append(titles, s.Name)
+			}
+		}
+		title = strings.Join(titles, ", ")
+	}
+	if version == "" {
+		for i, s := range m.services {
+			v := ""
+			if s.Spec.Info != nil {
+				v = s.Spec.Info.Version
+			}
+			if i == 0 {
+				version = v
+			} else if v != version {
+				version = ""
+				break
+			}
+		}
+		if version == "" {
+			version = "1.0.0"
+		}
+	}
+
+	info := map[string]interface{}{"title": title, "version": version}
+	if opts.Description != "" {
+		info["description"] = opts.Description
+	}
+	return info
+}
+
+func serviceNames(services []*service) []string {
+	names := make([]string, len(services))
+	for i, s := range services {
+		names[i] = s.Name
+	}
+	return names
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,317 @@
+package merge
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/spec"
+)
+
+// usersSpec and ordersSpec are the services of the tests, merged under /api
+const usersSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Users", "version": "1.0.0"},
+  "security": [{"ApiKey": []}],
+  "securityDefinitions": {"ApiKey": {"type": "apiKey", "in": "header", "name": "X-Api-Key"}},
+  "paths": {
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true, "type": "string"}],
+      "get": {"operationId": "getUser", "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/User"}}}}
+    },
+    "/health": {"get": {"operationId": "health", "responses": {"200": {"description": "OK"}}}}
+  },
+  "definitions": {
+    "User": {"type": "object", "properties": {"id": {"type": "string"}, "address": {"$ref": "#/definitions/Address"}}},
+    "Address": {"type": "object", "properties": {"city": {"type": "string"}}},
+    "Owner": {"type": "object", "properties": {"user": {"$ref": "#/definitions/User"}}},
+    "Node": {"type": "object", "properties": {"next": {"$ref": "#/definitions/Node"}}}
+  },
+  "tags": [{"name": "users", "description": "User accounts"}]
+}`
+
+const ordersSpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Orders", "version": "1.0.0"},
+  "security": [{"ApiKey": []}],
+  "securityDefinitions": {"ApiKey": {"type": "apiKey", "in": "header", "name": "X-Orders-Key"}},
+  "paths": {
+    "/users/{userId}": {
+      "get": {"operationId": "getCustomer", "responses": {"200": {"description": "OK"}}},
+      "delete": {"operationId": "deleteUser", "parameters": [{"name": "userId", "in": "path", "required": true, "type": "string"}], "responses": {"204": {"description": "No Content"}}}
+    },
+    "/orders/{id}": {
+      "get": {"operationId": "getUser", "responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/Owner"}}}}
+    },
+    "/health": {"get": {"operationId": "health", "responses": {"200": {"description": "OK"}}}}
+  },
+  "definitions": {
+    "User": {"type": "object", "properties": {"id": {"type": "integer"}, "address": {"$ref": "#/definitions/Address"}}},
+    "Address": {"type": "object", "properties": {"city": {"type": "string"}}},
+    "Owner": {"type": "object", "properties": {"user": {"$ref": "#/definitions/User"}}},
+    "Node": {"type": "object", "properties": {"next": {"$ref": "#/definitions/Node"}}}
+  },
+  "tags": [{"name": "users", "description": "Customers"}, {"name": "orders"}]
+}`
+
+func parseSpec(t *testing.T, document string) *spec.Swagger {
+	t.Helper()
+	var swagger spec.Swagger
+	if err := json.Unmarshal([]byte(document), &swagger); err != nil {
+		t.Fatal(err)
+	}
+	return &swagger
+}
+
+// refOf returns the $ref of a schema, e.g. of a definition
+func refOf(schema spec.Schema) string {
+	return schema.Ref.String()
+}
+
+func mergeServices(t *testing.T) Result {
+	t.Helper()
+	result, err := Merge([]Service{
+		{Name: "users", Prefix: "/api", Spec: parseSpec(t, usersSpec)},
+		{Name: "orders", Prefix: "/api", Spec: parseSpec(t, ordersSpec)},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestMergeConflicts(t *testing.T) {
+	result := mergeServices(t)
+
+	want := []Conflict{
+		{ConflictDefinition, "Owner", []string{"users", "orders"}, "2 different definitions, renamed users.Owner, orders.Owner"},
+		{ConflictDefinition, "User", []string{"users", "orders"}, "2 different definitions, renamed users.User, orders.User"},
+		{ConflictSecurityScheme, "ApiKey", []string{"users", "orders"}, "2 different security schemes, renamed users.ApiKey, orders.ApiKey"},
+		{ConflictOperationID, "getUser", []string{"users", "orders"}, "used by users and orders, renamed orders.getUser in orders"},
+		{ConflictPath, "/api/health", []string{"users", "orders"}, "claimed by users and orders, kept the GET of users"},
+		{ConflictPath, "/api/users/{id}", []string{"users", "orders"}, "claimed by users and orders, kept the GET of users, renamed {userId} to {id} in the DELETE of orders"},
+		{ConflictTag, "users", []string{"users", "orders"}, "described differently, the description of users is kept"},
+	}
+	if !reflect.DeepEqual(result.Conflicts, want) {
+		t.Errorf("conflicts:\n%v\nwant\n%v", result.Conflicts, want)
+	}
+	if paths := result.Of(ConflictPath); len(paths) != 2 {
+		t.Errorf("Of(ConflictPath) = %v", paths)
+	}
+}
+
+func TestMergeDefinitions(t *testing.T) {
+	swagger := mergeServices(t).Swagger
+
+	if got := strings.Join(sortedKeys(swagger.Definitions), ","); got != "Address,Node,orders.Owner,orders.User,users.Owner,users.User" {
+		t.Errorf("definitions = %s", got)
+	}
+
+	tests := []struct {
+		name string
+		ref  string
+		want string
+	}{
+		{"renamed definition", swagger.Paths.Paths["/api/users/{id}"].Get.Responses.StatusCodeResponses[200].Schema.Ref.String(), "#/definitions/users.User"},
+		{"reference in a renamed definition", refOf(swagger.Definitions["orders.Owner"].Properties["user"]), "#/definitions/orders.User"},
+		{"shared definition", refOf(swagger.Definitions["orders.User"].Properties["address"]), "#/definitions/Address"},
+		{"recursive shared definition", refOf(swagger.Definitions["Node"].Properties["next"]), "#/definitions/Node"},
+		{"operation of the second service", swagger.Paths.Paths["/api/orders/{id}"].Get.Responses.StatusCodeResponses[200].Schema.Ref.String(), "#/definitions/orders.Owner"},
+	}
+	for _, tt := range tests {
+		if tt.ref != tt.want {
+			t.Errorf("%s: $ref = %q, want %q", tt.name, tt.ref, tt.want)
+		}
+	}
+}
+
+func TestMergeKeywordPropertyNames(t *testing.T) {
+	// Order is the same in both services but references their own Item
+	// through properties named like the keywords walkRefs skips
+	document := func(itemType string) string {
+		return `{
+  "swagger": "2.0",
+  "info": {"title": "Shop", "version": "1.0.0"},
+  "paths": {},
+  "definitions": {
+    "Item": {"type": "` + itemType + `"},
+    "Order": {"type": "object", "properties": {
+      "example": {"$ref": "#/definitions/Item"},
+      "x-item": {"$ref": "#/definitions/Item"}
+    }}
+  }
+}`
+	}
+	result, err := Merge([]Service{
+		{Name: "a", Spec: parseSpec(t, document("string"))},
+		{Name: "b", Spec: parseSpec(t, document("integer"))},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	definitions := result.Swagger.Definitions
+	if got := strings.Join(sortedKeys(definitions), ","); got != "a.Item,a.Order,b.Item,b.Order" {
+		t.Fatalf("definitions = %s, want Order namespaced like the Item it references", got)
+	}
+	for _, property := range []string{"example", "x-item"} {
+		if got := refOf(definitions["b.Order"].Properties[property]); got != "#/definitions/b.Item" {
+			t.Errorf("b.Order.%s $ref = %q, want #/definitions/b.Item", property, got)
+		}
+	}
+}
+
+func TestMergePaths(t *testing.T) {
+	swagger := mergeServices(t).Swagger
+
+	if got := strings.Join(sortedKeys(swagger.Paths.Paths), ","); got != "/api/health,/api/orders/{id},/api/users/{id}" {
+		t.Errorf("paths = %s", got)
+	}
+
+	item := swagger.Paths.Paths["/api/users/{id}"]
+	tests := []struct {
+		name      string
+		operation *spec.Operation
+		id        string
+		params    string // "in name" of the parameters
+		security  string // the scheme required
+	}{
+		{"kept operation", item.Get, "getUser", "path id", "users.ApiKey"},
+		{"operation merged in", item.Delete, "deleteUser", "path id", "orders.ApiKey"},
+		{"renamed operationId", swagger.Paths.Paths["/api/orders/{id}"].Get, "orders.getUser", "", "orders.ApiKey"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.operation == nil {
+				t.Fatal("operation missing")
+			}
+			if tt.operation.ID != tt.id {
+				t.Errorf("operationId = %q, want %q", tt.operation.ID, tt.id)
+			}
+			var params []string
+			for _, param := range tt.operation.Parameters {
+				params = append(params, param.In+" "+param.Name)
+			}
+			if got := strings.Join(params, ","); got != tt.params {
+				t.Errorf("parameters = %q, want %q", got, tt.params)
+			}
+			if len(tt.operation.Security) != 1 || tt.operation.Security[0][tt.security] == nil {
+				t.Errorf("security = %v, want %s", tt.operation.Security, tt.security)
+			}
+		})
+	}
+
+	if len(item.Parameters) != 0 || len(swagger.Security) != 0 {
+		t.Error("path item or top level settings kept, they must be pushed down to the operations")
+	}
+	if got := swagger.SecurityDefinitions["orders.ApiKey"]; got == nil || got.Name != "X-Orders-Key" {
+		t.Errorf("orders.ApiKey = %+v", got)
+	}
+}
+
+func TestMergeSharedParameterRenamed(t *testing.T) {
+	orders := parseSpec(t, `{
+  "swagger": "2.0",
+  "info": {"title": "Orders", "version": "1.0.0"},
+  "parameters": {"userId": {"name": "userId", "in": "path", "required": true, "type": "string"}},
+  "paths": {"/users/{userId}": {"delete": {"parameters": [{"$ref": "#/parameters/userId"}], "responses": {"204": {"description": "No Content"}}}}}
+}`)
+	result, err := Merge([]Service{
+		{Name: "users", Spec: parseSpec(t, usersSpec)},
+		{Name: "orders", Spec: orders},
+	}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := result.Swagger.Paths.Paths["/users/{id}"].Delete.Parameters
+	if len(params) != 1 || params[0].Name != "id" || params[0].Ref.String() != "" {
+		t.Errorf("parameters = %+v, want a copy named id", params)
+	}
+	if shared := result.Swagger.Parameters["userId"]; shared.Name != "userId" {
+		t.Errorf("shared parameter renamed in place: %+v", shared)
+	}
+}
+
+func TestMergeInfo(t *testing.T) {
+	users, orders := parseSpec(t, usersSpec), parseSpec(t, ordersSpec)
+	tests := []struct {
+		name           string
+		opts           Options
+		ordersVersion  string
+		title, version string
+	}{
+		{"joined", Options{}, "1.0.0", "Users, Orders", "1.0.0"},
+		{"versions differ", Options{}, "2.1.0", "Users, Orders", "1.0.0"},
+		{"options", Options{Title: "Gateway", Version: "3.0.0", Description: "All services"}, "2.1.0", "Gateway", "3.0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders.Info.Version = tt.ordersVersion
+			result, err := Merge([]Service{{Name: "users", Spec: users}, {Name: "orders", Spec: orders}}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := result.Swagger.Info
+			if info.Title != tt.title || info.Version != tt.version || info.Description != tt.opts.Description {
+				t.Errorf("info = %q %q %q, want %q %q", info.Title, info.Version, info.Description, tt.title, tt.version)
+			}
+		})
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	users := parseSpec(t, usersSpec)
+	tests := []struct {
+		name     string
+		services []Service
+		wantErr  string
+	}{
+		{"nothing", nil, "no spec to merge"},
+		{"no name", []Service{{Spec: users}}, "every service needs a name"},
+		{"same name", []Service{{Name: "users", Spec: users}, {Name: "users", Spec: users}}, "service users is given twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Merge(tt.services, Options{})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParamRenames(t *testing.T) {
+	tests := []struct {
+		path, kept string
+		want       map[string]string
+	}{
+		{"/users/{id}", "/users/{id}", nil},
+		{"/users/{userId}", "/users/{id}", map[string]string{"userId": "id"}},
+		{"/users/{userId}/orders/{id}", "/users/{id}/orders/{orderId}", map[string]string{"userId": "id", "id": "orderId"}},
+		{"/users/{id}/orders/{orderId}", "/users/{id}/orders/{order}", map[string]string{"orderId": "order"}},
+	}
+	for _, tt := range tests {
+		if got := paramRenames(tt.path, tt.kept); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("paramRenames(%q, %q) = %v, want %v", tt.path, tt.kept, got, tt.want)
+		}
+	}
+}
+
+func TestJoinPaths(t *testing.T) {
+	tests := []struct {
+		parts []string
+		want  string
+	}{
+		{[]string{"/users", "/v1", "/{id}"}, "/users/v1/{id}"},
+		{[]string{"", "", "/health"}, "/health"},
+		{[]string{"users/", "/", "/"}, "/users"},
+		{[]string{"", "", "/"}, "/"},
+	}
+	for _, tt := range tests {
+		if got := joinPaths(tt.parts...); got != tt.want {
+			t.Errorf("joinPaths(%q) = %q, want %q", tt.parts, got, tt.want)
+		}
+	}
+}
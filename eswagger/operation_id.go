@@ -0,0 +1,135 @@
+package eswagger
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// handlerOperationName derives an operation name from the full function name
+// of a handler:
+//
+//	main/pkg/service.CreateUser.func1   -> CreateUser
+//	main/pkg/api.(*Server).GetUser-fm   -> GetUser
+//	main.healthz                        -> healthz
+//	main.main.func3                     -> "" (inline closure)
+//
+// A closure is named after its enclosing function only when that function is
+// exported, an unexported one such as main or setupRoutes says nothing about
+// the operation.
+func handlerOperationName(fullName string) string {
+	name := fullName[strings.LastIndex(fullName, "/")+1:]
+	if i := strings.Index(name, "["); i >= 0 {
+		// Generic instantiation, e.g. main.handle[...]
+		name = name[:i] + name[strings.LastIndex(name, "]")+1:]
+	}
+
+	parts := strings.Split(name, ".")
+	if len(parts) < 2 {
+		return ""
+	}
+	parts = parts[1:] // package
+
+	last := len(parts) - 1
+	closure := false
+	for last >= 0 && isClosureName(parts[last]) {
+		last--
+		closure = true
+	}
+	if last < 0 {
+		return ""
+	}
+
+	operation := strings.TrimSuffix(parts[last], "-fm")
+	if operation == "" || strings.HasPrefix(operation, "(") {
+		return ""
+	}
+	if closure && !unicode.IsUpper([]rune(operation)[0]) {
+		return ""
+	}
+	return operation
+}
+
+// isClosureName matches the "func1" and "2" parts naming closures
+func isClosureName(part string) bool {
+	part = strings.TrimPrefix(part, "func")
+	if part == "" {
+		return false
+	}
+	_, err := strconv.Atoi(part)
+	return err == nil
+}
+
+// pathOperationID is the fallback operationId built from the method and the
+// path, e.g. GET /users/{id}/orders -> getUsersByIdOrders
+func pathOperationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			b.WriteString("By")
+			segment = segment[1 : len(segment)-1]
+		}
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			runes := []rune(word)
+			b.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+		}
+	}
+	return b.String()
+}
+
+// assignOperationIDs makes the operationIds of the spec unique. Ids given in
+// the metadata are kept as they are, derived ones that collide fall back to
+// the method and path, then get a numeric suffix. Operations are visited in
+// path and method order so the ids don't depend on route registration order.
+func (g *Generator) assignOperationIDs(explicit map[string]bool) {
+	paths := make([]string, 0, len(g.swagger.Paths.Paths))
+	for path := range g.swagger.Paths.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	used := make(map[string]bool)
+	for key := range explicit {
+		method, path, _ := strings.Cut(key, " ")
+		if operation := operationsOf(g.swagger.Paths.Paths[path])[method]; operation != nil {
+			used[operation.ID] = true
+		}
+	}
+
+	for _, path := range paths {
+		operations := operationsOf(g.swagger.Paths.Paths[path])
+		for _, method := range sortedMethods(operations) {
+			if explicit[method+" "+path] {
+				continue
+			}
+			operation := operations[method]
+
+			id := operation.ID
+			if id == "" || used[id] {
+				id = pathOperationID(method, path)
+			}
+			for n := 2; used[id]; n++ {
+				id = pathOperationID(method, path) + strconv.Itoa(n)
+			}
+
+			operation.ID = id
+			used[id] = true
+		}
+	}
+}
+
+// OperationID returns the operationId of the documented operation serving
+// the request, or an empty string. It is meant as a stable key for metrics
+// and tracing.
+func (g *Generator) OperationID(r *http.Request) string {
+	if match := g.findOperation(r); match != nil {
+		return match.Operation.ID
+	}
+	return ""
+}
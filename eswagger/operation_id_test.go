@@ -0,0 +1,161 @@
+package eswagger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHandlerOperationName(t *testing.T) {
+	tests := []struct {
+		fullName string
+		want     string
+	}{
+		{"main/pkg/service.CreateUser.func1", "CreateUser"},
+		{"main/pkg/api.(*Server).GetUser-fm", "GetUser"},
+		{"main.healthz", "healthz"},
+		{"main.main.func3", ""},
+		{"main.setupRoutes.func2.1", ""},
+		{"main/pkg/api.Routes.func1.2", "Routes"},
+		{"main/pkg/api.handle[...].func1", ""},
+		{"main/pkg/api.List[...]", "List"},
+		{"noPackage", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fullName, func(t *testing.T) {
+			if got := handlerOperationName(tt.fullName); got != tt.want {
+				t.Errorf("handlerOperationName(%q) = %q, want %q", tt.fullName, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathOperationID(t *testing.T) {
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{"GET", "/users", "getUsers"},
+		{"GET", "/users/{id}/orders", "getUsersByIdOrders"},
+		{"DELETE", "/user-groups/{group_id}", "deleteUserGroupsByGroupId"},
+		{"POST", "/", "post"},
+	}
+	for _, tt := range tests {
+		if got := pathOperationID(tt.method, tt.path); got != tt.want {
+			t.Errorf("pathOperationID(%q, %q) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func ListUsers(http.ResponseWriter, *http.Request) {}
+
+// noopHandler returns a closure of an unexported function, which names no
+// operation
+func noopHandler() http.HandlerFunc {
+	return func(http.ResponseWriter, *http.Request) {}
+}
+
+func TestAssignOperationIDs(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users", ListUsers).Methods("GET")
+	router.HandleFunc("/admin/users", ListUsers).Methods("GET")
+	router.HandleFunc("/v2/users", ListUsers).Methods("GET")
+	router.HandleFunc("/orders", noopHandler()).Methods("GET", "POST")
+	router.HandleFunc("/orders/{id}", noopHandler()).Methods("GET")
+
+	var metadata RouteMetadata
+	metadata.Set("GET", "/v2/users", EndpointMetadata{OperationID: "ListUsers"})
+	metadata.Set("POST", "/orders", EndpointMetadata{OperationID: "getOrdersById"})
+
+	// registration order doesn't matter, only paths and methods do
+	for _, order := range []string{"registered", "reversed"} {
+		t.Run(order, func(t *testing.T) {
+			source := MuxRoutes(router)
+			if order == "reversed" {
+				source = reversedRoutes{source}
+			}
+			g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+			diagnostics, err := g.GenerateFromRoutes(source, metadata)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if duplicates := diagnostics.Of(DiagDuplicateOperationID); len(duplicates) > 0 {
+				t.Errorf("duplicates reported: %v", duplicates)
+			}
+
+			paths := g.GetSwaggerSpec().Paths.Paths
+			tests := []struct {
+				method, path string
+				want         string
+			}{
+				{"GET", "/v2/users", "ListUsers"},         // explicit
+				{"GET", "/admin/users", "getAdminUsers"},  // taken by the explicit id
+				{"GET", "/users", "getUsers"},             // taken as well
+				{"GET", "/orders", "getOrders"},           // closure
+				{"POST", "/orders", "getOrdersById"},      // explicit, kept even if odd
+				{"GET", "/orders/{id}", "getOrdersById2"}, // suffixed
+			}
+			for _, tt := range tests {
+				if got := operationsOf(paths[tt.path])[tt.method].ID; got != tt.want {
+					t.Errorf("%s %s operationId = %q, want %q", tt.method, tt.path, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicateExplicitOperationIDs(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users", ListUsers).Methods("GET")
+	router.HandleFunc("/admins", ListUsers).Methods("GET")
+
+	var metadata RouteMetadata
+	metadata.Set("GET", "/users", EndpointMetadata{OperationID: "list"})
+	metadata.Set("GET", "/admins", EndpointMetadata{OperationID: "list"})
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	diagnostics, err := g.GenerateFromRouter(router, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if duplicates := diagnostics.Of(DiagDuplicateOperationID); len(duplicates) != 1 {
+		t.Errorf("duplicates = %v, want GET /users reported", duplicates)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users", ListUsers).Methods("GET")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, target string
+		want           string
+	}{
+		{"GET", "/users", "ListUsers"},
+		{"POST", "/users", ""},
+		{"GET", "/orders", ""},
+	}
+	for _, tt := range tests {
+		if got := g.OperationID(httptest.NewRequest(tt.method, tt.target, nil)); got != tt.want {
+			t.Errorf("OperationID(%s %s) = %q, want %q", tt.method, tt.target, got, tt.want)
+		}
+	}
+}
+
+// reversedRoutes lists the routes of a source backwards
+type reversedRoutes struct{ source RouteSource }
+
+func (r reversedRoutes) Routes() ([]Route, error) {
+	routes, err := r.source.Routes()
+	for i, j := 0, len(routes)-1; i < j; i, j = i+1, j-1 {
+		routes[i], routes[j] = routes[j], routes[i]
+	}
+	return routes, err
+}
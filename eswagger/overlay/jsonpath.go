@@ -0,0 +1,810 @@
+package overlay
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// query is a compiled JSONPath expression as defined by RFC 9535, e.g.
+// "$.paths.*[?@.x-internal == true]". Member names of the dot notation may
+// contain dashes so extensions can be written "@.x-internal".
+type query struct {
+	source   string
+	relative bool // starts with @, only inside filters
+	segments []segment
+}
+
+type segment struct {
+	descendant bool // ".." selects from the node and all its descendants
+	selectors  []selector
+}
+
+// node is a value of the document with its location, the member names and
+// array indexes leading to it from the root
+type node struct {
+	value    interface{}
+	location location
+}
+
+type location []interface{}
+
+func (l location) child(key interface{}) location {
+	child := make(location, len(l), len(l)+1)
+	copy(child, l)
+	return append(child, key)
+}
+
+// String returns the location as a normalized path, e.g. $['paths']['/users']
+func (l location) String() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, key := range l {
+		if name, ok := key.(string); ok {
+			fmt.Fprintf(&b, "[%s]", strconv.Quote(name))
+		} else {
+			fmt.Fprintf(&b, "[%d]", key)
+		}
+	}
+	return b.String()
+}
+
+type selector interface {
+	// selectFrom appends the children of n it selects to out
+	selectFrom(n node, root interface{}, out []node) []node
+}
+
+// compileQuery parses an absolute JSONPath expression
+func compileQuery(source string) (*query, error) {
+	p := &parser{src: source}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSONPath %q: %v", source, err)
+	}
+	if q.relative {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", source)
+	}
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q at offset %d", source, p.src[p.pos:], p.pos)
+	}
+	return q, nil
+}
+
+// selectNodes evaluates the query, current is the value @ refers to
+func (q *query) selectNodes(root, current interface{}) []node {
+	start := root
+	if q.relative {
+		start = current
+	}
+	nodes := []node{{value: start}}
+	for _, seg := range q.segments {
+		var selected []node
+		for _, n := range nodes {
+			targets := []node{n}
+			if seg.descendant {
+				targets = descendants(n, nil)
+			}
+			for _, target := range targets {
+				for _, sel := range seg.selectors {
+					selected = sel.selectFrom(target, root, selected)
+				}
+			}
+		}
+		nodes = selected
+	}
+	return nodes
+}
+
+// descendants appends n and the values nested in it, in document order
+func descendants(n node, out []node) []node {
+	out = append(out, n)
+	for _, child := range children(n) {
+		out = descendants(child, out)
+	}
+	return out
+}
+
+// children returns the members of an object, by name, or the items of an array
+func children(n node) []node {
+	switch v := n.value.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		nodes := make([]node, len(names))
+		for i, name := range names {
+			nodes[i] = node{v[name], n.location.child(name)}
+		}
+		return nodes
+	case []interface{}:
+		nodes := make([]node, len(v))
+		for i, item := range v {
+			nodes[i] = node{item, n.location.child(i)}
+		}
+		return nodes
+	}
+	return nil
+}
+
+type nameSelector string
+
+func (s nameSelector) selectFrom(n node, root interface{}, out []node) []node {
+	if object, ok := n.value.(map[string]interface{}); ok {
+		if value, found := object[string(s)]; found {
+			out = append(out, node{value, n.location.child(string(s))})
+		}
+	}
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(n node, root interface{}, out []node) []node {
+	return append(out, children(n)...)
+}
+
+type indexSelector int
+
+func (s indexSelector) selectFrom(n node, root interface{}, out []node) []node {
+	array, ok := n.value.([]interface{})
+	if !ok {
+		return out
+	}
+	i := int(s)
+	if i < 0 {
+		i += len(array)
+	}
+	if i >= 0 && i < len(array) {
+		out = append(out, node{array[i], n.location.child(i)})
+	}
+	return out
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(n node, root interface{}, out []node) []node {
+	array, ok := n.value.([]interface{})
+	if !ok || s.step == 0 {
+		return out
+	}
+	length := len(array)
+	normalize := func(i int) int {
+		if i < 0 {
+			return i + length
+		}
+		return i
+	}
+	clamp := func(i, low, high int) int {
+		return max(low, min(i, high))
+	}
+
+	if s.step > 0 {
+		start, end := 0, length
+		if s.start != nil {
+			start = clamp(normalize(*s.start), 0, length)
+		}
+		if s.end != nil {
+			end = clamp(normalize(*s.end), 0, length)
+		}
+		for i := start; i < end; i += s.step {
+			out = append(out, node{array[i], n.location.child(i)})
+		}
+		return out
+	}
+
+	start, end := length-1, -1
+	if s.start != nil {
+		start = clamp(normalize(*s.start), -1, length-1)
+	}
+	if s.end != nil {
+		end = clamp(normalize(*s.end), -1, length-1)
+	}
+	for i := start; i > end; i += s.step {
+		out = append(out, node{array[i], n.location.child(i)})
+	}
+	return out
+}
+
+type filterSelector struct {
+	expr logicalExpr
+}
+
+func (s filterSelector) selectFrom(n node, root interface{}, out []node) []node {
+	for _, child := range children(n) {
+		if s.expr.test(child.value, root) {
+			out = append(out, child)
+		}
+	}
+	return out
+}
+
+// Filter expressions
+
+type logicalExpr interface {
+	test(current, root interface{}) bool
+}
+
+type orExpr []logicalExpr
+
+func (e orExpr) test(current, root interface{}) bool {
+	for _, expr := range e {
+		if expr.test(current, root) {
+			return true
+		}
+	}
+	return false
+}
+
+type andExpr []logicalExpr
+
+func (e andExpr) test(current, root interface{}) bool {
+	for _, expr := range e {
+		if !expr.test(current, root) {
+			return false
+		}
+	}
+	return true
+}
+
+type notExpr struct {
+	expr logicalExpr
+}
+
+func (e notExpr) test(current, root interface{}) bool {
+	return !e.expr.test(current, root)
+}
+
+// existsExpr is a query used as a test, true when it selects something
+type existsExpr struct {
+	query *query
+}
+
+func (e existsExpr) test(current, root interface{}) bool {
+	return len(e.query.selectNodes(root, current)) > 0
+}
+
+type comparisonExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e comparisonExpr) test(current, root interface{}) bool {
+	left, leftOK := e.left.value(current, root)
+	right, rightOK := e.right.value(current, root)
+
+	switch e.op {
+	case "==":
+		return equal(left, leftOK, right, rightOK)
+	case "!=":
+		return !equal(left, leftOK, right, rightOK)
+	case "<":
+		return less(left, leftOK, right, rightOK)
+	case ">":
+		return less(right, rightOK, left, leftOK)
+	case "<=":
+		return less(left, leftOK, right, rightOK) || equal(left, leftOK, right, rightOK)
+	case ">=":
+		return less(right, rightOK, left, leftOK) || equal(left, leftOK, right, rightOK)
+	}
+	return false
+}
+
+// equal compares two values, ok is false for Nothing, the result of a query
+// selecting no single value. Two Nothings are equal.
+func equal(a interface{}, aOK bool, b interface{}, bOK bool) bool {
+	if !aOK || !bOK {
+		return aOK == bOK
+	}
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func less(a interface{}, aOK bool, b interface{}, bOK bool) bool {
+	if !aOK || !bOK {
+		return false
+	}
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x < y
+	}
+	x, ok := a.(string)
+	y, ok2 := b.(string)
+	return ok && ok2 && x < y
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// operand is an operand of a comparison or a function argument
+type operand interface {
+	value(current, root interface{}) (interface{}, bool)
+}
+
+type literal struct {
+	v interface{}
+}
+
+func (l literal) value(current, root interface{}) (interface{}, bool) {
+	return l.v, true
+}
+
+// singularQuery is a query used as a value, Nothing unless it selects
+// exactly one value
+type singularQuery struct {
+	query *query
+}
+
+func (q singularQuery) value(current, root interface{}) (interface{}, bool) {
+	nodes := q.query.selectNodes(root, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].value, true
+}
+
+// functionCall is one of the functions of RFC 9535: length, count, value,
+// match and search
+type functionCall struct {
+	name string
+	args []operand
+}
+
+func (f functionCall) value(current, root interface{}) (interface{}, bool) {
+	switch f.name {
+	case "length":
+		arg, ok := f.args[0].value(current, root)
+		if !ok {
+			return nil, false
+		}
+		switch v := arg.(type) {
+		case string:
+			return float64(utf8.RuneCountInString(v)), true
+		case []interface{}:
+			return float64(len(v)), true
+		case map[string]interface{}:
+			return float64(len(v)), true
+		}
+		return nil, false
+	case "count":
+		q := f.args[0].(singularQuery).query
+		return float64(len(q.selectNodes(root, current))), true
+	case "value":
+		return f.args[0].value(current, root)
+	}
+	return nil, false
+}
+
+func (f functionCall) test(current, root interface{}) bool {
+	subject, ok := f.args[0].value(current, root)
+	pattern, ok2 := f.args[1].value(current, root)
+	s, isString := subject.(string)
+	expr, isPattern := pattern.(string)
+	if !ok || !ok2 || !isString || !isPattern {
+		return false
+	}
+	if f.name == "match" {
+		expr = "^(?:" + expr + ")$"
+	}
+	re, err := regexp.Compile(expr)
+	return err == nil && re.MatchString(s)
+}
+
+// Parser
+
+type parser struct {
+	src string
+	pos int
+}
+
+func (p *parser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *parser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\n\r", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at offset %d", fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *parser) parseQuery() (*query, error) {
+	q := &query{source: p.src}
+	switch {
+	case p.consume("$"):
+	case p.consume("@"):
+		q.relative = true
+	default:
+		return nil, p.errorf("expected $ or @")
+	}
+
+	for {
+		var seg segment
+		switch {
+		case p.consume(".."):
+			seg.descendant = true
+			if p.peek() == '[' {
+				selectors, err := p.parseBracket()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = selectors
+			} else {
+				sel, err := p.parseDotSelector()
+				if err != nil {
+					return nil, err
+				}
+				seg.selectors = []selector{sel}
+			}
+		case p.consume("."):
+			sel, err := p.parseDotSelector()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = []selector{sel}
+		case p.peek() == '[':
+			selectors, err := p.parseBracket()
+			if err != nil {
+				return nil, err
+			}
+			seg.selectors = selectors
+		default:
+			return q, nil
+		}
+		q.segments = append(q.segments, seg)
+	}
+}
+
+func (p *parser) parseDotSelector() (selector, error) {
+	if p.consume("*") {
+		return wildcardSelector{}, nil
+	}
+	name := p.parseName()
+	if name == "" {
+		return nil, p.errorf("expected a member name or *")
+	}
+	return nameSelector(name), nil
+}
+
+func (p *parser) parseName() string {
+	start := p.pos
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c >= 0x80 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+func (p *parser) parseBracket() ([]selector, error) {
+	p.pos++ // [
+	var selectors []selector
+	for {
+		p.skipSpace()
+		sel, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, sel)
+		p.skipSpace()
+		if p.consume("]") {
+			return selectors, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or ]")
+		}
+	}
+}
+
+func (p *parser) parseSelector() (selector, error) {
+	switch c := p.peek(); {
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return nameSelector(s), nil
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '?':
+		p.pos++
+		p.skipSpace()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return filterSelector{expr}, nil
+	case c == '-' || c == ':' || c >= '0' && c <= '9':
+		return p.parseIndexOrSlice()
+	}
+	return nil, p.errorf("expected a selector")
+}
+
+func (p *parser) parseInt() (*int, error) {
+	start := p.pos
+	p.consume("-")
+	for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(p.src[start:p.pos])
+	if err != nil {
+		return nil, p.errorf("invalid integer %q", p.src[start:p.pos])
+	}
+	return &i, nil
+}
+
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	start, err := p.parseInt()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.consume(":") {
+		if start == nil {
+			return nil, p.errorf("expected an index")
+		}
+		return indexSelector(*start), nil
+	}
+
+	s := sliceSelector{start: start, step: 1}
+	p.skipSpace()
+	if s.end, err = p.parseInt(); err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.consume(":") {
+		p.skipSpace()
+		step, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		if step != nil {
+			s.step = *step
+		}
+	}
+	return s, nil
+}
+
+func (p *parser) parseString() (string, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch escaped := p.src[p.pos]; escaped {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'u':
+				if p.pos+4 >= len(p.src) {
+					return "", p.errorf("invalid escape")
+				}
+				r, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid escape")
+				}
+				b.WriteRune(rune(r))
+				p.pos += 4
+			default:
+				b.WriteByte(escaped)
+			}
+			p.pos++
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) parseOr() (logicalExpr, error) {
+	var operands orExpr
+	for {
+		and, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, and)
+		p.skipSpace()
+		if !p.consume("||") {
+			break
+		}
+		p.skipSpace()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+func (p *parser) parseAnd() (logicalExpr, error) {
+	var operands andExpr
+	for {
+		unary, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, unary)
+		p.skipSpace()
+		if !p.consume("&&") {
+			break
+		}
+		p.skipSpace()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return operands, nil
+}
+
+var comparisonOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func (p *parser) parseUnary() (logicalExpr, error) {
+	p.skipSpace()
+	if p.consume("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{expr}, nil
+	}
+	if p.consume("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		if !p.consume(")") {
+			return nil, p.errorf("expected )")
+		}
+		return expr, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	for _, op := range comparisonOperators {
+		if !p.consume(op) {
+			continue
+		}
+		p.skipSpace()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return comparisonExpr{op, left, right}, nil
+	}
+
+	switch left := left.(type) {
+	case singularQuery:
+		return existsExpr{left.query}, nil
+	case functionCall:
+		if left.name == "match" || left.name == "search" {
+			return left, nil
+		}
+	}
+	return nil, p.errorf("expected a comparison")
+}
+
+func (p *parser) parseOperand() (operand, error) {
+	switch c := p.peek(); {
+	case c == '@' || c == '$':
+		q, err := p.parseQuery()
+		if err != nil {
+			return nil, err
+		}
+		return singularQuery{q}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+		return literal{s}, nil
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.consume("-")
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil || math.IsInf(f, 0) {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return literal{f}, nil
+	case p.consume("true"):
+		return literal{true}, nil
+	case p.consume("false"):
+		return literal{false}, nil
+	case p.consume("null"):
+		return literal{nil}, nil
+	}
+
+	name := p.parseName()
+	if !p.consume("(") {
+		return nil, p.errorf("expected a query, a literal or a function")
+	}
+	arity := map[string]int{"length": 1, "count": 1, "value": 1, "match": 2, "search": 2}
+	if _, ok := arity[name]; !ok {
+		return nil, p.errorf("unknown function %q", name)
+	}
+	call := functionCall{name: name}
+	for {
+		p.skipSpace()
+		arg, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		call.args = append(call.args, arg)
+		p.skipSpace()
+		if p.consume(")") {
+			break
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or )")
+		}
+	}
+	if len(call.args) != arity[name] {
+		return nil, p.errorf("%s takes %d arguments", name, arity[name])
+	}
+	if _, ok := call.args[0].(singularQuery); !ok && (name == "count" || name == "value") {
+		return nil, p.errorf("%s takes a query", name)
+	}
+	return call, nil
+}
@@ -0,0 +1,305 @@
+// Package overlay applies OpenAPI Overlay documents
+// (https://spec.openapis.org/overlay/v1.0.0.html) to a spec: JSONPath
+// targeted updates and removals, e.g. to rename tags, hide internal fields or
+// rewrite descriptions for one audience without touching the generator.
+//
+//	overlay: 1.0.0
+//	info:
+//	  title: Public API
+//	  version: 1.0.0
+//	actions:
+//	  - target: $.paths.*[?@.x-internal == true]
+//	    remove: true
+//	  - target: $.info
+//	    update:
+//	      description: The API of our partners
+package overlay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Overlay is an Overlay document, its actions are applied in order
+type Overlay struct {
+	Overlay string   `json:"overlay"` // version of the Overlay specification, 1.x
+	Info    Info     `json:"info"`
+	Extends string   `json:"extends,omitempty"` // URL of the document it was written for, informative
+	Actions []Action `json:"actions"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Action changes the nodes selected by Target, a JSONPath expression. Update
+// is merged into the objects selected and appended to the arrays, a scalar
+// is replaced by it. Remove deletes the nodes and wins over Update.
+type Action struct {
+	Target      string      `json:"target"`
+	Description string      `json:"description,omitempty"`
+	Update      interface{} `json:"update,omitempty"`
+	Remove      bool        `json:"remove,omitempty"`
+}
+
+// Load reads an Overlay from a YAML or JSON file
+func Load(path string) (*Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading overlay: %v", err)
+	}
+	o, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return o, nil
+}
+
+// Parse decodes and checks a YAML or JSON Overlay document
+func Parse(data []byte) (*Overlay, error) {
+	// Through YAML and back to JSON so the update values have the types of a
+	// decoded JSON document, e.g. float64 numbers
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("error parsing overlay: %v", err)
+	}
+	encoded, err := json.Marshal(jsonValue(document))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing overlay: %v", err)
+	}
+	o := new(Overlay)
+	if err := json.Unmarshal(encoded, o); err != nil {
+		return nil, fmt.Errorf("error parsing overlay: %v", err)
+	}
+	return o, o.Validate()
+}
+
+// Validate checks the version, the info and the actions of the overlay
+func (o *Overlay) Validate() error {
+	var errs []error
+	if major, _, _ := strings.Cut(o.Overlay, "."); major != "1" {
+		errs = append(errs, fmt.Errorf("unsupported overlay version %q, expected 1.x", o.Overlay))
+	}
+	if o.Info.Title == "" || o.Info.Version == "" {
+		errs = append(errs, errors.New("info.title and info.version are required"))
+	}
+	if len(o.Actions) == 0 {
+		errs = append(errs, errors.New("overlay has no actions"))
+	}
+	for i, action := range o.Actions {
+		if _, err := compileQuery(action.Target); err != nil {
+			errs = append(errs, fmt.Errorf("action %d: %v", i, err))
+		}
+		if !action.Remove && action.Update == nil {
+			errs = append(errs, fmt.Errorf("action %d: neither update nor remove is set", i))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Name identifies the overlay in messages
+func (o *Overlay) Name() string {
+	if o.Info.Title == "" {
+		return "overlay"
+	}
+	return fmt.Sprintf("overlay %q", o.Info.Title)
+}
+
+// Apply runs the actions in order on a document decoded from JSON, which is
+// modified in place. The actions whose target selects nothing are returned,
+// they usually mean the overlay no longer matches the spec.
+func (o *Overlay) Apply(document map[string]interface{}) ([]Action, error) {
+	var unmatched []Action
+	for i, action := range o.Actions {
+		q, err := compileQuery(action.Target)
+		if err != nil {
+			return unmatched, fmt.Errorf("%s: action %d: %v", o.Name(), i, err)
+		}
+
+		nodes := q.selectNodes(document, document)
+		if len(nodes) == 0 {
+			unmatched = append(unmatched, action)
+			continue
+		}
+
+		locations := make([]location, len(nodes))
+		for i, n := range nodes {
+			locations[i] = n.location
+		}
+		if action.Remove {
+			err = remove(document, locations)
+		} else {
+			err = update(document, locations, action.Update)
+		}
+		if err != nil {
+			return unmatched, fmt.Errorf("%s: action %d (%s): %v", o.Name(), i, action.Target, err)
+		}
+	}
+	return unmatched, nil
+}
+
+func remove(document map[string]interface{}, locations []location) error {
+	// From the last array index to the first so the others stay valid, and
+	// from the descendants to their ancestors
+	sort.Slice(locations, func(i, j int) bool { return compareLocations(locations[i], locations[j]) > 0 })
+	for _, loc := range locations {
+		if len(loc) == 0 {
+			return errors.New("the document itself can't be removed")
+		}
+		parent, ok := get(document, loc[:len(loc)-1])
+		if !ok {
+			continue // removed with an ancestor
+		}
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			delete(p, loc[len(loc)-1].(string))
+		case []interface{}:
+			i := loc[len(loc)-1].(int)
+			if i >= len(p) {
+				continue
+			}
+			set(document, loc[:len(loc)-1], append(p[:i:i], p[i+1:]...))
+		}
+	}
+	return nil
+}
+
+func update(document map[string]interface{}, locations []location, value interface{}) error {
+	for _, loc := range locations {
+		target, ok := get(document, loc)
+		if !ok {
+			continue
+		}
+		switch t := target.(type) {
+		case map[string]interface{}:
+			fields, ok := value.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s is an object, the update must be one too", loc)
+			}
+			merge(t, fields)
+		case []interface{}:
+			set(document, loc, append(t, copyValue(value)))
+		default:
+			if len(loc) == 0 {
+				return errors.New("the document must be an object")
+			}
+			set(document, loc, copyValue(value))
+		}
+	}
+	return nil
+}
+
+// merge sets the fields of update on target, objects are merged recursively
+func merge(target, update map[string]interface{}) {
+	for key, value := range update {
+		if object, ok := target[key].(map[string]interface{}); ok {
+			if fields, ok := value.(map[string]interface{}); ok {
+				merge(object, fields)
+				continue
+			}
+		}
+		target[key] = copyValue(value)
+	}
+}
+
+// copyValue deep copies a value so targets never share the update
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = copyValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = copyValue(item)
+		}
+		return copied
+	}
+	return value
+}
+
+func get(document interface{}, loc location) (interface{}, bool) {
+	value := document
+	for _, key := range loc {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			name, _ := key.(string)
+			next, ok := v[name]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, ok := key.(int)
+			if !ok || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func set(document interface{}, loc location, value interface{}) {
+	parent, ok := get(document, loc[:len(loc)-1])
+	if !ok {
+		return
+	}
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[loc[len(loc)-1].(string)] = value
+	case []interface{}:
+		p[loc[len(loc)-1].(int)] = value
+	}
+}
+
+func compareLocations(a, b location) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch x := a[i].(type) {
+		case int:
+			if y, ok := b[i].(int); ok && x != y {
+				return x - y
+			}
+		case string:
+			if y, ok := b[i].(string); ok && x != y {
+				return strings.Compare(x, y)
+			}
+		}
+	}
+	return len(a) - len(b)
+}
+
+// jsonValue turns the maps with non-string keys YAML produces, e.g. for an
+// unquoted 200 response code, into maps encoding/json accepts
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = jsonValue(item)
+		}
+		return v
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			converted[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return converted
+	case []interface{}:
+		for i, item := range v {
+			v[i] = jsonValue(item)
+		}
+	}
+	return value
+}
@@ -0,0 +1,244 @@
+package overlay
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// usersDocument is the spec the overlays of the tests are applied to
+const usersDocument = `{
+  "info": {"title": "Users", "version": "1.0.0"},
+  "paths": {
+    "/users": {
+      "get": {"summary": "List users", "tags": ["users"], "x-internal": true},
+      "post": {"summary": "Create a user", "tags": ["users", "admin"]}
+    },
+    "/health": {
+      "get": {"summary": "Health check", "x-internal": false}
+    }
+  },
+  "tags": [{"name": "users"}, {"name": "admin"}, {"name": "internal"}]
+}`
+
+func decode(t *testing.T, document string) map[string]interface{} {
+	t.Helper()
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestQuery(t *testing.T) {
+	document := decode(t, usersDocument)
+
+	tests := []struct {
+		query string
+		want  []string // normalized paths of the nodes selected
+	}{
+		{"$", []string{"$"}},
+		{"$.info.title", []string{`$["info"]["title"]`}},
+		{"$['paths']['/users'].get", []string{`$["paths"]["/users"]["get"]`}},
+		{"$.paths.*.get", []string{`$["paths"]["/health"]["get"]`, `$["paths"]["/users"]["get"]`}},
+		{"$.tags[0]", []string{`$["tags"][0]`}},
+		{"$.tags[-1]", []string{`$["tags"][2]`}},
+		{"$.tags[0,2]", []string{`$["tags"][0]`, `$["tags"][2]`}},
+		{"$.tags[1:]", []string{`$["tags"][1]`, `$["tags"][2]`}},
+		{"$.tags[::-1]", []string{`$["tags"][2]`, `$["tags"][1]`, `$["tags"][0]`}},
+		{"$..summary", []string{`$["paths"]["/health"]["get"]["summary"]`, `$["paths"]["/users"]["get"]["summary"]`, `$["paths"]["/users"]["post"]["summary"]`}},
+		{"$.paths.*[?@.x-internal == true]", []string{`$["paths"]["/users"]["get"]`}},
+		{"$.paths.*[?@.x-internal]", []string{`$["paths"]["/health"]["get"]`, `$["paths"]["/users"]["get"]`}},
+		{"$.paths.*[?!@.x-internal]", []string{`$["paths"]["/users"]["post"]`}},
+		{"$.tags[?@.name == 'admin' || @.name == 'internal']", []string{`$["tags"][1]`, `$["tags"][2]`}},
+		{"$.tags[?@.name != 'users' && length(@.name) > 5]", []string{`$["tags"][2]`}},
+		{"$.paths.*[?length(@.tags) > 1]", []string{`$["paths"]["/users"]["post"]`}},
+		{"$.paths.*[?count(@.tags) > 1]", nil}, // count of the nodes selected, not of the items
+		{"$.paths.*[?count(@..tags[*]) > 1]", []string{`$["paths"]["/users"]["post"]`}},
+		{"$.paths..tags[?@ == 'admin']", []string{`$["paths"]["/users"]["post"]["tags"][1]`}},
+		{"$.tags[?match(@.name, 'ad.*')]", []string{`$["tags"][1]`}},
+		{"$.tags[?search(@.name, 'er')]", []string{`$["tags"][0]`, `$["tags"][2]`}},
+		{"$.paths['/orders']", nil},
+		{"$.tags[5]", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := compileQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, n := range q.selectNodes(document, document) {
+				got = append(got, n.location.String())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidQuery(t *testing.T) {
+	for _, query := range []string{
+		"",
+		"paths",
+		"@.paths",
+		"$.tags[",
+		"$.tags[?@.name ==]",
+		"$.tags[0] extra",
+	} {
+		if _, err := compileQuery(query); err == nil {
+			t.Errorf("compileQuery(%q) succeeded", query)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		actions   []Action
+		check     string // JSONPath of the value compared with want
+		want      string // JSON of that value
+		unmatched int
+	}{
+		{"update merges objects", []Action{{Target: "$.info", Update: map[string]interface{}{"description": "Public API", "title": "Partners"}}},
+			"$.info", `{"description":"Public API","title":"Partners","version":"1.0.0"}`, 0},
+		{"update appends to arrays", []Action{{Target: "$.paths.*.*.tags", Update: "public"}},
+			"$.paths['/users'].post.tags", `["users","admin","public"]`, 0},
+		{"update replaces scalars", []Action{{Target: "$..summary", Update: "TBD"}},
+			"$.paths['/health'].get.summary", `"TBD"`, 0},
+		{"update merges nested objects", []Action{{Target: "$.paths['/users']", Update: map[string]interface{}{"get": map[string]interface{}{"deprecated": true}}}},
+			"$.paths['/users'].get", `{"deprecated":true,"summary":"List users","tags":["users"],"x-internal":true}`, 0},
+		{"remove members", []Action{{Target: "$.paths.*[?@.x-internal == true]", Remove: true}},
+			"$.paths['/users']", `{"post":{"summary":"Create a user","tags":["users","admin"]}}`, 0},
+		{"remove array items from the last", []Action{{Target: "$.tags[?@.name != 'admin']", Remove: true}},
+			"$.tags", `[{"name":"admin"}]`, 0},
+		{"remove in reverse order", []Action{{Target: "$.tags[0,1]", Remove: true}},
+			"$.tags", `[{"name":"internal"}]`, 0},
+		{"remove descendants with their ancestors", []Action{{Target: "$..tags", Remove: true}},
+			"$.paths['/users'].post", `{"summary":"Create a user"}`, 0},
+		{"remove wins over update", []Action{{Target: "$.tags", Update: map[string]interface{}{"name": "public"}, Remove: true}},
+			"$", `{"info":{"title":"Users","version":"1.0.0"},"paths":{"/health":{"get":{"summary":"Health check","x-internal":false}},"/users":{"get":{"summary":"List users","tags":["users"],"x-internal":true},"post":{"summary":"Create a user","tags":["users","admin"]}}}}`, 0},
+		{"actions in order", []Action{
+			{Target: "$.tags[0]", Remove: true},
+			{Target: "$.tags[0]", Update: map[string]interface{}{"description": "Admin"}},
+		}, "$.tags[0]", `{"description":"Admin","name":"admin"}`, 0},
+		{"unmatched targets", []Action{
+			{Target: "$.paths['/orders']", Remove: true},
+			{Target: "$.info", Update: map[string]interface{}{"title": "Partners"}},
+			{Target: "$.tags[?@.name == 'orders']", Update: map[string]interface{}{}},
+		}, "$.info.title", `"Partners"`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := decode(t, usersDocument)
+			o := &Overlay{Overlay: "1.0.0", Info: Info{Title: "Test", Version: "1"}, Actions: tt.actions}
+			unmatched, err := o.Apply(document)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(unmatched) != tt.unmatched {
+				t.Errorf("unmatched = %+v, want %d actions", unmatched, tt.unmatched)
+			}
+
+			q, err := compileQuery(tt.check)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes := q.selectNodes(document, document)
+			if len(nodes) != 1 {
+				t.Fatalf("%s selects %d nodes", tt.check, len(nodes))
+			}
+			got, _ := json.Marshal(nodes[0].value)
+			if string(got) != tt.want {
+				t.Errorf("%s = %s\nwant %s", tt.check, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesAreCopies(t *testing.T) {
+	document := decode(t, usersDocument)
+	o := &Overlay{Overlay: "1.0.0", Info: Info{Title: "Test", Version: "1"}, Actions: []Action{
+		{Target: "$.paths.*.get", Update: map[string]interface{}{"x-owner": map[string]interface{}{"team": "core"}}},
+	}}
+	if _, err := o.Apply(document); err != nil {
+		t.Fatal(err)
+	}
+
+	health := document["paths"].(map[string]interface{})["/health"].(map[string]interface{})["get"].(map[string]interface{})
+	health["x-owner"].(map[string]interface{})["team"] = "ops"
+	users := document["paths"].(map[string]interface{})["/users"].(map[string]interface{})["get"].(map[string]interface{})
+	if team := users["x-owner"].(map[string]interface{})["team"]; team != "core" {
+		t.Errorf("targets share the update, team = %v", team)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  Action
+		wantErr string
+	}{
+		{"remove the document", Action{Target: "$", Remove: true}, "the document itself can't be removed"},
+		{"scalar into an object", Action{Target: "$.info", Update: "Users"}, "the update must be one too"},
+		{"scalar document", Action{Target: "$", Update: "Users"}, "the update must be one too"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &Overlay{Overlay: "1.0.0", Info: Info{Title: "Test", Version: "1"}, Actions: []Action{tt.action}}
+			_, err := o.Apply(decode(t, usersDocument))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), `overlay "Test": action 0`) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		wantErrs []string
+	}{
+		{"yaml", "overlay: 1.0.0\ninfo: {title: Public, version: 1.0.0}\nactions:\n  - target: $.paths.*.*.responses.500\n    remove: true\n  - target: $.info\n    update: {description: Public API}\n", nil},
+		{"json", `{"overlay":"1.1.0","info":{"title":"Public","version":"1"},"actions":[{"target":"$.info","update":{"x-audience":"public"}}]}`, nil},
+		{"version", "overlay: 2.0.0\ninfo: {title: Public, version: 1.0.0}\nactions: [{target: $.info, remove: true}]\n", []string{`unsupported overlay version "2.0.0"`}},
+		{"info", "overlay: 1.0.0\ninfo: {title: Public}\nactions: [{target: $.info, remove: true}]\n", []string{"info.title and info.version are required"}},
+		{"no actions", "overlay: 1.0.0\ninfo: {title: Public, version: 1.0.0}\n", []string{"overlay has no actions"}},
+		{"actions", "overlay: 1.0.0\ninfo: {title: Public, version: 1.0.0}\nactions:\n  - target: info\n    remove: true\n  - target: $.info\n", []string{"action 0: invalid JSONPath", "action 1: neither update nor remove is set"}},
+		{"not yaml", "overlay: [", []string{"error parsing overlay"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.document))
+			if tt.wantErrs == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("no error, want %q", tt.wantErrs)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("err = %v, want %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseResponseCodes(t *testing.T) {
+	// unquoted YAML keys are decoded as numbers
+	o, err := Parse([]byte("overlay: 1.0.0\ninfo: {title: Public, version: 1.0.0}\nactions:\n  - target: $.paths['/users'].get.responses\n    update:\n      404: {description: Not Found}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses, ok := o.Actions[0].Update.(map[string]interface{})
+	if _, found := responses["404"]; !ok || !found {
+		t.Errorf("update = %#v", o.Actions[0].Update)
+	}
+}
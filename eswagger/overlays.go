@@ -0,0 +1,118 @@
+package eswagger
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"main/eswagger/overlay"
+
+	"github.com/go-openapi/spec"
+)
+
+// ApplyOverlays returns a copy of the spec with the overlays applied in
+// order, e.g. one overlay per audience on top of the generated spec. The
+// actions selecting nothing are reported as DiagStaleOverlay.
+func ApplyOverlays(swagger *spec.Swagger, overlays ...*overlay.Overlay) (*spec.Swagger, Diagnostics, error) {
+	data, err := json.Marshal(swagger)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error marshalling Swagger spec: %v", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("error marshalling Swagger spec: %v", err)
+	}
+
+	var diagnostics Diagnostics
+	for _, o := range overlays {
+		unmatched, err := o.Apply(document)
+		for _, action := range unmatched {
+			diagnostics = append(diagnostics, Diagnostic{
+				Kind:    DiagStaleOverlay,
+				Message: fmt.Sprintf("%s: target %s selects nothing", o.Name(), action.Target),
+			})
+		}
+		if err != nil {
+			return nil, diagnostics, err
+		}
+	}
+
+	if data, err = json.Marshal(document); err != nil {
+		return nil, diagnostics, fmt.Errorf("error encoding the spec with overlays: %v", err)
+	}
+	result := new(spec.Swagger)
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, diagnostics, fmt.Errorf("the overlays made the spec invalid: %v", err)
+	}
+	return result, diagnostics, nil
+}
+
+// AddOverlays appends overlays applied after Config.Overlays on every build,
+// before the spec is served or saved. Invalid overlays are rejected, the
+// others are dropped again when the spec can't be regenerated with them.
+func (g *Generator) AddOverlays(overlays ...*overlay.Overlay) error {
+	for _, o := range overlays {
+		if err := o.Validate(); err != nil {
+			return fmt.Errorf("%s: %v", o.Name(), err)
+		}
+	}
+
+	g.mu.Lock()
+	g.overlays = append(g.overlays, overlays...)
+	source, metadata := g.source, g.metadata
+	g.mu.Unlock()
+
+	if _, err := g.rebuild(source, metadata); err != nil {
+		g.mu.Lock()
+		g.overlays = withoutOverlays(g.overlays, overlays)
+		g.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func withoutOverlays(overlays, removed []*overlay.Overlay) []*overlay.Overlay {
+	kept := overlays[:0:0]
+next:
+	for _, o := range overlays {
+		for _, r := range removed {
+			if o == r {
+				continue next
+			}
+		}
+		kept = append(kept, o)
+	}
+	return kept
+}
+
+// configOverlays loads Config.Overlays, once: the files are read by the first
+// build and their errors reported by every build
+func (g *Generator) configOverlays() ([]*overlay.Overlay, error) {
+	g.overlaysOnce.Do(func() {
+		for _, path := range g.config.Overlays {
+			o, err := overlay.Load(path)
+			if err != nil {
+				g.overlaysErr = err
+				return
+			}
+			g.loadedOverlays = append(g.loadedOverlays, o)
+		}
+	})
+	return g.loadedOverlays, g.overlaysErr
+}
+
+// applyOverlays applies the overlays to the spec being built
+func (g *Generator) applyOverlays(overlays []*overlay.Overlay) error {
+	if len(overlays) == 0 {
+		return nil
+	}
+
+	swagger, diagnostics, err := ApplyOverlays(g.swagger, overlays...)
+	for _, diagnostic := range diagnostics {
+		g.report(diagnostic.Kind, diagnostic.Method, diagnostic.Path, "%s", diagnostic.Message)
+	}
+	if err != nil {
+		return err
+	}
+	g.swagger = swagger
+	return nil
+}
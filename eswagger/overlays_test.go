@@ -0,0 +1,119 @@
+package eswagger
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"main/eswagger/overlay"
+
+	"github.com/gorilla/mux"
+)
+
+func parseOverlay(t *testing.T, document string) *overlay.Overlay {
+	t.Helper()
+	o, err := overlay.Parse([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return o
+}
+
+func TestApplyOverlays(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", func(http.ResponseWriter, *http.Request) {}).Methods("PUT")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	g.RegisterEndpoint("/users/{id}", "PUT", updateUserRequest{}, handleUser{})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	swagger := g.GetSwaggerSpec()
+
+	public := parseOverlay(t, `
+overlay: 1.0.0
+info: {title: Public, version: 1.0.0}
+actions:
+  - target: $.info
+    update: {description: The public API}
+  - target: $.paths.*.*.parameters[?@.in == 'body']
+    remove: true
+  - target: $.paths['/orders']
+    remove: true
+`)
+	partners := parseOverlay(t, `
+overlay: 1.0.0
+info: {title: Partners, version: 1.0.0}
+actions:
+  - target: $.info.title
+    update: Partners API
+`)
+
+	result, diagnostics, err := ApplyOverlays(swagger, public, partners)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Info.Title != "Partners API" || result.Info.Description != "The public API" {
+		t.Errorf("info = %q %q", result.Info.Title, result.Info.Description)
+	}
+	if params := result.Paths.Paths["/users/{id}"].Put.Parameters; len(params) != 1 || params[0].In != "path" {
+		t.Errorf("parameters = %+v, want the path one", params)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Kind != DiagStaleOverlay || diagnostics[0].Message != `overlay "Public": target $.paths['/orders'] selects nothing` {
+		t.Errorf("diagnostics = %v", diagnostics)
+	}
+	if swagger.Info.Title != "Users" || len(swagger.Paths.Paths["/users/{id}"].Put.Parameters) != 2 {
+		t.Error("spec of the generator modified")
+	}
+}
+
+func TestAddOverlays(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", noop).Methods("PUT")
+	router.HandleFunc("/internal/metrics", noop).Methods("GET")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		overlay  *overlay.Overlay
+		wantErr  string
+		internal bool // whether /internal/metrics is still documented
+	}{
+		{"invalid", &overlay.Overlay{Overlay: "1.0.0", Info: overlay.Info{Title: "Invalid", Version: "1"}}, "overlay has no actions", true},
+		{"failing", &overlay.Overlay{Overlay: "1.0.0", Info: overlay.Info{Title: "Failing", Version: "1"}, Actions: []overlay.Action{
+			{Target: "$", Remove: true},
+		}}, "the document itself can't be removed", true},
+		{"valid", &overlay.Overlay{Overlay: "1.0.0", Info: overlay.Info{Title: "Public", Version: "1"}, Actions: []overlay.Action{
+			{Target: "$.paths['/internal/metrics']", Remove: true},
+		}}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := g.AddOverlays(tt.overlay)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+
+			_, internal := g.GetSwaggerSpec().Paths.Paths["/internal/metrics"]
+			if internal != tt.internal {
+				t.Errorf("/internal/metrics documented = %v, want %v", internal, tt.internal)
+			}
+		})
+	}
+
+	// the rejected overlays are not kept for the next builds
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Errorf("regenerating: %v", err)
+	}
+	if _, ok := g.GetSwaggerSpec().Paths.Paths["/internal/metrics"]; ok {
+		t.Error("added overlay not applied when regenerating")
+	}
+}
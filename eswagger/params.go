@@ -0,0 +1,302 @@
+package eswagger
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// templateVar is a variable of a mux template such as {id:[0-9]+}
+type templateVar struct {
+	Name    string
+	Pattern string
+}
+
+// remainder reports whether the variable matches the rest of the path,
+// slashes included, as {path:.*} or the {path...} of an http.ServeMux
+func (v templateVar) remainder() bool {
+	return v.Pattern == ".*" || v.Pattern == ".+"
+}
+
+// parseTemplate strips the regular expressions from a mux template, e.g.
+// "/users/{id:[0-9]+}" becomes "/users/{id}", and returns its variables.
+// Patterns may hold braces themselves, as in {code:[a-z]{3}}.
+func parseTemplate(template string) (string, []templateVar) {
+	var clean strings.Builder
+	var vars []templateVar
+
+	for i := 0; i < len(template); i++ {
+		if template[i] != '{' {
+			clean.WriteByte(template[i])
+			continue
+		}
+
+		depth, end := 0, -1
+		for j := i; j < len(template); j++ {
+			if template[j] == '{' {
+				depth++
+			} else if template[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		if end < 0 {
+			clean.WriteString(template[i:])
+			break
+		}
+
+		name, pattern, _ := strings.Cut(template[i+1:end], ":")
+		name = strings.TrimSpace(name)
+		vars = append(vars, templateVar{Name: name, Pattern: strings.TrimSpace(pattern)})
+		clean.WriteString("{" + name + "}")
+		i = end
+	}
+
+	return clean.String(), vars
+}
+
+var integerPattern = regexp.MustCompile(`^(\[0-9\]|\\d)[+*]?$|^(\[0-9\]|\\d)\{\d+(,\d*)?\}$`)
+
+// simpleSchemaFor guesses the type of a variable from its pattern, or from
+// its name when it has none
+func simpleSchemaFor(v templateVar) (spec.SimpleSchema, string) {
+	switch {
+	case v.remainder():
+		return spec.SimpleSchema{Type: "string"}, ""
+	case integerPattern.MatchString(v.Pattern):
+		return spec.SimpleSchema{Type: "integer", Format: "int64"}, ""
+	case v.Pattern != "":
+		return spec.SimpleSchema{Type: "string"}, "^(?:" + v.Pattern + ")$"
+	case v.Name == "id":
+		return spec.SimpleSchema{Type: "integer", Format: "int64"}, ""
+	}
+	return spec.SimpleSchema{Type: "string"}, ""
+}
+
+// routeParameters documents the path variables and the query and header
+// matchers of a route
+func (g *Generator) routeParameters(route Route, vars []templateVar) []spec.Parameter {
+	var params []spec.Parameter
+
+	for _, v := range vars {
+		simpleSchema, pattern := simpleSchemaFor(v)
+		param := spec.Parameter{
+			SimpleSchema: simpleSchema,
+			ParamProps: spec.ParamProps{
+				Name:     v.Name,
+				In:       "path",
+				Required: true,
+			},
+		}
+		param.Pattern = pattern
+		if v.Name == "id" {
+			param.Description = "ID of the resource"
+		}
+		if v.remainder() {
+			// Swagger 2.0 path parameters are a single segment, the extension
+			// tells the validation middleware to match slashes too
+			param.Description = "Rest of the path, may contain slashes"
+			param.AddExtension("x-remainder", true)
+		}
+		params = append(params, param)
+	}
+
+	for _, key := range sortedKeys(route.Queries) {
+		value := route.Queries[key]
+		param := spec.Parameter{
+			SimpleSchema: spec.SimpleSchema{Type: "string"},
+			ParamProps: spec.ParamProps{
+				Name:     key,
+				In:       "query",
+				Required: true,
+			},
+		}
+
+		switch clean, queryVars := parseTemplate(value); {
+		case len(queryVars) == 0:
+			// A fixed value such as Queries("type", "admin")
+			param.Enum = []interface{}{value}
+		case len(queryVars) == 1 && clean == "{"+queryVars[0].Name+"}":
+			param.SimpleSchema, param.Pattern = simpleSchemaFor(queryVars[0])
+		}
+		params = append(params, param)
+	}
+
+	for _, name := range sortedKeys(route.Headers) {
+		param := spec.Parameter{
+			SimpleSchema: spec.SimpleSchema{Type: "string"},
+			ParamProps: spec.ParamProps{
+				Name:     name,
+				In:       "header",
+				Required: true,
+			},
+		}
+		if value := route.Headers[name]; value != "" {
+			param.Enum = []interface{}{value}
+		}
+		params = append(params, param)
+	}
+
+	for _, name := range sortedKeys(route.HeaderPatterns) {
+		param := spec.Parameter{
+			SimpleSchema: spec.SimpleSchema{Type: "string"},
+			ParamProps: spec.ParamProps{
+				Name:     name,
+				In:       "header",
+				Required: true,
+			},
+		}
+		param.Pattern = route.HeaderPatterns[name]
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// hostServers describes a host template as an OpenAPI 3 style server list,
+// Swagger 2.0 has no server variables so it is attached as "x-servers"
+func (g *Generator) hostServers(route Route) []map[string]interface{} {
+	if route.Host == "" {
+		return nil
+	}
+
+	host, vars := parseTemplate(route.Host)
+
+	schemes := route.Schemes
+	if len(schemes) == 0 {
+		schemes = g.swagger.Schemes
+	}
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+
+	var servers []map[string]interface{}
+	for _, scheme := range schemes {
+		server := map[string]interface{}{
+			"url": scheme + "://" + host + g.swagger.BasePath,
+		}
+		if len(vars) > 0 {
+			variables := make(map[string]interface{}, len(vars))
+			for _, v := range vars {
+				variable := map[string]interface{}{"default": v.Name}
+				if v.Pattern != "" {
+					variable["x-pattern"] = v.Pattern
+				}
+				variables[v.Name] = variable
+			}
+			server["variables"] = variables
+		}
+		servers = append(servers, server)
+	}
+	return servers
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// bindingParameters documents the fields of a Handle request bound from the
+// path and the query string. A scalar request takes the single path variable,
+// like bindParams does.
+func (g *Generator) bindingParameters(t reflect.Type, vars []templateVar) []spec.Parameter {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return nil
+	}
+
+	if isScalar(t.Kind()) {
+		if len(vars) != 1 {
+			return nil
+		}
+		return []spec.Parameter{{
+			SimpleSchema: kindSimpleSchema(t),
+			ParamProps:   spec.ParamProps{Name: vars[0].Name, In: "path", Required: true},
+		}}
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []spec.Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		param := spec.Parameter{SimpleSchema: kindSimpleSchema(field.Type)}
+		if name := field.Tag.Get("path"); name != "" {
+			param.Name, param.In, param.Required = name, "path", true
+		} else if name := field.Tag.Get("query"); name != "" {
+			param.Name, param.In, param.Required = name, "query", g.isRequiredField(field)
+		} else {
+			continue
+		}
+		if doc := field.Tag.Get("doc"); doc != "" {
+			param.Description = doc
+		} else {
+			param.Description = g.fieldComment(t, field.Name)
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// kindSimpleSchema maps a scalar Go type to the type of a parameter
+func kindSimpleSchema(t reflect.Type) spec.SimpleSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return spec.SimpleSchema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64, reflect.Int, reflect.Uint:
+		return spec.SimpleSchema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return spec.SimpleSchema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return spec.SimpleSchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return spec.SimpleSchema{Type: "number", Format: "double"}
+	}
+	return spec.SimpleSchema{Type: "string"}
+}
+
+// mergeParameters adds the parameters of a binding to those of the route. A
+// parameter the route already documents takes the type of the bound field,
+// its pattern is kept for strings.
+func mergeParameters(params, bound []spec.Parameter) []spec.Parameter {
+next:
+	for _, b := range bound {
+		for i := range params {
+			p := &params[i]
+			if p.Name != b.Name || p.In != b.In {
+				continue
+			}
+			p.SimpleSchema = b.SimpleSchema
+			if b.Type != "string" {
+				p.Pattern = ""
+			}
+			if b.Description != "" {
+				p.Description = b.Description
+			}
+			p.Required = p.Required || b.Required
+			continue next
+		}
+		params = append(params, b)
+	}
+	return params
+}
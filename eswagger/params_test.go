@@ -0,0 +1,135 @@
+package eswagger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/gorilla/mux"
+)
+
+func TestParseTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		clean    string
+		vars     []templateVar
+	}{
+		{"/users", "/users", nil},
+		{"/users/{id}", "/users/{id}", []templateVar{{Name: "id"}}},
+		{"/users/{id:[0-9]+}/files/{name}", "/users/{id}/files/{name}", []templateVar{{Name: "id", Pattern: "[0-9]+"}, {Name: "name"}}},
+		{"/codes/{code:[a-z]{3}}", "/codes/{code}", []templateVar{{Name: "code", Pattern: "[a-z]{3}"}}},
+		{"{tenant}.example.com", "{tenant}.example.com", []templateVar{{Name: "tenant"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			clean, vars := parseTemplate(tt.template)
+			if clean != tt.clean || !reflect.DeepEqual(vars, tt.vars) {
+				t.Errorf("parseTemplate(%q) = %q %+v, want %q %+v", tt.template, clean, vars, tt.clean, tt.vars)
+			}
+		})
+	}
+}
+
+func TestSimpleSchemaFor(t *testing.T) {
+	tests := []struct {
+		v       templateVar
+		typ     string
+		pattern string
+	}{
+		{templateVar{Name: "id"}, "integer", ""},
+		{templateVar{Name: "id", Pattern: "[0-9]+"}, "integer", ""},
+		{templateVar{Name: "id", Pattern: "[a-f0-9-]{36}"}, "string", "^(?:[a-f0-9-]{36})$"},
+		{templateVar{Name: "page", Pattern: `\d{1,3}`}, "integer", ""},
+		{templateVar{Name: "name"}, "string", ""},
+		{templateVar{Name: "path", Pattern: ".*"}, "string", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.v.Name+":"+tt.v.Pattern, func(t *testing.T) {
+			schema, pattern := simpleSchemaFor(tt.v)
+			if schema.Type != tt.typ || pattern != tt.pattern {
+				t.Errorf("got %q %q, want %q %q", schema.Type, pattern, tt.typ, tt.pattern)
+			}
+		})
+	}
+}
+
+func TestUUIDPathParameter(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/things/{id:[a-f0-9-]{36}}", func(http.ResponseWriter, *http.Request) {}).Methods("GET")
+
+	g := NewGenerator(Config{Title: "Things", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	handler := g.ValidationMiddleware(ValidationOptions{})(router)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/things/0b6c1a52-9f3e-4c8e-a8a1-5d2f7e4b9c10", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET with a UUID = %d %s, want 200", w.Code, w.Body)
+	}
+}
+
+func TestMuxMatcherParameters(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/items/{id:[0-9]+}", noop).Methods("GET").
+		Queries("type", "admin", "page", "{page:[0-9]+}").
+		Headers("X-Api-Version", "2", "X-Request-Id", "").
+		HeadersRegexp("Content-Type", "^application/json$").
+		Schemes("https").
+		Host("{tenant}.example.com")
+
+	g := NewGenerator(Config{Title: "Items", Version: "1.0.0"})
+	diagnostics, err := g.GenerateFromRouter(router, RouteMetadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unread := diagnostics.Of(DiagUnreadMatchers); len(unread) > 0 {
+		t.Fatalf("matchers not read: %v", unread)
+	}
+	operation := g.GetSwaggerSpec().Paths.Paths["/items/{id}"].Get
+	if operation == nil {
+		t.Fatal("GET /items/{id} not documented")
+	}
+
+	params := make(map[string]spec.Parameter)
+	for _, param := range operation.Parameters {
+		params[param.In+" "+param.Name] = param
+	}
+
+	tests := []struct {
+		name    string
+		typ     string
+		enum    []interface{}
+		pattern string
+	}{
+		{"path id", "integer", nil, ""},
+		{"query type", "string", []interface{}{"admin"}, ""},
+		{"query page", "integer", nil, ""},
+		{"header X-Api-Version", "string", []interface{}{"2"}, ""},
+		{"header X-Request-Id", "string", nil, ""},
+		{"header Content-Type", "string", nil, "^application/json$"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			param, ok := params[tt.name]
+			if !ok {
+				t.Fatalf("not documented, got %v", reflect.ValueOf(params).MapKeys())
+			}
+			if param.Type != tt.typ || !reflect.DeepEqual(param.Enum, tt.enum) || param.Pattern != tt.pattern || !param.Required {
+				t.Errorf("got type %q enum %v pattern %q required %v", param.Type, param.Enum, param.Pattern, param.Required)
+			}
+		})
+	}
+
+	if !reflect.DeepEqual(operation.Schemes, []string{"https"}) {
+		t.Errorf("schemes = %q, want https", operation.Schemes)
+	}
+	servers, _ := operation.Extensions["x-servers"].([]map[string]interface{})
+	if len(servers) != 1 || servers[0]["url"] != "https://{tenant}.example.com" {
+		t.Errorf("x-servers = %v", operation.Extensions["x-servers"])
+	}
+}
@@ -0,0 +1,149 @@
+package eswagger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/go-openapi/spec"
+	"github.com/gorilla/mux"
+)
+
+func TestRegenerate(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+	router.HandleFunc("/users/{id}", noop).Methods("PUT")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	before, revision := g.snapshot()
+
+	tests := []struct {
+		name    string
+		apply   func(t *testing.T)
+		path    string
+		updated func(swagger *spec.Swagger) bool
+	}{
+		{"route added after startup", func(t *testing.T) {
+			router.HandleFunc("/orders", noop).Methods("GET")
+			if _, err := g.Regenerate(); err != nil {
+				t.Fatal(err)
+			}
+		}, "/orders", func(swagger *spec.Swagger) bool {
+			_, ok := swagger.Paths.Paths["/orders"]
+			return ok
+		}},
+		{"endpoint registered after startup", func(t *testing.T) {
+			g.RegisterEndpoint("/users/{id}", "PUT", handleUser{}, handleUser{})
+		}, "PUT /users/{id} body", hasUpdateBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.apply(t)
+
+			swagger, next := g.snapshot()
+			if next <= revision {
+				t.Errorf("revision = %d, want more than %d", next, revision)
+			}
+			revision = next
+			if !tt.updated(swagger) {
+				t.Errorf("%s not documented", tt.path)
+			}
+			if tt.updated(before) {
+				t.Errorf("%s added to the previous spec", tt.path)
+			}
+		})
+	}
+
+	// registrations survive a later rebuild
+	if _, err := g.Regenerate(); err != nil {
+		t.Fatal(err)
+	}
+	if !hasUpdateBody(g.GetSwaggerSpec()) {
+		t.Error("registered endpoint dropped by Regenerate")
+	}
+}
+
+// hasUpdateBody reports whether PUT /users/{id} documents a body
+func hasUpdateBody(swagger *spec.Swagger) bool {
+	for _, param := range swagger.Paths.Paths["/users/{id}"].Put.Parameters {
+		if param.In == "body" {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRegenerateConcurrently(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	handler := g.Handler()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if _, err := g.Regenerate(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/swagger.json", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if _, revision := g.snapshot(); revision != 9 {
+		t.Errorf("revision = %d, want 9", revision)
+	}
+}
+
+func TestRegisterEndpointBuildsOnce(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", noop).Methods("GET", "PUT", "DELETE")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	_, revision := g.snapshot()
+
+	g.RegisterEndpoint("/users/{id}", "GET", nil, handleUser{})
+	g.RegisterEndpoint("/users/{id}", "PUT", handleUser{}, handleUser{})
+	g.RegisterEndpoint("/users/{id}", "DELETE", nil, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.GetSwaggerSpec()
+		}()
+	}
+	wg.Wait()
+
+	swagger, next := g.snapshot()
+	if next != revision+1 {
+		t.Errorf("revision = %d, want %d, a single build", next, revision+1)
+	}
+	if !hasUpdateBody(swagger) {
+		t.Error("registered endpoint not documented")
+	}
+}
@@ -0,0 +1,337 @@
+package eswagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-openapi/spec"
+)
+
+// ValidationOptions configures ValidationMiddleware
+type ValidationOptions struct {
+	// ReportOnly lets invalid requests through after reporting them
+	ReportOnly bool
+
+	// Exclude lists operations that are never validated, as
+	// "METHOD /path/{template}" with the path as documented in the spec
+	Exclude []string
+
+	// OnViolation is called for every invalid request, it defaults to
+	// logging the violations
+	OnViolation func(r *http.Request, violations []Violation)
+
+	// MaxBodyBytes bounds the bodies of the validated requests, larger ones
+	// are rejected with a 413 even in ReportOnly mode. It defaults to
+	// DefaultMaxBodyBytes, a negative value removes the limit.
+	MaxBodyBytes int64
+}
+
+// DefaultMaxBodyBytes is the default ValidationOptions.MaxBodyBytes
+const DefaultMaxBodyBytes = 10 << 20
+
+// ValidationError is the body returned for rejected requests
+type ValidationError struct {
+	Message string      `json:"message"`
+	Errors  []Violation `json:"errors"`
+}
+
+// ValidationMiddleware validates incoming requests against the parameters and
+// body schema documented for their operation. Invalid requests are rejected
+// with a 400 listing every violation, bodies over MaxBodyBytes with a 413.
+// Requests without a documented operation are passed through untouched.
+func (g *Generator) ValidationMiddleware(opts ValidationOptions) func(http.Handler) http.Handler {
+	excluded := make(map[string]bool)
+	for _, entry := range opts.Exclude {
+		method, path := splitPattern(entry)
+		excluded[method+" "+path] = true
+	}
+
+	onViolation := opts.OnViolation
+	if onViolation == nil {
+		onViolation = func(r *http.Request, violations []Violation) {
+			g.logger().Warn("request validation failed",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Any("violations", violations))
+		}
+	}
+
+	maxBodyBytes := opts.MaxBodyBytes
+	if maxBodyBytes == 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			match := g.findOperation(r)
+			if match == nil || excluded[r.Method+" "+match.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if maxBodyBytes > 0 && r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+			}
+			violations, err := g.validateRequest(r, match)
+			if err != nil {
+				// The body was cut short, the handler can't be given it
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(ValidationError{
+					Message: "request body too large",
+					Errors:  []Violation{{In: "body", Message: err.Error()}},
+				})
+				return
+			}
+			if len(violations) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			onViolation(r, violations)
+			if opts.ReportOnly {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ValidationError{
+				Message: "request validation failed",
+				Errors:  violations,
+			})
+		})
+	}
+}
+
+// validateRequest returns the violations of the request, the error is a
+// *http.MaxBytesError when the body exceeds the limit
+func (g *Generator) validateRequest(r *http.Request, match *operationMatch) ([]Violation, error) {
+	var violations []Violation
+	definitions := match.Definitions
+
+	for _, param := range match.Operation.Parameters {
+		if param.In == "body" {
+			bodyViolations, err := g.validateBody(r, param, definitions)
+			if err != nil {
+				return nil, err
+			}
+			violations = append(violations, bodyViolations...)
+			continue
+		}
+
+		var raw string
+		var present bool
+		switch param.In {
+		case "path":
+			raw, present = match.Vars[param.Name]
+		case "query":
+			present = r.URL.Query().Has(param.Name)
+			raw = r.URL.Query().Get(param.Name)
+		case "header":
+			raw = r.Header.Get(param.Name)
+			present = raw != ""
+		default:
+			continue
+		}
+
+		if !present {
+			if param.Required {
+				violations = append(violations, Violation{In: param.In, Field: param.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		schema := parameterSchema(param)
+		validator := newSchemaValidator(definitions, param.In)
+		validator.validate(schema, parameterValue(schema, raw), param.Name)
+		violations = append(violations, validator.violations...)
+	}
+
+	return violations, nil
+}
+
+func (g *Generator) validateBody(r *http.Request, param spec.Parameter, definitions spec.Definitions) ([]Violation, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(r.Body)
+		r.Body.Close()
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		if err != nil {
+			return []Violation{{In: "body", Message: "could not be read"}}, nil
+		}
+		// Give the handler its own copy of the body
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if param.Required {
+			return []Violation{{In: "body", Message: "is required"}}, nil
+		}
+		return nil, nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []Violation{{In: "body", Message: "is not valid JSON: " + err.Error()}}, nil
+	}
+
+	validator := newSchemaValidator(definitions, "body")
+	validator.validate(param.Schema, value, "")
+	return validator.violations, nil
+}
+
+// operationMatch is the documented operation serving a request
+type operationMatch struct {
+	Path      string // path template as documented
+	Method    string
+	Operation *spec.Operation
+	Vars      map[string]string
+
+	// Definitions of the spec the operation was found in, the spec may be
+	// swapped by Regenerate while the request is served
+	Definitions spec.Definitions
+}
+
+type pathMatcher struct {
+	template string
+	pattern  *regexp.Regexp
+	names    []string // of the variables, in order
+}
+
+var pathVariable = regexp.MustCompile(`\{([^{}:]+)(?::[^{}]*)?\}`)
+
+// compilePathTemplate compiles a path template, the variables in remainders
+// match the rest of the path
+func compilePathTemplate(template string, remainders map[string]bool) *pathMatcher {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	last := 0
+	var names []string
+	indexes := pathVariable.FindAllStringSubmatchIndex(template, -1)
+	for _, index := range indexes {
+		name := template[index[2]:index[3]]
+		names = append(names, name)
+		pattern.WriteString(regexp.QuoteMeta(template[last:index[0]]))
+		if remainders[name] {
+			pattern.WriteString("(?P<" + sanitizeGroupName(name) + ">.*)")
+		} else {
+			pattern.WriteString("(?P<" + sanitizeGroupName(name) + ">[^/]+)")
+		}
+		last = index[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("/?$")
+
+	return &pathMatcher{
+		template: template,
+		pattern:  regexp.MustCompile(pattern.String()),
+		names:    names,
+	}
+}
+
+// newPathMatchers compiles the path templates of a spec, literal paths
+// first. It runs once per published spec, see rebuild.
+func newPathMatchers(swagger *spec.Swagger) []*pathMatcher {
+	if swagger.Paths == nil {
+		return nil
+	}
+
+	matchers := make([]*pathMatcher, 0, len(swagger.Paths.Paths))
+	for template, item := range swagger.Paths.Paths {
+		matchers = append(matchers, compilePathTemplate(template, remainderParams(item)))
+	}
+	sort.Slice(matchers, func(i, j int) bool {
+		if len(matchers[i].names) != len(matchers[j].names) {
+			return len(matchers[i].names) < len(matchers[j].names)
+		}
+		return matchers[i].template < matchers[j].template
+	})
+	return matchers
+}
+
+// remainderParams returns the path parameters of a path item which match the
+// rest of the path, see routeParameters
+func remainderParams(item spec.PathItem) map[string]bool {
+	remainders := make(map[string]bool)
+	for _, operation := range operationsOf(item) {
+		for _, param := range operation.Parameters {
+			if remainder, _ := param.Extensions.GetBool("x-remainder"); remainder && param.In == "path" {
+				remainders[param.Name] = true
+			}
+		}
+	}
+	return remainders
+}
+
+func sanitizeGroupName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// findOperation looks up the documented operation for a request by matching
+// its path against the path templates of the spec, with and without the
+// base path. Literal paths win over templated ones.
+func (g *Generator) findOperation(r *http.Request) *operationMatch {
+	g.refresh()
+
+	g.mu.RLock()
+	swagger, matchers := g.swagger, g.matchers
+	g.mu.RUnlock()
+	if swagger.Paths == nil {
+		return nil
+	}
+
+	candidates := []string{r.URL.Path}
+	if swagger.BasePath != "" && swagger.BasePath != "/" && strings.HasPrefix(r.URL.Path, swagger.BasePath) {
+		candidates = append(candidates, strings.TrimPrefix(r.URL.Path, swagger.BasePath))
+	}
+
+	method := strings.ToUpper(r.Method)
+	for _, path := range candidates {
+		for _, matcher := range matchers {
+			groups := matcher.pattern.FindStringSubmatch(path)
+			if groups == nil {
+				continue
+			}
+
+			operation := operationsOf(swagger.Paths.Paths[matcher.template])[method]
+			if operation == nil {
+				continue
+			}
+
+			vars := make(map[string]string, len(matcher.names))
+			for i, name := range matcher.names {
+				vars[name] = groups[i+1]
+			}
+
+			return &operationMatch{
+				Path:      matcher.template,
+				Method:    method,
+				Operation: operation,
+				Vars:      vars,
+
+				Definitions: swagger.Definitions,
+			}
+		}
+	}
+
+	return nil
+}
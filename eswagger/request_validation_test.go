@@ -0,0 +1,170 @@
+package eswagger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type signupRequest struct {
+	Team  int      `path:"team"`
+	Dry   bool     `query:"dry"`
+	Name  string   `json:"name" validate:"required,min=2,max=8"`
+	Email string   `json:"email" validate:"required,email"`
+	Age   int      `json:"age" validate:"gte=18"`
+	Role  string   `json:"role" validate:"oneof=admin member"`
+	Tags  []string `json:"tags" validate:"max=2"`
+}
+
+func validatedRouter(t *testing.T, opts ValidationOptions) (http.Handler, *[]Violation) {
+	t.Helper()
+
+	router := mux.NewRouter()
+	Handle(router, "POST /teams/{team:[0-9]+}/members", func(ctx context.Context, req signupRequest) (handleUser, error) {
+		return handleUser{Name: req.Name}, nil
+	})
+	Handle(router, "GET /teams/me", func(ctx context.Context, _ struct{}) (handleUser, error) {
+		return handleUser{Name: "me"}, nil
+	})
+
+	g := NewGenerator(Config{Title: "Teams", Version: "1.0.0", BasePath: "/api"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	reported := new([]Violation)
+	opts.OnViolation = func(r *http.Request, violations []Violation) {
+		*reported = violations
+	}
+	return g.ValidationMiddleware(opts)(router), reported
+}
+
+func TestValidationMiddleware(t *testing.T) {
+	handler, _ := validatedRouter(t, ValidationOptions{})
+
+	valid := `{"name":"ada","email":"ada@example.com","age":30,"role":"admin"}`
+	tests := []struct {
+		name       string
+		target     string
+		body       string
+		status     int
+		violations []Violation
+	}{
+		{"valid", "/teams/1/members", valid, 201, nil},
+		{"base path stripped", "/api/teams/1/members", `{"name":"ada"}`, 400, []Violation{{In: "body", Field: "email", Message: "is required"}}},
+		{"path type", "/teams/x/members", valid, 400, []Violation{{In: "path", Field: "team", Message: "must be of type integer, got string"}}},
+		{"query type", "/teams/1/members?dry=maybe", valid, 400, []Violation{{In: "query", Field: "dry", Message: "must be of type boolean, got string"}}},
+		{"missing body", "/teams/1/members", "", 400, []Violation{{In: "body", Message: "is required"}}},
+		{"invalid JSON", "/teams/1/members", `{"name":`, 400, nil},
+		{"every violation listed", "/teams/1/members", `{"name":"a","email":"nope","age":12,"role":"owner","tags":["a","b","c"]}`, 400, []Violation{
+			{In: "body", Field: "age", Message: "must be greater than or equal to 18"},
+			{In: "body", Field: "email", Message: "must be a valid email"},
+			{In: "body", Field: "name", Message: "must be at least 2 characters long"},
+			{In: "body", Field: "role", Message: "must be one of [admin, member]"},
+			{In: "body", Field: "tags", Message: "must contain at most 2 items"},
+		}},
+		{"wrong body type", "/teams/1/members", `{"name":"ada","email":"ada@example.com","age":"old"}`, 400, []Violation{{In: "body", Field: "age", Message: "must be of type integer, got string"}}},
+		{"undocumented route", "/elsewhere", "", 404, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.violations == nil {
+				return
+			}
+			var body ValidationError
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(body.Errors, tt.violations) {
+				t.Errorf("violations = %v\nwant %v", body.Errors, tt.violations)
+			}
+		})
+	}
+}
+
+func TestValidationMiddlewareOptions(t *testing.T) {
+	invalid := `{"name":"a"}`
+	tests := []struct {
+		name     string
+		opts     ValidationOptions
+		body     string
+		status   int
+		reported bool
+	}{
+		{"rejected", ValidationOptions{}, invalid, 400, true},
+		{"report only", ValidationOptions{ReportOnly: true}, invalid, 400, true}, // the handler rejects it on its own
+		{"excluded", ValidationOptions{Exclude: []string{"POST /teams/{team}/members"}}, `{"name":"ada","email":"x"}`, 201, false},
+		{"body too large", ValidationOptions{MaxBodyBytes: 16}, `{"name":"ada","email":"ada@example.com"}`, 413, false},
+		{"body too large in report only mode", ValidationOptions{MaxBodyBytes: 16, ReportOnly: true}, `{"name":"ada","email":"ada@example.com"}`, 413, false},
+		{"no limit", ValidationOptions{MaxBodyBytes: -1}, `{"name":"ada","email":"ada@example.com","tags":["` + strings.Repeat("x", 1<<20) + `"]}`, 201, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, reported := validatedRouter(t, tt.opts)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", "/teams/1/members", strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %.200s", w.Code, tt.status, w.Body)
+			}
+			if got := len(*reported) > 0; got != tt.reported {
+				t.Errorf("reported = %v, want %v", *reported, tt.reported)
+			}
+		})
+	}
+}
+
+func TestFindOperation(t *testing.T) {
+	router := mux.NewRouter()
+	Handle(router, "GET /teams/{team}", func(ctx context.Context, team string) (handleUser, error) { return handleUser{}, nil })
+	Handle(router, "GET /teams/me", func(ctx context.Context, _ struct{}) (handleUser, error) { return handleUser{}, nil })
+	Handle(router, "GET /teams/{team}/members/{member}", func(ctx context.Context, _ struct{}) (handleUser, error) { return handleUser{}, nil })
+	generator := NewGenerator(Config{Title: "Teams", Version: "1.0.0"})
+	if _, err := generator.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		want   string
+		vars   map[string]string
+	}{
+		{"GET", "/teams/me", "/teams/me", map[string]string{}},
+		{"GET", "/teams/red", "/teams/{team}", map[string]string{"team": "red"}},
+		{"GET", "/teams/red/", "/teams/{team}", map[string]string{"team": "red"}},
+		{"GET", "/teams/red/members/7", "/teams/{team}/members/{member}", map[string]string{"team": "red", "member": "7"}},
+		{"POST", "/teams/red", "", nil},
+		{"GET", "/players/7", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			match := generator.findOperation(httptest.NewRequest(tt.method, tt.path, nil))
+			if tt.want == "" {
+				if match != nil {
+					t.Errorf("matched %s, want no operation", match.Path)
+				}
+				return
+			}
+			if match == nil {
+				t.Fatalf("no operation, want %s", tt.want)
+			}
+			if match.Path != tt.want || !reflect.DeepEqual(match.Vars, tt.vars) {
+				t.Errorf("matched %s %v, want %s %v", match.Path, match.Vars, tt.want, tt.vars)
+			}
+		})
+	}
+}
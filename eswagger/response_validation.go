@@ -0,0 +1,121 @@
+package eswagger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// ResponseValidationOptions configures ResponseValidationMiddleware
+type ResponseValidationOptions struct {
+	// AllowUndocumentedFields stops reporting response fields missing from
+	// the documented schema
+	AllowUndocumentedFields bool
+
+	// OnViolation is called for every response that does not match the
+	// spec, it defaults to logging the violations
+	OnViolation func(r *http.Request, status int, violations []Violation)
+}
+
+// ResponseValidationMiddleware checks the status code and body of every
+// response against the responses documented for its operation. It never
+// changes the response, violations are only reported through OnViolation.
+// The body is buffered, so it is meant for staging and tests.
+func (g *Generator) ResponseValidationMiddleware(opts ResponseValidationOptions) func(http.Handler) http.Handler {
+	onViolation := opts.OnViolation
+	if onViolation == nil {
+		onViolation = func(r *http.Request, status int, violations []Violation) {
+			g.logger().Warn("response does not match the spec",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Any("violations", violations))
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			match := g.findOperation(r)
+			if match == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if violations := g.validateResponse(match, recorder, !opts.AllowUndocumentedFields); len(violations) > 0 {
+				onViolation(r, recorder.status, violations)
+			}
+		})
+	}
+}
+
+func (g *Generator) validateResponse(match *operationMatch, recorder *responseRecorder, closed bool) []Violation {
+	responses := match.Operation.Responses
+	if responses == nil {
+		return nil
+	}
+
+	response, documented := responses.StatusCodeResponses[recorder.status]
+	if !documented && responses.Default != nil {
+		response, documented = *responses.Default, true
+	}
+	if !documented {
+		return []Violation{{In: "status", Message: fmt.Sprintf("status code %d is not documented", recorder.status)}}
+	}
+
+	body := bytes.TrimSpace(recorder.body.Bytes())
+	if response.Schema == nil {
+		if len(body) > 0 {
+			return []Violation{{In: "response", Message: "body is not documented"}}
+		}
+		return nil
+	}
+	if len(body) == 0 {
+		return []Violation{{In: "response", Message: "body is missing"}}
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return []Violation{{In: "response", Message: "is not valid JSON: " + err.Error()}}
+	}
+
+	validator := newSchemaValidator(match.Definitions, "response")
+	validator.closed = closed
+	validator.validate(response.Schema, value, "")
+	return validator.violations
+}
+
+// responseRecorder passes the response through while keeping a copy of the
+// status code and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,63 @@
+package eswagger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestResponseValidationMiddleware(t *testing.T) {
+	router := mux.NewRouter()
+	Handle(router, "GET /users/{id}", func(ctx context.Context, id int) (handleUser, error) { return handleUser{}, nil })
+	Handle(router, "DELETE /users/{id}", func(ctx context.Context, id int) (struct{}, error) { return struct{}{}, nil })
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       ResponseValidationOptions
+		method     string
+		status     int
+		body       string
+		violations []Violation
+	}{
+		{"matching", ResponseValidationOptions{}, "GET", 200, `{"id":1,"name":"ada"}`, nil},
+		{"undocumented status", ResponseValidationOptions{}, "GET", 418, `{}`, []Violation{{In: "status", Message: "status code 418 is not documented"}}},
+		{"wrong field type", ResponseValidationOptions{}, "GET", 200, `{"id":"1","name":"ada"}`, []Violation{{In: "response", Field: "id", Message: "must be of type integer, got string"}}},
+		{"undocumented field", ResponseValidationOptions{}, "GET", 200, `{"id":1,"name":"ada","admin":true}`, []Violation{{In: "response", Field: "admin", Message: "is not documented"}}},
+		{"undocumented field allowed", ResponseValidationOptions{AllowUndocumentedFields: true}, "GET", 200, `{"id":1,"name":"ada","admin":true}`, nil},
+		{"missing body", ResponseValidationOptions{}, "GET", 200, ``, []Violation{{In: "response", Message: "body is missing"}}},
+		{"no content", ResponseValidationOptions{}, "DELETE", 204, ``, nil},
+		{"unexpected body", ResponseValidationOptions{}, "DELETE", 204, `{"deleted":true}`, []Violation{{In: "response", Message: "body is not documented"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported []Violation
+			opts := tt.opts
+			opts.OnViolation = func(r *http.Request, status int, violations []Violation) {
+				reported = violations
+			}
+			handler := g.ResponseValidationMiddleware(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, "/users/1", nil))
+
+			if w.Code != tt.status || w.Body.String() != tt.body {
+				t.Errorf("response changed to %d %s", w.Code, w.Body)
+			}
+			if !reflect.DeepEqual(reported, tt.violations) {
+				t.Errorf("violations = %v\nwant %v", reported, tt.violations)
+			}
+		})
+	}
+}
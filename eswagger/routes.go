@@ -0,0 +1,273 @@
+package eswagger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// Route is a route as seen by the generator, independent of the router it
+// was registered on
+type Route struct {
+	Path        string      // OpenAPI path template, e.g. /users/{id}
+	Methods     []string    // upper-case HTTP methods
+	Handler     interface{} // http.Handler or the router's own handler type
+	HandlerName string      // full function name, used when Handler can't be inspected
+	Name        string      // route name, when the router supports it
+
+	// Matchers beyond path and method, as supported by gorilla/mux
+	Host    string            // host template, e.g. {tenant}.example.com
+	Schemes []string          // e.g. https
+	Queries map[string]string // query key -> value template, e.g. page -> {page:[0-9]+}
+	Headers map[string]string // header -> exact value, empty for any value
+	// HeaderPatterns holds headers matched against a regular expression
+	HeaderPatterns map[string]string
+
+	// Unread lists what couldn't be read from the router, e.g. after an
+	// upgrade changed its internals. Reported as DiagUnreadMatchers.
+	Unread []string
+}
+
+// RouteSource lists the routes of a router
+type RouteSource interface {
+	Routes() ([]Route, error)
+}
+
+// RouteSourceFunc adapts a function to a RouteSource
+type RouteSourceFunc func() ([]Route, error)
+
+func (f RouteSourceFunc) Routes() ([]Route, error) {
+	return f()
+}
+
+// handlerFullName returns the function name of the route handler, e.g.
+// "main/pkg/service.CreateUser.func1"
+func (r Route) handlerFullName() string {
+	if r.HandlerName != "" {
+		return r.HandlerName
+	}
+	return funcName(r.Handler)
+}
+
+// MuxRoutes lists the routes of a gorilla/mux router
+func MuxRoutes(router *mux.Router) RouteSource {
+	return RouteSourceFunc(func() ([]Route, error) {
+		var routes []Route
+
+		err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+			pathTemplate, err := route.GetPathTemplate()
+			if err != nil {
+				return nil
+			}
+
+			// Routes without methods are subrouters or catch-all handlers,
+			// catch-all handlers are kept so the generator can report them.
+			// A subrouter has no handler, its routes are walked on their own.
+			methods, _ := route.GetMethods()
+			if len(methods) == 0 && route.GetHandler() == nil {
+				return nil
+			}
+
+			r := Route{
+				Path:    pathTemplate,
+				Methods: methods,
+				Handler: route.GetHandler(),
+				Name:    route.GetName(),
+			}
+			r.Host, _ = route.GetHostTemplate()
+
+			if queries, err := route.GetQueriesTemplates(); err == nil && len(queries) > 0 {
+				r.Queries = make(map[string]string, len(queries))
+				for _, query := range queries {
+					key, value, _ := strings.Cut(query, "=")
+					r.Queries[key] = value
+				}
+			}
+
+			if err := readMuxMatchers(route, &r); err != nil {
+				r.Unread = append(r.Unread, "header and scheme matchers: "+err.Error())
+			}
+
+			routes = append(routes, r)
+			return nil
+		})
+
+		return routes, err
+	})
+}
+
+// readMuxMatchers reads the header and scheme matchers of a mux route. mux has
+// no accessor for them, so the unexported matcher list is inspected: this was
+// written against gorilla/mux v1.8.1, the version pinned in go.mod. Every step
+// is checked and a layout it doesn't know is returned as an error rather than
+// read wrong, the generator reports it as DiagUnreadMatchers.
+func readMuxMatchers(route *mux.Route, r *Route) error {
+	value := reflect.ValueOf(route)
+	if !value.IsValid() || value.IsNil() {
+		return nil
+	}
+	matchers := value.Elem().FieldByName("matchers")
+	if !matchers.IsValid() || matchers.Kind() != reflect.Slice {
+		return errors.New("mux.Route has no matchers slice")
+	}
+
+	for i := 0; i < matchers.Len(); i++ {
+		matcher := matchers.Index(i)
+		if matcher.Kind() == reflect.Interface {
+			matcher = matcher.Elem()
+		}
+		if !matcher.IsValid() {
+			continue
+		}
+
+		switch name := matcher.Type().Name(); name {
+		case "headerMatcher":
+			headers, err := readStringMap(matcher, name)
+			if err != nil {
+				return err
+			}
+			if r.Headers == nil {
+				r.Headers = make(map[string]string)
+			}
+			for key, value := range headers {
+				r.Headers[key] = value
+			}
+		case "headerRegexMatcher":
+			if matcher.Kind() != reflect.Map || matcher.Type().Key().Kind() != reflect.String {
+				return fmt.Errorf("mux %s is a %s, expected a map", name, matcher.Type())
+			}
+			if r.HeaderPatterns == nil {
+				r.HeaderPatterns = make(map[string]string)
+			}
+			iter := matcher.MapRange()
+			for iter.Next() {
+				pattern := ""
+				if re := iter.Value(); re.Kind() == reflect.Ptr && !re.IsNil() {
+					expr := re.Elem().FieldByName("expr")
+					if !expr.IsValid() || expr.Kind() != reflect.String {
+						return fmt.Errorf("mux %s values aren't *regexp.Regexp", name)
+					}
+					pattern = expr.String()
+				}
+				r.HeaderPatterns[iter.Key().String()] = pattern
+			}
+		case "schemeMatcher":
+			if matcher.Kind() != reflect.Slice || matcher.Type().Elem().Kind() != reflect.String {
+				return fmt.Errorf("mux %s is a %s, expected a string slice", name, matcher.Type())
+			}
+			for j := 0; j < matcher.Len(); j++ {
+				r.Schemes = append(r.Schemes, matcher.Index(j).String())
+			}
+		}
+	}
+	return nil
+}
+
+// readStringMap reads a map[string]string matcher
+func readStringMap(matcher reflect.Value, name string) (map[string]string, error) {
+	t := matcher.Type()
+	if matcher.Kind() != reflect.Map || t.Key().Kind() != reflect.String || t.Elem().Kind() != reflect.String {
+		return nil, fmt.Errorf("mux %s is a %s, expected a string map", name, t)
+	}
+	values := make(map[string]string, matcher.Len())
+	iter := matcher.MapRange()
+	for iter.Next() {
+		values[iter.Key().String()] = iter.Value().String()
+	}
+	return values, nil
+}
+
+// ConvertPathTemplate turns the path syntax used by other routers into an
+// OpenAPI path template:
+//
+//	/users/:id           -> /users/{id}
+//	/files/*filepath     -> /files/{filepath}
+//	/static/*            -> /static/{wildcard}
+//	/users/{id:[0-9]+}   -> /users/{id}
+func ConvertPathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = "{" + segment[1:] + "}"
+		case segment == "*":
+			segments[i] = "{wildcard}"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "{" + segment[1:] + "}"
+		case strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			name, _, _ := strings.Cut(segment[1:len(segment)-1], ":")
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// WithPathPrefix keeps the routes of a source registered under prefix, so a
+// single router can produce one spec per subrouter, e.g. "/api/v1/admin"
+func WithPathPrefix(source RouteSource, prefix string) RouteSource {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return RouteSourceFunc(func() ([]Route, error) {
+		routes, err := source.Routes()
+		if err != nil {
+			return nil, err
+		}
+
+		var filtered []Route
+		for _, route := range routes {
+			if hasPathPrefix(route.Path, prefix) {
+				filtered = append(filtered, route)
+			}
+		}
+		return filtered, nil
+	})
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it, matching
+// whole segments only
+func hasPathPrefix(path, prefix string) bool {
+	if prefix == "" || prefix == "/" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// commonPathPrefix returns the literal leading segments shared by all paths,
+// leaving at least one segment to every path
+func commonPathPrefix(paths []string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	var common []string
+	for i, path := range paths {
+		segments := strings.Split(strings.Trim(path, "/"), "/")
+		// The last segment always stays part of the path
+		segments = segments[:len(segments)-1]
+
+		if i == 0 {
+			common = segments
+			continue
+		}
+
+		n := 0
+		for n < len(common) && n < len(segments) && common[n] == segments[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	for n, segment := range common {
+		if strings.Contains(segment, "{") {
+			common = common[:n]
+			break
+		}
+	}
+
+	if len(common) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(common, "/")
+}
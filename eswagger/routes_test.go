@@ -0,0 +1,25 @@
+package eswagger
+
+import "testing"
+
+func TestConvertPathTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/users", "/users"},
+		{"/users/:id", "/users/{id}"},
+		{"/users/:id/files/*filepath", "/users/{id}/files/{filepath}"},
+		{"/static/*", "/static/{wildcard}"},
+		{"/users/{id:[0-9]+}", "/users/{id}"},
+		{"/users/{id}", "/users/{id}"},
+		{"/", "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := ConvertPathTemplate(tt.path); got != tt.want {
+				t.Errorf("ConvertPathTemplate(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
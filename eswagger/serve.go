@@ -0,0 +1,507 @@
+package eswagger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/spec"
+	httpSwagger "github.com/swaggo/http-swagger"
+)
+
+// redoc.standalone.js is ReDoc 2.0.0-rc.59, MIT licensed, see ui/redoc.LICENSE
+//
+//go:embed ui/*.html ui/redoc.standalone.js
+var uiAssets embed.FS
+
+// The pages of the UIs, parsed once
+var (
+	redocTemplate = template.Must(template.ParseFS(uiAssets, "ui/redoc.html"))
+	plainTemplate = template.Must(template.ParseFS(uiAssets, "ui/plain.html"))
+)
+
+// UI selects the documentation renderer mounted by Handler
+type UI string
+
+const (
+	UISwaggerUI UI = "swagger-ui" // Swagger UI, assets embedded through swaggo/files
+	UIReDoc     UI = "redoc"      // ReDoc, the bundle is embedded
+	UIPlain     UI = "plain"      // static HTML rendered on the server, no JavaScript
+	UINone      UI = "none"
+)
+
+// HandlerOptions configures Handler
+type HandlerOptions struct {
+	// SpecPath is the path of the spec without extension, it is served as
+	// SpecPath, SpecPath+".json" and SpecPath+".yaml". Defaults to /swagger.
+	SpecPath string
+
+	// DocsPath is where the UI is mounted, defaults to SpecPath+"/"
+	DocsPath string
+
+	// UI defaults to UISwaggerUI
+	UI UI
+
+	// ReDocBundle replaces the embedded redoc.standalone.js of UIReDoc,
+	// e.g. with another ReDoc release
+	ReDocBundle fs.FS
+
+	// CORSOrigins are allowed to fetch the spec, "*" allows any origin
+	CORSOrigins []string
+}
+
+func (o HandlerOptions) withDefaults() HandlerOptions {
+	if o.SpecPath == "" {
+		o.SpecPath = "/swagger"
+	}
+	o.SpecPath = "/" + strings.Trim(o.SpecPath, "/")
+	if o.DocsPath == "" {
+		o.DocsPath = o.SpecPath + "/"
+	}
+	if !strings.HasSuffix(o.DocsPath, "/") {
+		o.DocsPath += "/"
+	}
+	if o.UI == "" {
+		o.UI = UISwaggerUI
+	}
+	return o
+}
+
+// encodedSpec is the spec encoded in one format, with its gzip variant
+type encodedSpec struct {
+	data        []byte
+	gzipped     []byte
+	etag        string
+	contentType string
+}
+
+// Handler serves the spec and the documentation UI. The spec is served as
+// JSON or YAML depending on the extension or the Accept header, encodings are
+// cached until the spec changes and support ETag and gzip.
+//
+//	r.PathPrefix("/swagger").Handler(swaggerGen.Handler())
+func (g *Generator) Handler(options ...HandlerOptions) http.Handler {
+	var opts HandlerOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	opts = opts.withDefaults()
+
+	h := &specHandler{
+		generator: g,
+		opts:      opts,
+		cache:     make(map[string]*encodedSpec),
+	}
+
+	switch opts.UI {
+	case UISwaggerUI:
+		h.ui = httpSwagger.Handler(
+			httpSwagger.URL(opts.SpecPath+".json"),
+			httpSwagger.DeepLinking(true),
+		)
+	case UIReDoc:
+		h.ui = h.redocHandler()
+	case UIPlain:
+		h.ui = http.HandlerFunc(h.servePlain)
+	}
+
+	return h
+}
+
+type specHandler struct {
+	generator *Generator
+	opts      HandlerOptions
+	ui        http.Handler
+
+	mu       sync.Mutex
+	revision uint64
+	cache    map[string]*encodedSpec // format -> encoding
+}
+
+func (h *specHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.setCORS(w, r)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch r.URL.Path {
+	case h.opts.SpecPath + ".json":
+		h.serveSpec(w, r, "json")
+		return
+	case h.opts.SpecPath + ".yaml", h.opts.SpecPath + ".yml":
+		h.serveSpec(w, r, "yaml")
+		return
+	case h.opts.SpecPath:
+		h.serveSpec(w, r, negotiateFormat(r.Header.Get("Accept")))
+		return
+	}
+
+	if h.ui != nil && strings.HasPrefix(r.URL.Path, h.opts.DocsPath) {
+		h.ui.ServeHTTP(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// negotiateFormat picks YAML when the client asks for it, JSON otherwise
+func negotiateFormat(accept string) string {
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType = strings.TrimSpace(strings.Split(mediaType, ";")[0])
+		switch mediaType {
+		case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+			return "yaml"
+		case "application/json":
+			return "json"
+		}
+	}
+	return "json"
+}
+
+func (h *specHandler) setCORS(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(h.opts.CORSOrigins) == 0 {
+		return
+	}
+
+	for _, allowed := range h.opts.CORSOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			if allowed == "*" {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Accept-Encoding, If-None-Match")
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
+			return
+		}
+	}
+}
+
+func (h *specHandler) serveSpec(w http.ResponseWriter, r *http.Request, format string) {
+	encoded, err := h.encoded(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", encoded.etag)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", encoded.contentType)
+
+	if etagMatches(r.Header.Get("If-None-Match"), encoded.etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	data := encoded.data
+	if acceptsGzip(r.Header.Get("Accept-Encoding")) {
+		w.Header().Set("Content-Encoding", "gzip")
+		data = encoded.gzipped
+	}
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	w.Write(data)
+}
+
+// encoded returns the cached encoding of the spec, re-encoding it when the
+// generator revision moved
+func (h *specHandler) encoded(format string) (*encodedSpec, error) {
+	swagger, revision := h.generator.snapshot()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.revision != revision {
+		h.cache = make(map[string]*encodedSpec)
+		h.revision = revision
+	}
+	if encoded, ok := h.cache[format]; ok {
+		return encoded, nil
+	}
+
+	data, err := MarshalSpec(swagger, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	zw.Write(data)
+	zw.Close()
+
+	sum := sha256.Sum256(data)
+	encoded := &encodedSpec{
+		data:        data,
+		gzipped:     gzipped.Bytes(),
+		etag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+		contentType: "application/json; charset=utf-8",
+	}
+	if format == "yaml" {
+		encoded.contentType = "application/yaml; charset=utf-8"
+	}
+
+	h.cache[format] = encoded
+	return encoded, nil
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func acceptsGzip(acceptEncoding string) bool {
+	for _, encoding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+		if strings.TrimSpace(name) == "gzip" && strings.ReplaceAll(params, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *specHandler) redocHandler() http.Handler {
+	bundle := h.opts.ReDocBundle
+	if bundle == nil {
+		bundle, _ = fs.Sub(uiAssets, "ui")
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch strings.TrimPrefix(r.URL.Path, h.opts.DocsPath) {
+		case "", "index.html":
+			swagger, _ := h.generator.snapshot()
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			redocTemplate.Execute(w, map[string]string{
+				"Title":   swagger.Info.Title,
+				"SpecURL": h.opts.SpecPath + ".json",
+			})
+		case "redoc.standalone.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			http.ServeFileFS(w, r, bundle, "redoc.standalone.js")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// plainPage is the data of the plain HTML renderer
+type plainPage struct {
+	Title, Version, Description, BaseURL, SpecURL string
+	Groups                                        []plainGroup
+	Definitions                                   []plainDefinition
+}
+
+type plainGroup struct {
+	Name, Description string
+	Operations        []plainOperation
+}
+
+type plainOperation struct {
+	Method, Path, Summary, Description string
+	Deprecated                         bool
+	Parameters                         []plainParameter
+	Responses                          []plainResponse
+}
+
+type plainParameter struct {
+	Name, In, Type, Description string
+	Required                    bool
+}
+
+type plainResponse struct {
+	Status, Description, Type string
+}
+
+type plainDefinition struct {
+	Name, Schema string
+}
+
+func (h *specHandler) servePlain(w http.ResponseWriter, r *http.Request) {
+	if rest := strings.TrimPrefix(r.URL.Path, h.opts.DocsPath); rest != "" && rest != "index.html" {
+		http.NotFound(w, r)
+		return
+	}
+
+	swagger, _ := h.generator.snapshot()
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := plainTemplate.Execute(w, buildPlainPage(swagger, h.opts.SpecPath+".json")); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func buildPlainPage(swagger *spec.Swagger, specURL string) plainPage {
+	page := plainPage{SpecURL: specURL}
+	if swagger.Info != nil {
+		page.Title = swagger.Info.Title
+		page.Version = swagger.Info.Version
+		page.Description = swagger.Info.Description
+	}
+	if swagger.Host != "" {
+		scheme := "https"
+		if len(swagger.Schemes) > 0 {
+			scheme = swagger.Schemes[0]
+		}
+		page.BaseURL = scheme + "://" + swagger.Host + swagger.BasePath
+	} else {
+		page.BaseURL = swagger.BasePath
+	}
+
+	groups := make(map[string]*plainGroup)
+	var order []string
+	for _, tag := range swagger.Tags {
+		groups[tag.Name] = &plainGroup{Name: tag.Name, Description: tag.Description}
+		order = append(order, tag.Name)
+	}
+
+	paths := make([]string, 0)
+	if swagger.Paths != nil {
+		for path := range swagger.Paths.Paths {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		operations := operationsOf(swagger.Paths.Paths[path])
+		for _, method := range sortedMethods(operations) {
+			operation := operations[method]
+
+			tag := "default"
+			if len(operation.Tags) > 0 {
+				tag = operation.Tags[0]
+			}
+			group, ok := groups[tag]
+			if !ok {
+				group = &plainGroup{Name: tag}
+				groups[tag] = group
+				order = append(order, tag)
+			}
+
+			group.Operations = append(group.Operations, plainOperationOf(method, path, operation))
+		}
+	}
+
+	for _, name := range order {
+		if len(groups[name].Operations) > 0 {
+			page.Groups = append(page.Groups, *groups[name])
+		}
+	}
+
+	names := make([]string, 0, len(swagger.Definitions))
+	for name := range swagger.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, _ := json.MarshalIndent(swagger.Definitions[name], "", "  ")
+		page.Definitions = append(page.Definitions, plainDefinition{Name: name, Schema: string(data)})
+	}
+
+	return page
+}
+
+func plainOperationOf(method, path string, operation *spec.Operation) plainOperation {
+	result := plainOperation{
+		Method:      strings.ToLower(method),
+		Path:        path,
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		Deprecated:  operation.Deprecated,
+	}
+
+	for _, param := range operation.Parameters {
+		paramType := param.Type
+		if param.Schema != nil {
+			paramType = schemaTypeName(param.Schema)
+		}
+		result.Parameters = append(result.Parameters, plainParameter{
+			Name:        param.Name,
+			In:          param.In,
+			Type:        paramType,
+			Description: param.Description,
+			Required:    param.Required,
+		})
+	}
+
+	if operation.Responses != nil {
+		codes := make([]int, 0, len(operation.Responses.StatusCodeResponses))
+		for code := range operation.Responses.StatusCodeResponses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			response := operation.Responses.StatusCodeResponses[code]
+			result.Responses = append(result.Responses, plainResponse{
+				Status:      fmt.Sprint(code),
+				Description: response.Description,
+				Type:        schemaTypeName(response.Schema),
+			})
+		}
+		if operation.Responses.Default != nil {
+			result.Responses = append(result.Responses, plainResponse{
+				Status:      "default",
+				Description: operation.Responses.Default.Description,
+				Type:        schemaTypeName(operation.Responses.Default.Schema),
+			})
+		}
+	}
+
+	return result
+}
+
+// schemaTypeName is a short human description of a schema, e.g. "User" or
+// "array of User"
+func schemaTypeName(schema *spec.Schema) string {
+	if schema == nil {
+		return ""
+	}
+	if ref := schema.Ref.String(); ref != "" {
+		return ref[strings.LastIndex(ref, "/")+1:]
+	}
+	if schema.Type.Contains("array") && schema.Items != nil && schema.Items.Schema != nil {
+		return "array of " + schemaTypeName(schema.Items.Schema)
+	}
+	return strings.Join(schema.Type, ", ")
+}
+
+var methodOrder = map[string]int{"GET": 0, "POST": 1, "PUT": 2, "PATCH": 3, "DELETE": 4, "HEAD": 5, "OPTIONS": 6}
+
+// sortedMethods returns the methods of an operation map in a stable order
+func sortedMethods(operations map[string]*spec.Operation) []string {
+	methods := make([]string, 0, len(operations))
+	for method := range operations {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		return methodOrder[methods[i]] < methodOrder[methods[j]]
+	})
+	return methods
+}
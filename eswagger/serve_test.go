@@ -0,0 +1,177 @@
+package eswagger
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gorilla/mux"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "json"},
+		{"application/json", "json"},
+		{"application/yaml", "yaml"},
+		{"text/html, application/x-yaml;q=0.9", "yaml"},
+		{"application/json, application/yaml", "json"},
+		{"*/*", "json"},
+	}
+	for _, tt := range tests {
+		if got := negotiateFormat(tt.accept); got != tt.want {
+			t.Errorf("negotiateFormat(%q) = %q, want %q", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{"*", true},
+		{`"xyz"`, false},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.ifNoneMatch, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %v, want %v", tt.ifNoneMatch, got, tt.want)
+		}
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"br, gzip;q=0.8", true},
+		{"gzip; q=0", false},
+		{"deflate", false},
+	}
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.acceptEncoding); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		options     HandlerOptions
+		method      string
+		target      string
+		header      http.Header
+		status      int
+		contentType string
+		contains    string
+	}{
+		{"json", HandlerOptions{}, "GET", "/swagger.json", nil, 200, "application/json; charset=utf-8", `"title": "Users"`},
+		{"yaml", HandlerOptions{}, "GET", "/swagger.yaml", nil, 200, "application/yaml; charset=utf-8", "title: Users"},
+		{"yml", HandlerOptions{}, "GET", "/swagger.yml", nil, 200, "application/yaml; charset=utf-8", "title: Users"},
+		{"negotiated", HandlerOptions{}, "GET", "/swagger", http.Header{"Accept": {"application/yaml"}}, 200, "application/yaml; charset=utf-8", "title: Users"},
+		{"head", HandlerOptions{}, "HEAD", "/swagger.json", nil, 200, "application/json; charset=utf-8", ""},
+		{"method not allowed", HandlerOptions{}, "POST", "/swagger.json", nil, 405, "", ""},
+		{"custom spec path", HandlerOptions{SpecPath: "docs/openapi/"}, "GET", "/docs/openapi.json", nil, 200, "application/json; charset=utf-8", `"swagger": "2.0"`},
+		{"swagger ui", HandlerOptions{}, "GET", "/swagger/index.html", nil, 200, "text/html; charset=utf-8", "swagger-ui"},
+		{"redoc", HandlerOptions{UI: UIReDoc}, "GET", "/swagger/", nil, 200, "text/html; charset=utf-8", "/swagger.json"},
+		{"redoc bundle", HandlerOptions{UI: UIReDoc}, "GET", "/swagger/redoc.standalone.js", nil, 200, "application/javascript", ""},
+		{"replaced redoc bundle", HandlerOptions{UI: UIReDoc, ReDocBundle: fstest.MapFS{"redoc.standalone.js": {Data: []byte("/* custom */")}}}, "GET", "/swagger/redoc.standalone.js", nil, 200, "application/javascript", "/* custom */"},
+		{"plain", HandlerOptions{UI: UIPlain}, "GET", "/swagger/", nil, 200, "text/html; charset=utf-8", "/users"},
+		{"no ui", HandlerOptions{UI: UINone}, "GET", "/swagger/", nil, 404, "", ""},
+		{"preflight", HandlerOptions{CORSOrigins: []string{"*"}}, "OPTIONS", "/swagger.json", http.Header{"Origin": {"https://example.com"}}, 204, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			for key, values := range tt.header {
+				r.Header[key] = values
+			}
+			w := httptest.NewRecorder()
+			g.Handler(tt.options).ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.contentType != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("content type = %q, want %q", w.Header().Get("Content-Type"), tt.contentType)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("body does not contain %q:\n%.300s", tt.contains, w.Body)
+			}
+		})
+	}
+}
+
+func TestHandlerCaching(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users", noop).Methods("GET")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	handler := g.Handler(HandlerOptions{CORSOrigins: []string{"https://docs.example.com"}})
+
+	get := func(header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/swagger.json", nil)
+		for key, values := range header {
+			r.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := get(http.Header{"Origin": {"https://docs.example.com"}})
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+	if origin := first.Header().Get("Access-Control-Allow-Origin"); origin != "https://docs.example.com" {
+		t.Errorf("allowed origin = %q", origin)
+	}
+	if origin := get(http.Header{"Origin": {"https://evil.example.com"}}).Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("allowed origin %q for an unknown origin", origin)
+	}
+
+	if w := get(http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("status = %d, want 304", w.Code)
+	}
+	if w := get(http.Header{"Accept-Encoding": {"gzip"}}); w.Header().Get("Content-Encoding") != "gzip" {
+		t.Error("response not gzipped")
+	}
+
+	router.HandleFunc("/orders", noop).Methods("GET")
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	w := get(http.Header{"If-None-Match": {etag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("got %d with ETag %s after the spec changed", w.Code, w.Header().Get("ETag"))
+	}
+	if !strings.Contains(w.Body.String(), "/orders") {
+		t.Error("served spec is stale")
+	}
+}
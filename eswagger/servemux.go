@@ -0,0 +1,102 @@
+package eswagger
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ServeMux wraps an http.ServeMux and records the patterns registered on it.
+// A ServeMux can't be walked, so routes must be registered through the
+// wrapper to be documented.
+type ServeMux struct {
+	*http.ServeMux
+
+	mu     sync.Mutex
+	routes []Route
+}
+
+var _ RouteSource = (*ServeMux)(nil)
+
+func NewServeMux() *ServeMux {
+	return &ServeMux{ServeMux: http.NewServeMux()}
+}
+
+// Handle registers the handler like http.ServeMux.Handle and records the route
+func (m *ServeMux) Handle(pattern string, handler http.Handler) {
+	route, names := parseServeMuxPattern(pattern)
+	route.Handler = handler
+
+	if len(names) > 0 {
+		handler = withPathParams(handler, names)
+	}
+	m.ServeMux.Handle(pattern, handler)
+
+	m.mu.Lock()
+	m.routes = append(m.routes, route)
+	m.mu.Unlock()
+}
+
+// HandleFunc registers the handler like http.ServeMux.HandleFunc and records
+// the route
+func (m *ServeMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// Routes returns the routes registered so far
+func (m *ServeMux) Routes() ([]Route, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	routes := make([]Route, len(m.routes))
+	copy(routes, m.routes)
+	return routes, nil
+}
+
+// withPathParams exposes the wildcard names of the pattern to typed handlers
+func withPathParams(handler http.Handler, names []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), pathParamsKey{}, names)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+var serveMuxWildcard = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// parseServeMuxPattern translates a Go 1.22 pattern "[METHOD ][HOST]/path"
+// into a route. A wildcard {name} stays {name}, {name...} matches the rest of
+// the path and becomes {name:.*}, the {$} end anchor is dropped.
+func parseServeMuxPattern(pattern string) (Route, []string) {
+	method, rest := splitPattern(pattern)
+
+	// Anything before the first slash is the host
+	host, path := "", rest
+	if i := strings.Index(rest, "/"); i > 0 {
+		host, path = rest[:i], rest[i:]
+	}
+
+	var names []string
+	path = serveMuxWildcard.ReplaceAllStringFunc(path, func(wildcard string) string {
+		name := strings.Trim(wildcard, "{}")
+		if name == "$" {
+			return ""
+		}
+		if strings.HasSuffix(name, "...") {
+			name = strings.TrimSuffix(name, "...")
+			names = append(names, name)
+			return "{" + name + ":.*}"
+		}
+		names = append(names, name)
+		return "{" + name + "}"
+	})
+
+	// Patterns without a method match every method, like mux routes without
+	// Methods() they are left undocumented
+	route := Route{Path: path, Host: host}
+	if method != "" {
+		route.Methods = []string{method}
+	}
+	return route, names
+}
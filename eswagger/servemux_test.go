@@ -0,0 +1,116 @@
+package eswagger
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseServeMuxPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		path    string
+		methods []string
+		names   []string
+	}{
+		{"/users", "", "/users", nil, nil},
+		{"GET /users/{id}", "", "/users/{id}", []string{"GET"}, []string{"id"}},
+		{"POST example.com/users/{id}/files/{path...}", "example.com", "/users/{id}/files/{path:.*}", []string{"POST"}, []string{"id", "path"}},
+		{"GET /{$}", "", "/", []string{"GET"}, nil},
+		{"delete /users/{id}", "", "/users/{id}", []string{"DELETE"}, []string{"id"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			route, names := parseServeMuxPattern(tt.pattern)
+			if route.Host != tt.host || route.Path != tt.path {
+				t.Errorf("host, path = %q, %q, want %q, %q", route.Host, route.Path, tt.host, tt.path)
+			}
+			if !reflect.DeepEqual(route.Methods, tt.methods) {
+				t.Errorf("methods = %q, want %q", route.Methods, tt.methods)
+			}
+			if !reflect.DeepEqual(names, tt.names) {
+				t.Errorf("names = %q, want %q", names, tt.names)
+			}
+		})
+	}
+}
+
+func TestServeMux(t *testing.T) {
+	mux := NewServeMux()
+	mux.Handle("GET /users/{id}", NewHandler(func(ctx context.Context, id int) (handleUser, error) {
+		return handleUser{ID: id, Name: "ada"}, nil
+	}))
+	mux.Handle("PUT /users/{id}", NewHandler(func(ctx context.Context, req updateUserRequest) (handleUser, error) {
+		return handleUser{ID: req.ID, Name: req.Name}, nil
+	}))
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+
+	routes, err := mux.Routes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) != 3 {
+		t.Fatalf("recorded %d routes, want 3", len(routes))
+	}
+
+	tests := []struct {
+		method, target, body string
+		status               int
+		response             string
+	}{
+		{"GET", "/users/4", "", 200, `{"id":4,"name":"ada"}`},
+		{"PUT", "/users/5?version=1", `{"name":"bob"}`, 200, `{"id":5,"name":"bob"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if w.Code != tt.status || strings.TrimSpace(w.Body.String()) != tt.response {
+				t.Errorf("got %d %s, want %d %s", w.Code, w.Body, tt.status, tt.response)
+			}
+		})
+	}
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	diagnostics, err := g.GenerateFromRoutes(mux, RouteMetadata{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item, ok := g.GetSwaggerSpec().Paths.Paths["/users/{id}"]
+	if !ok || item.Get == nil || item.Put == nil {
+		t.Errorf("/users/{id} = %+v, want GET and PUT", item)
+	}
+	if len(diagnostics.Of(DiagNoMethods)) != 1 {
+		t.Errorf("diagnostics = %v, want /health reported without methods", diagnostics)
+	}
+}
+
+func TestServeMuxRemainder(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("GET /files/{path...}", func(w http.ResponseWriter, r *http.Request) {})
+
+	g := NewGenerator(Config{Title: "Files", Version: "1.0.0"})
+	if _, err := g.GenerateFromRoutes(mux, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	item, ok := g.GetSwaggerSpec().Paths.Paths["/files/{path}"]
+	if !ok || item.Get == nil || len(item.Get.Parameters) != 1 {
+		t.Fatalf("/files/{path} = %+v, want GET with the path parameter", item)
+	}
+	param := item.Get.Parameters[0]
+	if remainder, _ := param.Extensions.GetBool("x-remainder"); !remainder || param.Pattern != "" {
+		t.Errorf("parameter = %+v, want a remainder without pattern", param)
+	}
+
+	// Unmatched requests pass the validation middleware unchecked
+	for _, path := range []string{"a.txt", "docs/a.txt"} {
+		match := g.findOperation(httptest.NewRequest("GET", "/files/"+path, nil))
+		if match == nil || match.Vars["path"] != path {
+			t.Errorf("GET /files/%s matched %+v, want path %q", path, match, path)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package eswagger
+
+import (
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// sourceDocs reads doc comments from the Go source of the packages declaring
+// the documented interfaces and types. Packages are loaded on first use and
+// kept for later builds.
+type sourceDocs struct {
+	mu       sync.Mutex
+	packages map[string]*packageDocs // import path -> docs, nil when it couldn't be loaded
+}
+
+// packageDocs holds the comments of one package, keyed by "Type", "Func",
+// "Type.Method" or "Type.Field"
+type packageDocs struct {
+	comments map[string]string
+}
+
+func newSourceDocs() *sourceDocs {
+	return &sourceDocs{packages: make(map[string]*packageDocs)}
+}
+
+// load returns the docs of a package, loading it with go/packages
+func (d *sourceDocs) load(importPath string) (*packageDocs, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if docs, ok := d.packages[importPath]; ok {
+		return docs, nil
+	}
+	// Failures are remembered too, go list is slow
+	d.packages[importPath] = nil
+
+	pkgs, err := packages.Load(&packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+	}, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading package %s: %v", importPath, err)
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("error loading package %s: %d packages found", importPath, len(pkgs))
+	}
+	if len(pkgs[0].Errors) > 0 {
+		return nil, fmt.Errorf("error loading package %s: %v", importPath, pkgs[0].Errors[0])
+	}
+
+	pkg, err := doc.NewFromFiles(pkgs[0].Fset, pkgs[0].Syntax, importPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading docs of package %s: %v", importPath, err)
+	}
+
+	docs := &packageDocs{comments: make(map[string]string)}
+	for _, f := range pkg.Funcs {
+		docs.comments[f.Name] = f.Doc
+	}
+	for _, t := range pkg.Types {
+		docs.comments[t.Name] = t.Doc
+		for _, f := range append(t.Funcs, t.Methods...) {
+			docs.comments[t.Name+"."+f.Name] = f.Doc
+		}
+		for _, spec := range t.Decl.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == t.Name {
+				docs.addMembers(t.Name, typeSpec.Type)
+			}
+		}
+	}
+
+	d.packages[importPath] = docs
+	return docs, nil
+}
+
+// addMembers records the comments of interface methods and struct fields,
+// a trailing line comment is used when there is no doc comment
+func (p *packageDocs) addMembers(typeName string, expr ast.Expr) {
+	var fields *ast.FieldList
+	switch t := expr.(type) {
+	case *ast.InterfaceType:
+		fields = t.Methods
+	case *ast.StructType:
+		fields = t.Fields
+	}
+	if fields == nil {
+		return
+	}
+
+	for _, field := range fields.List {
+		comment := field.Doc.Text()
+		if comment == "" {
+			comment = field.Comment.Text()
+		}
+		for _, name := range field.Names {
+			p.comments[typeName+"."+name.Name] = comment
+		}
+	}
+}
+
+// sourceComment returns the doc comment of a declaration of the package at
+// importPath, key is as described on packageDocs
+func (g *Generator) sourceComment(importPath, key string) string {
+	if g.docs == nil || importPath == "" {
+		return ""
+	}
+
+	docs, err := g.docs.load(importPath)
+	if err != nil {
+		g.logger().Warn("couldn't read doc comments", slog.Any("error", err))
+		return ""
+	}
+	if docs == nil {
+		return ""
+	}
+	return strings.TrimSpace(docs.comments[key])
+}
+
+// typeComment returns the doc comment of a named type
+func (g *Generator) typeComment(t reflect.Type) string {
+	return g.sourceComment(t.PkgPath(), t.Name())
+}
+
+// fieldComment returns the comment of a struct field
+func (g *Generator) fieldComment(t reflect.Type, field string) string {
+	if t.Name() == "" {
+		return ""
+	}
+	return g.sourceComment(t.PkgPath(), t.Name()+"."+field)
+}
+
+// funcComment returns the doc comment of a function given by its full name,
+// e.g. "main/pkg/service.CreateUser.func1" or "main/pkg/api.(*Server).GetUser-fm",
+// and the name it documents, e.g. CreateUser or GetUser
+func (g *Generator) funcComment(fullName string) (string, string) {
+	slash := strings.LastIndex(fullName, "/")
+	dot := strings.Index(fullName[slash+1:], ".")
+	if dot < 0 {
+		return "", ""
+	}
+	importPath := fullName[:slash+1+dot]
+
+	name := fullName[slash+1+dot+1:]
+	for {
+		// Generic instantiation, e.g. NewHandler[...].func1
+		open := strings.Index(name, "[")
+		end := strings.Index(name, "]")
+		if open < 0 || end < open {
+			break
+		}
+		name = name[:open] + name[end+1:]
+	}
+
+	var names []string
+	for _, part := range strings.Split(name, ".") {
+		if isClosureName(part) {
+			break
+		}
+		part = strings.TrimSuffix(part, "-fm")
+		part = strings.Trim(part, "(*)")
+		names = append(names, part)
+	}
+	if len(names) == 0 || len(names) > 2 {
+		return "", ""
+	}
+	return g.sourceComment(importPath, strings.Join(names, ".")), names[len(names)-1]
+}
+
+// commentSummary splits the doc comment of name into a summary, its first
+// sentence, and a description, what follows it. The Go convention of starting
+// with the name is dropped, "UpdateUser changes the username" is summarized as
+// "Changes the username".
+func commentSummary(comment, name string) (string, string) {
+	comment = strings.TrimSpace(comment)
+	if word, rest, ok := strings.Cut(comment, " "); ok && name != "" && word == name {
+		rest = strings.TrimLeft(rest, " ")
+		r, size := utf8.DecodeRuneInString(rest)
+		comment = string(unicode.ToUpper(r)) + rest[size:]
+	}
+	if comment == "" {
+		return "", ""
+	}
+
+	var pkg doc.Package
+	synopsis := pkg.Synopsis(comment)
+
+	// Synopsis joins the lines of the sentence, find where it ends
+	end := 0
+	for _, word := range strings.Fields(synopsis) {
+		i := strings.Index(comment[end:], word)
+		if i < 0 {
+			// Rewritten, e.g. a doc link, keep the whole comment
+			return strings.TrimSuffix(synopsis, "."), comment
+		}
+		end += i + len(word)
+	}
+	return strings.TrimSuffix(synopsis, "."), strings.TrimSpace(comment[end:])
+}
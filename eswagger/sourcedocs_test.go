@@ -0,0 +1,76 @@
+package eswagger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommentSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		comment     string
+		goName      string
+		summary     string
+		description string
+	}{
+		{"empty", "", "UpdateUser", "", ""},
+		{"one sentence", "UpdateUser changes the username.", "UpdateUser", "Changes the username", ""},
+		{"description", "UpdateUser changes the username.\nThe email is kept.", "UpdateUser", "Changes the username", "The email is kept."},
+		{"wrapped sentence", "DeleteUser removes a user\nand its data. It can't be undone.", "DeleteUser", "Removes a user and its data", "It can't be undone."},
+		{"not starting with the name", "Lists every user.", "ListUsers", "Lists every user", ""},
+		{"name alone", "ListUsers", "ListUsers", "ListUsers", ""},
+		{"unknown name", "ListUsers lists users.", "", "ListUsers lists users", ""},
+		{"doc link", "GetUser returns a [User].\nIt is cached.", "GetUser", "Returns a [User]", "It is cached."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, description := commentSummary(tt.comment, tt.goName)
+			if summary != tt.summary || description != tt.description {
+				t.Errorf("commentSummary() = %q, %q\nwant %q, %q", summary, description, tt.summary, tt.description)
+			}
+		})
+	}
+}
+
+func TestSourceComments(t *testing.T) {
+	g := NewGenerator(Config{SourceDocs: true})
+
+	tests := []struct {
+		name   string
+		lookup func() string
+		want   string
+	}{
+		{"type", func() string { return g.typeComment(reflect.TypeOf(HandlerOptions{})) }, "HandlerOptions configures Handler"},
+		{"field", func() string { return g.fieldComment(reflect.TypeOf(HandlerOptions{}), "ReDocBundle") }, "ReDocBundle replaces the embedded redoc.standalone.js of UIReDoc,\ne.g. with another ReDoc release"},
+		{"unnamed type", func() string { return g.fieldComment(reflect.TypeOf(struct{ A int }{}), "A") }, ""},
+		{"function", func() string {
+			comment, _ := g.funcComment("main/eswagger.NewHandler[...]")
+			return comment
+		}, "NewHandler wraps fn like Handle does, for routers other than gorilla/mux"},
+		{"method value", func() string {
+			comment, name := g.funcComment("main/eswagger.(*Generator).Regenerate-fm")
+			line, _, _ := strings.Cut(comment, "\n")
+			return name + ": " + line
+		}, "Regenerate: Regenerate walks the routes given to the last GenerateFromRouter call again"},
+		{"closure", func() string {
+			_, name := g.funcComment("main/eswagger.NewHandler[...].func1")
+			return name
+		}, "NewHandler"},
+		{"unknown package", func() string {
+			comment, _ := g.funcComment("example.com/missing.Handler")
+			return comment
+		}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.lookup(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if comment := NewGenerator(Config{}).typeComment(reflect.TypeOf(HandlerOptions{})); comment != "" {
+		t.Errorf("comment %q read with SourceDocs off", comment)
+	}
+}
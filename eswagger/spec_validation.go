@@ -0,0 +1,412 @@
+package eswagger
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-openapi/spec"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// The meta-schemas of the supported document versions, and the JSON Schema
+// draft 4 the Swagger 2.0 and OpenAPI 3.0 ones refer to
+var (
+	//go:embed schemas/swagger-2.0.json
+	swagger20Schema string
+	//go:embed schemas/openapi-3.0.json
+	openAPI30Schema string
+	//go:embed schemas/openapi-3.1.json
+	openAPI31Schema string
+	//go:embed schemas/jsonschema-draft-04.json
+	draft04Schema string
+)
+
+const draft04SchemaURL = "http://json-schema.org/draft-04/schema"
+
+// metaSchema is the schema of one document version, compiled on first use
+type metaSchema struct {
+	name   string
+	url    string
+	source string
+	draft  *jsonschema.Draft
+
+	once   sync.Once
+	schema *jsonschema.Schema
+	err    error
+}
+
+var (
+	swagger20MetaSchema = &metaSchema{name: "Swagger 2.0", url: "http://swagger.io/v2/schema.json", source: swagger20Schema, draft: jsonschema.Draft4}
+	openAPI30MetaSchema = &metaSchema{name: "OpenAPI 3.0", url: "https://spec.openapis.org/oas/3.0/schema/2021-09-28", source: openAPI30Schema, draft: jsonschema.Draft4}
+	openAPI31MetaSchema = &metaSchema{name: "OpenAPI 3.1", url: "https://spec.openapis.org/oas/3.1/schema/2022-10-07", source: openAPI31Schema, draft: jsonschema.Draft2020}
+)
+
+func (m *metaSchema) compile() (*jsonschema.Schema, error) {
+	m.once.Do(func() {
+		compiler := jsonschema.NewCompiler()
+		compiler.Draft = m.draft
+		if m.err = compiler.AddResource(draft04SchemaURL, strings.NewReader(draft04Schema)); m.err != nil {
+			return
+		}
+		if m.err = compiler.AddResource(m.url, strings.NewReader(m.source)); m.err != nil {
+			return
+		}
+		m.schema, m.err = compiler.Compile(m.url)
+	})
+	return m.schema, m.err
+}
+
+// documentMetaSchema picks the meta-schema from the swagger or openapi field
+// of a document, Swagger 2.0 when there is none
+func documentMetaSchema(document interface{}) (*metaSchema, error) {
+	fields, _ := document.(map[string]interface{})
+	version, ok := fields["openapi"].(string)
+	switch {
+	case !ok:
+		return swagger20MetaSchema, nil
+	case strings.HasPrefix(version, "3.0."):
+		return openAPI30MetaSchema, nil
+	case strings.HasPrefix(version, "3.1."):
+		return openAPI31MetaSchema, nil
+	}
+	return nil, fmt.Errorf("unsupported OpenAPI version %q, expected 3.0.x or 3.1.x", version)
+}
+
+// SpecViolation is a defect of a spec document. Pointer is the JSON pointer
+// of the offending element, e.g. "/paths/~1users/post/responses/201".
+type SpecViolation struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (v SpecViolation) String() string {
+	pointer := v.Pointer
+	if pointer == "" {
+		pointer = "/"
+	}
+	return fmt.Sprintf("%s: %s", pointer, v.Message)
+}
+
+// SpecViolations is the error returned when a spec document is invalid
+type SpecViolations []SpecViolation
+
+func (v SpecViolations) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid spec: %d violations:", len(v))
+	for _, violation := range v {
+		b.WriteString("\n  ")
+		b.WriteString(violation.String())
+	}
+	return b.String()
+}
+
+// Validate checks the generated spec against the Swagger 2.0 meta-schema,
+// resolves its references and checks its examples against their schemas. The
+// error is SpecViolations when the spec is invalid.
+func (g *Generator) Validate() error {
+	return ValidateSpec(g.GetSwaggerSpec())
+}
+
+// ValidateSpec checks a spec as described on Generator.Validate
+func ValidateSpec(swagger *spec.Swagger) error {
+	data, err := json.Marshal(swagger)
+	if err != nil {
+		return fmt.Errorf("error marshalling Swagger spec: %v", err)
+	}
+	return ValidateSpecDocument(data)
+}
+
+// ValidateSpecDocument checks a JSON or YAML spec document, such as one
+// written by SaveSwagger, as described on Generator.Validate. OpenAPI 3.0 and
+// 3.1 documents are checked against their own meta-schema, picked from the
+// openapi field, and their references, examples are only checked in Swagger
+// 2.0 documents.
+func ValidateSpecDocument(data []byte) error {
+	var decoded interface{}
+	if err := yaml.Unmarshal(data, &decoded); err != nil {
+		return fmt.Errorf("error parsing spec: %v", err)
+	}
+	encoded, err := json.Marshal(yamlToJSONValue(decoded))
+	if err != nil {
+		return fmt.Errorf("error parsing spec: %v", err)
+	}
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return fmt.Errorf("error parsing spec: %v", err)
+	}
+
+	meta, err := documentMetaSchema(document)
+	if err != nil {
+		return err
+	}
+	schema, err := meta.compile()
+	if err != nil {
+		return fmt.Errorf("error loading the %s meta-schema: %v", meta.name, err)
+	}
+
+	var violations SpecViolations
+	var validationErr *jsonschema.ValidationError
+	if err := schema.Validate(document); errors.As(err, &validationErr) {
+		violations = append(violations, metaSchemaViolations(validationErr)...)
+	} else if err != nil {
+		return err
+	}
+
+	violations = append(violations, refViolations(document, document, "")...)
+
+	// Examples are only checked once the document has the expected shape,
+	// the one of spec.Swagger
+	if meta == swagger20MetaSchema {
+		swagger := new(spec.Swagger)
+		if err := json.Unmarshal(encoded, swagger); err == nil {
+			violations = append(violations, exampleViolations(swagger)...)
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Pointer < violations[j].Pointer })
+	return violations
+}
+
+// metaSchemaViolations returns the innermost causes of a validation error.
+// A oneOf failing reports the causes of every alternative, only those of the
+// closest alternative are kept: the one with the fewest violations, going
+// the deepest into the document. The parameter alternatives are told apart
+// by "in", one rejecting it is never the closest.
+func metaSchemaViolations(err *jsonschema.ValidationError) []SpecViolation {
+	if len(err.Causes) == 0 {
+		// The library escapes the pointers as URI fragments
+		pointer, unescapeErr := url.PathUnescape(err.InstanceLocation)
+		if unescapeErr != nil {
+			pointer = err.InstanceLocation
+		}
+		return []SpecViolation{{Pointer: pointer, Message: err.Message}}
+	}
+
+	if strings.HasSuffix(err.KeywordLocation, "/oneOf") || strings.HasSuffix(err.KeywordLocation, "/anyOf") {
+		var best []SpecViolation
+		bestScore := 0
+		for _, cause := range err.Causes {
+			causes := metaSchemaViolations(cause)
+			score := len(causes)
+			for _, violation := range causes {
+				if strings.HasSuffix(violation.Pointer, "/in") {
+					score += 100
+				}
+			}
+			if best == nil || score < bestScore || score == bestScore && maxPointerDepth(causes) > maxPointerDepth(best) {
+				best, bestScore = causes, score
+			}
+		}
+		return best
+	}
+
+	var violations []SpecViolation
+	seen := make(map[SpecViolation]bool)
+	for _, cause := range err.Causes {
+		for _, violation := range metaSchemaViolations(cause) {
+			if !seen[violation] {
+				seen[violation] = true
+				violations = append(violations, violation)
+			}
+		}
+	}
+	return violations
+}
+
+func maxPointerDepth(violations []SpecViolation) int {
+	depth := 0
+	for _, violation := range violations {
+		if d := strings.Count(violation.Pointer, "/"); d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// refViolations lists the local references that don't resolve. References
+// to other documents aren't followed.
+func refViolations(document, value interface{}, pointer string) []SpecViolation {
+	var violations []SpecViolation
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#") {
+			if strings.HasSuffix(ref, "/") {
+				violations = append(violations, SpecViolation{pointer + "/$ref", fmt.Sprintf("$ref %q has an empty name", ref)})
+			} else if _, found := resolvePointer(document, ref[1:]); !found {
+				violations = append(violations, SpecViolation{pointer + "/$ref", fmt.Sprintf("$ref %q doesn't resolve", ref)})
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			// Examples and extensions are free form
+			if key == "example" || key == "examples" || strings.HasPrefix(key, "x-") {
+				continue
+			}
+			violations = append(violations, refViolations(document, v[key], pointer+"/"+escapePointerToken(key))...)
+		}
+	case []interface{}:
+		for i, item := range v {
+			violations = append(violations, refViolations(document, item, pointer+"/"+strconv.Itoa(i))...)
+		}
+	}
+	return violations
+}
+
+// resolvePointer returns the value at a JSON pointer of the document
+func resolvePointer(document interface{}, pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return document, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	value := document
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// exampleViolations checks the examples of the schemas, responses and body
+// parameters against their schema
+func exampleViolations(swagger *spec.Swagger) []SpecViolation {
+	var violations []SpecViolation
+	check := func(pointer string, schema *spec.Schema, example interface{}) {
+		validator := newSchemaValidator(swagger.Definitions, "example")
+		validator.validate(schema, example, "")
+		for _, violation := range validator.violations {
+			message := violation.Message
+			if violation.Field != "" {
+				message = violation.Field + " " + message
+			}
+			violations = append(violations, SpecViolation{pointer, "example " + message})
+		}
+	}
+
+	var walk func(pointer string, schema *spec.Schema)
+	walk = func(pointer string, schema *spec.Schema) {
+		if schema == nil {
+			return
+		}
+		if schema.Example != nil {
+			check(pointer+"/example", schema, schema.Example)
+		}
+		for _, name := range sortedMapKeys(schema.Properties) {
+			property := schema.Properties[name]
+			walk(pointer+"/properties/"+escapePointerToken(name), &property)
+		}
+		if schema.Items != nil {
+			walk(pointer+"/items", schema.Items.Schema)
+		}
+		if schema.AdditionalProperties != nil {
+			walk(pointer+"/additionalProperties", schema.AdditionalProperties.Schema)
+		}
+		for i := range schema.AllOf {
+			walk(pointer+"/allOf/"+strconv.Itoa(i), &schema.AllOf[i])
+		}
+	}
+	checkResponse := func(pointer string, response spec.Response) {
+		walk(pointer+"/schema", response.Schema)
+		if response.Schema == nil {
+			return
+		}
+		for _, mediaType := range sortedMapKeys(response.Examples) {
+			if strings.Contains(mediaType, "json") {
+				check(pointer+"/examples/"+escapePointerToken(mediaType), response.Schema, response.Examples[mediaType])
+			}
+		}
+	}
+	checkParameter := func(pointer string, param spec.Parameter) {
+		walk(pointer+"/schema", param.Schema)
+		if example, ok := param.Extensions["x-example"]; ok && param.Schema != nil {
+			check(pointer+"/x-example", param.Schema, example)
+		}
+	}
+
+	for _, name := range sortedMapKeys(swagger.Definitions) {
+		definition := swagger.Definitions[name]
+		walk("/definitions/"+escapePointerToken(name), &definition)
+	}
+	for _, name := range sortedMapKeys(swagger.Parameters) {
+		checkParameter("/parameters/"+escapePointerToken(name), swagger.Parameters[name])
+	}
+	for _, name := range sortedMapKeys(swagger.Responses) {
+		checkResponse("/responses/"+escapePointerToken(name), swagger.Responses[name])
+	}
+	if swagger.Paths == nil {
+		return violations
+	}
+	for _, path := range sortedMapKeys(swagger.Paths.Paths) {
+		operations := operationsOf(swagger.Paths.Paths[path])
+		for _, method := range sortedMethods(operations) {
+			operation := operations[method]
+			pointer := "/paths/" + escapePointerToken(path) + "/" + strings.ToLower(method)
+			for i, param := range operation.Parameters {
+				checkParameter(pointer+"/parameters/"+strconv.Itoa(i), param)
+			}
+			if operation.Responses == nil {
+				continue
+			}
+			if operation.Responses.Default != nil {
+				checkResponse(pointer+"/responses/default", *operation.Responses.Default)
+			}
+			codes := make([]int, 0, len(operation.Responses.StatusCodeResponses))
+			for code := range operation.Responses.StatusCodeResponses {
+				codes = append(codes, code)
+			}
+			sort.Ints(codes)
+			for _, code := range codes {
+				checkResponse(pointer+"/responses/"+strconv.Itoa(code), operation.Responses.StatusCodeResponses[code])
+			}
+		}
+	}
+	return violations
+}
+
+func sortedMapKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,88 @@
+package eswagger
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestValidateSpecDocument(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		pointers []string // of the violations, nil when the document is valid
+	}{
+		{"swagger 2.0", `{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},"paths":{"/users":{"get":{"responses":{"200":{"description":"OK","schema":{"$ref":"#/definitions/User"}}}}}},"definitions":{"User":{"type":"object"}}}`, nil},
+		{"yaml", "swagger: \"2.0\"\ninfo:\n  title: Users\n  version: 1.0.0\npaths: {}\n", nil},
+		{"missing info", `{"swagger":"2.0","paths":{}}`, []string{""}},
+		{"response without description", `{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},"paths":{"/users":{"get":{"responses":{"200":{}}}}}}`, []string{"/paths/~1users/get/responses/200"}},
+		{"invalid parameter type", `{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},"paths":{"/users":{"get":{"parameters":[{"name":"q","in":"query","type":"strng"}],"responses":{"200":{"description":"OK"}}}}}}`, []string{"/paths/~1users/get/parameters/0/type"}},
+		{"dangling ref", `{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},"paths":{"/users":{"get":{"responses":{"200":{"description":"OK","schema":{"$ref":"#/definitions/User"}}}}}}}`, []string{"/paths/~1users/get/responses/200/schema/$ref"}},
+		{"empty ref", `{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},"paths":{},"definitions":{"User":{"properties":{"tags":{"$ref":"#/definitions/"}}}}}`, []string{"/definitions/User/properties/tags/$ref"}},
+		{"invalid example", `{"swagger":"2.0","info":{"title":"Users","version":"1.0.0"},"paths":{},"definitions":{"User":{"type":"object","required":["name"],"properties":{"name":{"type":"string"}},"example":{"name":4}}}}`, []string{"/definitions/User/example"}},
+		{"openapi 3.0", `{"openapi":"3.0.3","info":{"title":"Users","version":"1.0.0"},"paths":{"/users":{"get":{"responses":{"200":{"description":"OK","content":{"application/json":{"schema":{"$ref":"#/components/schemas/User"}}}}}}}},"components":{"schemas":{"User":{"type":"object"}}}}`, nil},
+		{"invalid openapi 3.0", `{"openapi":"3.0.3","info":{"title":"Users"},"paths":{}}`, []string{"/info"}},
+		{"openapi 3.1", `{"openapi":"3.1.0","info":{"title":"Users","version":"1.0.0"},"paths":{}}`, nil},
+		{"dangling ref in openapi 3.1", `{"openapi":"3.1.0","info":{"title":"Users","version":"1.0.0"},"paths":{},"components":{"schemas":{"User":{"$ref":"#/components/schemas/Missing"}}}}`, []string{"/components/schemas/User/$ref"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSpecDocument([]byte(tt.document))
+			if tt.pointers == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var violations SpecViolations
+			if !errors.As(err, &violations) {
+				t.Fatalf("err = %v, want violations", err)
+			}
+			var pointers []string
+			for _, violation := range violations {
+				pointers = append(pointers, violation.Pointer)
+			}
+			if !reflect.DeepEqual(pointers, tt.pointers) {
+				t.Errorf("violations at %q, want %q\n%v", pointers, tt.pointers, err)
+			}
+		})
+	}
+}
+
+func TestValidateSpecDocumentErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+	}{
+		{"not yaml", "swagger: [2.0"},
+		{"unsupported version", `{"openapi":"4.0.0","info":{"title":"Users","version":"1.0.0"},"paths":{}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSpecDocument([]byte(tt.document))
+			var violations SpecViolations
+			if err == nil || errors.As(err, &violations) {
+				t.Errorf("err = %v, want a parsing error", err)
+			}
+		})
+	}
+}
+
+func TestGeneratorValidate(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}", noop).Methods("PUT")
+
+	g := NewGenerator(Config{Title: "Users", Version: "1.0.0"})
+	g.RegisterEndpoint("/users/{id}", "PUT", updateUserRequest{}, handleUser{})
+	if _, err := g.GenerateFromRouter(router, RouteMetadata{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Validate(); err != nil {
+		t.Errorf("generated spec is invalid: %v", err)
+	}
+}
@@ -0,0 +1,355 @@
+package eswagger
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-openapi/spec"
+)
+
+// Violation describes a value that does not conform to the documented schema
+type Violation struct {
+	In      string `json:"in"`              // body, path, query or header
+	Field   string `json:"field,omitempty"` // e.g. "items[0].email"
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return fmt.Sprintf("%s: %s", v.In, v.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", v.In, v.Field, v.Message)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+var patternCache sync.Map // pattern -> *regexp.Regexp
+
+// schemaValidator checks decoded JSON values against schemas, resolving
+// "#/definitions/" references through the given definitions
+type schemaValidator struct {
+	definitions spec.Definitions
+	in          string
+	violations  []Violation
+
+	// closed reports properties missing from the schema even when
+	// additionalProperties is not set, used to detect undocumented fields
+	closed bool
+}
+
+func newSchemaValidator(definitions spec.Definitions, in string) *schemaValidator {
+	return &schemaValidator{definitions: definitions, in: in}
+}
+
+func (v *schemaValidator) fail(field, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{In: v.in, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// resolve follows $ref until a concrete schema is found, nil means the
+// reference can't be resolved
+func (v *schemaValidator) resolve(schema *spec.Schema) *spec.Schema {
+	for depth := 0; schema != nil && schema.Ref.String() != ""; depth++ {
+		name := strings.TrimPrefix(schema.Ref.String(), "#/definitions/")
+		definition, ok := v.definitions[name]
+		if !ok || depth > 32 {
+			return nil
+		}
+		schema = &definition
+	}
+	return schema
+}
+
+func (v *schemaValidator) validate(schema *spec.Schema, value interface{}, field string) {
+	if schema = v.resolve(schema); schema == nil {
+		return
+	}
+
+	if value == nil {
+		if !schema.Nullable && len(schema.Type) > 0 && !schema.Type.Contains("null") {
+			v.fail(field, "must not be null")
+		}
+		return
+	}
+
+	if len(schema.Type) > 0 && !v.checkType(schema, value, field) {
+		return
+	}
+
+	if len(schema.Enum) > 0 && !inEnum(schema.Enum, value) {
+		v.fail(field, "must be one of %s", formatEnum(schema.Enum))
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(schema, value, field)
+	case json.Number:
+		if f, err := value.Float64(); err == nil {
+			v.validateNumber(schema, f, field)
+		}
+	case float64:
+		v.validateNumber(schema, value, field)
+	case []interface{}:
+		v.validateArray(schema, value, field)
+	case map[string]interface{}:
+		v.validateObject(schema, value, field)
+	}
+}
+
+func (v *schemaValidator) checkType(schema *spec.Schema, value interface{}, field string) bool {
+	var actual string
+	switch value := value.(type) {
+	case string:
+		actual = "string"
+	case bool:
+		actual = "boolean"
+	case json.Number:
+		actual = "number"
+		if _, err := value.Int64(); err == nil {
+			actual = "integer"
+		}
+	case float64:
+		actual = "number"
+		if value == float64(int64(value)) {
+			actual = "integer"
+		}
+	case []interface{}:
+		actual = "array"
+	case map[string]interface{}:
+		actual = "object"
+	}
+
+	if schema.Type.Contains(actual) || (actual == "integer" && schema.Type.Contains("number")) {
+		return true
+	}
+	v.fail(field, "must be of type %s, got %s", strings.Join(schema.Type, " or "), actual)
+	return false
+}
+
+func (v *schemaValidator) validateString(schema *spec.Schema, value, field string) {
+	length := int64(utf8.RuneCountInString(value))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.fail(field, "must be at least %d characters long", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(field, "must be at most %d characters long", *schema.MaxLength)
+	}
+
+	if schema.Pattern != "" {
+		if re := compilePattern(schema.Pattern); re != nil && !re.MatchString(value) {
+			v.fail(field, "must match pattern %s", schema.Pattern)
+		}
+	}
+
+	if schema.Format != "" && !checkFormat(schema.Format, value) {
+		v.fail(field, "must be a valid %s", schema.Format)
+	}
+}
+
+func (v *schemaValidator) validateNumber(schema *spec.Schema, value float64, field string) {
+	if schema.Minimum != nil {
+		if schema.ExclusiveMinimum && value <= *schema.Minimum {
+			v.fail(field, "must be greater than %v", *schema.Minimum)
+		} else if value < *schema.Minimum {
+			v.fail(field, "must be greater than or equal to %v", *schema.Minimum)
+		}
+	}
+	if schema.Maximum != nil {
+		if schema.ExclusiveMaximum && value >= *schema.Maximum {
+			v.fail(field, "must be less than %v", *schema.Maximum)
+		} else if value > *schema.Maximum {
+			v.fail(field, "must be less than or equal to %v", *schema.Maximum)
+		}
+	}
+
+	switch schema.Format {
+	case "int32":
+		if value < -1<<31 || value > 1<<31-1 {
+			v.fail(field, "must be a valid int32")
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(schema *spec.Schema, value []interface{}, field string) {
+	count := int64(len(value))
+	if schema.MinItems != nil && count < *schema.MinItems {
+		v.fail(field, "must contain at least %d items", *schema.MinItems)
+	}
+	if schema.MaxItems != nil && count > *schema.MaxItems {
+		v.fail(field, "must contain at most %d items", *schema.MaxItems)
+	}
+
+	if schema.Items == nil || schema.Items.Schema == nil {
+		return
+	}
+	for i, item := range value {
+		v.validate(schema.Items.Schema, item, fmt.Sprintf("%s[%d]", field, i))
+	}
+}
+
+func (v *schemaValidator) validateObject(schema *spec.Schema, value map[string]interface{}, field string) {
+	for _, name := range schema.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(joinField(field, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property := value[name]
+		propertySchema, ok := schema.Properties[name]
+		if !ok {
+			if schema.AdditionalProperties != nil && !schema.AdditionalProperties.Allows {
+				v.fail(joinField(field, name), "is not allowed")
+			} else if v.closed && schema.AdditionalProperties == nil && len(schema.Properties) > 0 {
+				v.fail(joinField(field, name), "is not documented")
+			} else if schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+				v.validate(schema.AdditionalProperties.Schema, property, joinField(field, name))
+			}
+			continue
+		}
+		v.validate(&propertySchema, property, joinField(field, name))
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func compilePattern(pattern string) *regexp.Regexp {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	patternCache.Store(pattern, re)
+	return re
+}
+
+// checkFormat validates the string formats we generate, unknown formats pass
+func checkFormat(format, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "email":
+		_, err := mail.ParseAddress(value)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	case "uri":
+		u, err := url.ParseRequestURI(value)
+		return err == nil && u.Scheme != ""
+	case "ipv4":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() != nil
+	case "ipv6":
+		ip := net.ParseIP(value)
+		return ip != nil && ip.To4() == nil
+	default:
+		return true
+	}
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, option := range enum {
+		if fmt.Sprint(option) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	options := make([]string, 0, len(enum))
+	for _, option := range enum {
+		options = append(options, fmt.Sprint(option))
+	}
+	return "[" + strings.Join(options, ", ") + "]"
+}
+
+// parameterSchema builds a schema out of the simple schema and validations of
+// a non-body parameter
+func parameterSchema(param spec.Parameter) *spec.Schema {
+	schema := &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Format:           param.Format,
+			Enum:             param.Enum,
+			Minimum:          param.Minimum,
+			Maximum:          param.Maximum,
+			ExclusiveMinimum: param.ExclusiveMinimum,
+			ExclusiveMaximum: param.ExclusiveMaximum,
+			MinLength:        param.MinLength,
+			MaxLength:        param.MaxLength,
+			Pattern:          param.Pattern,
+			MinItems:         param.MinItems,
+			MaxItems:         param.MaxItems,
+		},
+	}
+	if param.Type != "" {
+		schema.Type = []string{param.Type}
+	}
+	if param.Items != nil {
+		schema.Items = &spec.SchemaOrArray{Schema: &spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Type:    []string{param.Items.Type},
+				Format:  param.Items.Format,
+				Enum:    param.Items.Enum,
+				Pattern: param.Items.Pattern,
+			},
+		}}
+	}
+	return schema
+}
+
+// parameterValue converts the raw string of a parameter to the value its
+// schema expects, so it can go through the same checks as a JSON body
+func parameterValue(schema *spec.Schema, raw string) interface{} {
+	switch {
+	case schema.Type.Contains("integer"):
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return json.Number(strconv.FormatInt(n, 10))
+		}
+	case schema.Type.Contains("number"):
+		if _, err := strconv.ParseFloat(raw, 64); err == nil {
+			return json.Number(raw)
+		}
+	case schema.Type.Contains("boolean"):
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	case schema.Type.Contains("array"):
+		var items []interface{}
+		for _, item := range strings.Split(raw, ",") {
+			if schema.Items != nil && schema.Items.Schema != nil {
+				items = append(items, parameterValue(schema.Items.Schema, item))
+			} else {
+				items = append(items, item)
+			}
+		}
+		return items
+	}
+	return raw
+}
@@ -1,20 +1,15 @@
 package main
 
 import (
-	"encoding/json"
 	"log"
 	"main/eswagger"
 	"main/pkg/model"
-	"main/pkg/service"
+	"main/pkg/router"
 	"net/http"
-
-	"github.com/gorilla/mux"
-	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 func main() {
-	r := mux.NewRouter()
-
+	// doc/swagger.yaml is generated from eswagger.yaml, keep the info in sync
 	swaggerGen := eswagger.NewGenerator(eswagger.Config{
 		Title:       "[REST] User Login API",
 		Description: "This is a simple user login API",
@@ -24,40 +19,24 @@ func main() {
 	})
 
 	var userSvc model.UserInterface
-	// Register routes
-	r.HandleFunc("/users", service.CreateUser(userSvc)).Methods("POST")
-	r.HandleFunc("/users/{id}", service.DeleteUser(userSvc)).Methods("DELETE")
-	r.HandleFunc("/users/{id}", service.UpdateUser(userSvc)).Methods("PUT")
-	r.HandleFunc("/users/CreateUserPointerSliceToPointerResponse", service.CreateUserPointerSliceToPointerResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/NotWork_CreateUserSliceToPointerResponse", service.NotWork_CreateUserSliceToPointerResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/CreateUserStructToPointerResponse", service.CreateUserStructToPointerResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/CreateUserPointerSliceToSliceResponse", service.CreateUserPointerSliceToSliceResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/CreateUserStructToSliceResponse", service.CreateUserStructToSliceResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/CreateUserPointerSliceToNonPointerResponse", service.CreateUserPointerSliceToNonPointerResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/CreateUserStructToNonPointerResponse", service.CreateUserStructToNonPointerResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/CreateUserPointerSliceToNonPointerSliceResponse", service.CreateUserPointerSliceToNonPointerSliceResponse(userSvc)).Methods(http.MethodPost)
-	r.HandleFunc("/users/CreateUserStructToNonPointerSliceResponse", service.CreateUserStructToNonPointerResponse(userSvc)).Methods(http.MethodPost)
+	r, err := router.New(swaggerGen, userSvc)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Generate swagger documentation
-	if err := swaggerGen.GenerateFromRouter(r, eswagger.RouteMetadata{}); err != nil {
+	diagnostics, err := swaggerGen.GenerateFromRouter(r, eswagger.RouteMetadata{})
+	if err != nil {
 		log.Fatal("Failed to generate swagger documentation:", err)
 	}
+	for _, diagnostic := range diagnostics {
+		log.Println("swagger:", diagnostic)
+	}
 
-	// Serve swagger specification
-	r.HandleFunc("/swagger.json", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(swaggerGen.GetSwaggerSpec())
-	})
-
-	// Serve Swagger UI
-	r.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
-		httpSwagger.URL("/swagger.json"),
-		httpSwagger.DeepLinking(true),
-	))
+	// doc/swagger.yaml is written by `go generate ./pkg/router`
 
-	if err := swaggerGen.SaveSwagger("yaml"); err != nil {
-		log.Fatal(err)
-	}
+	// Serve the spec at /swagger.json and /swagger.yaml, and Swagger UI at /swagger/
+	r.PathPrefix("/swagger").Handler(swaggerGen.Handler())
 
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 
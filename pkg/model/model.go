@@ -16,7 +16,10 @@ type UserInterface interface {
 	CreateUserStructToNonPointerResponse(CreateUserStruct) (UserResponse, error)
 
 	// For another type
+
+	// UpdateUser changes the username or the email of a user.
 	UpdateUser(input UpdateUserRequest) (UserResponse, error)
+	// DeleteUser removes a user and its data.
 	DeleteUser(id int) error
 }
 
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"main/eswagger"
+	"main/pkg/model"
+	"main/pkg/service"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+//go:generate go run ../../cmd/eswagger generate -func New -config ../../eswagger.yaml -out ../../doc/swagger.yaml
+
+// New registers the API routes and the service interface they are documented
+// from. userSvc may be nil when only the routes are needed, as when the spec
+// is generated offline.
+func New(swaggerGen *eswagger.Generator, userSvc model.UserInterface) (*mux.Router, error) {
+	if err := swaggerGen.RegisterInterface((*model.UserInterface)(nil)); err != nil {
+		return nil, err
+	}
+
+	r := mux.NewRouter()
+
+	// Register routes
+	r.HandleFunc("/users", service.CreateUser(userSvc)).Methods("POST")
+	r.HandleFunc("/users/{id}", service.DeleteUser(userSvc)).Methods("DELETE")
+	r.HandleFunc("/users/{id}", service.UpdateUser(userSvc)).Methods("PUT")
+	r.HandleFunc("/users/CreateUserPointerSliceToPointerResponse", service.CreateUserPointerSliceToPointerResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/NotWork_CreateUserSliceToPointerResponse", service.NotWork_CreateUserSliceToPointerResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/CreateUserStructToPointerResponse", service.CreateUserStructToPointerResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/CreateUserPointerSliceToSliceResponse", service.CreateUserPointerSliceToSliceResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/CreateUserStructToSliceResponse", service.CreateUserStructToSliceResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/CreateUserPointerSliceToNonPointerResponse", service.CreateUserPointerSliceToNonPointerResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/CreateUserStructToNonPointerResponse", service.CreateUserStructToNonPointerResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/CreateUserPointerSliceToNonPointerSliceResponse", service.CreateUserPointerSliceToNonPointerSliceResponse(userSvc)).Methods(http.MethodPost)
+	r.HandleFunc("/users/CreateUserStructToNonPointerSliceResponse", service.CreateUserStructToNonPointerResponse(userSvc)).Methods(http.MethodPost)
+
+	return r, nil
+}